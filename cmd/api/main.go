@@ -4,6 +4,7 @@ import (
 	"context"
 	"log"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -12,13 +13,25 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
 	httpSwagger "github.com/swaggo/http-swagger"
+	"github.com/cdunlap/djaas/internal/auth"
 	"github.com/cdunlap/djaas/internal/config"
 	"github.com/cdunlap/djaas/internal/database"
+	"github.com/cdunlap/djaas/internal/graph"
 	"github.com/cdunlap/djaas/internal/handler"
+	"github.com/cdunlap/djaas/internal/health"
+	"github.com/cdunlap/djaas/internal/live"
+	"github.com/cdunlap/djaas/internal/metrics"
 	"github.com/cdunlap/djaas/internal/middleware"
+	"github.com/cdunlap/djaas/internal/model"
+	"github.com/cdunlap/djaas/internal/moderation"
+	"github.com/cdunlap/djaas/internal/render"
+	"github.com/cdunlap/djaas/internal/rpc"
 	"github.com/cdunlap/djaas/internal/service"
-	_ "github.com/cdunlap/djaas/docs"
+	"github.com/cdunlap/djaas/internal/telemetry"
+	"github.com/cdunlap/djaas/internal/version"
+	"github.com/cdunlap/djaas/docs"
 )
 
 // @title DJaaS API
@@ -35,10 +48,23 @@ import (
 // @BasePath /api/v1
 // @schemes https http
 
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Token
+
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description JWT from the configured identity provider, as "Bearer <token>"
+
 // @tag.name Jokes
 // @tag.description Endpoints for retrieving jokes
 // @tag.name Tags
 // @tag.description Endpoints for managing tags
+// @tag.name Account
+// @tag.description Endpoints describing the authenticated caller
+// @tag.name Audit
+// @tag.description Record of write and admin operations
 
 func main() {
 	// Load configuration
@@ -63,19 +89,41 @@ func main() {
 		Level: logLevel,
 	}
 
+	// Log records made with a request's context carry its trace ID
 	if cfg.Server.Env == "production" {
-		logger = slog.New(slog.NewJSONHandler(os.Stdout, opts))
+		logger = slog.New(telemetry.NewLogHandler(slog.NewJSONHandler(os.Stdout, opts)))
 	} else {
-		logger = slog.New(slog.NewTextHandler(os.Stdout, opts))
+		logger = slog.New(telemetry.NewLogHandler(slog.NewTextHandler(os.Stdout, opts)))
 	}
 
 	slog.SetDefault(logger)
 
+	build := version.Get()
 	logger.Info("starting dad joke service",
+		"version", build.Version,
+		"commit", build.Commit,
+		"build_time", build.BuildTime,
 		"env", cfg.Server.Env,
 		"port", cfg.Server.Port,
 	)
 
+	// Initialize tracing
+	shutdownTracing, err := telemetry.Setup(context.Background(), telemetry.Config{
+		Exporter:    cfg.Tracing.Exporter,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		logger.Error("failed to initialize tracing", "error", err)
+		os.Exit(1)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Error("failed to flush traces", "error", err)
+		}
+	}()
+
 	// Connect to database with retry logic
 	var dbPool *pgxpool.Pool
 	maxRetries := 5
@@ -89,6 +137,7 @@ func main() {
 			SSLMode:         cfg.Database.SSLMode,
 			MaxConnections:  cfg.Database.MaxConnections,
 			MaxIdleConns:    cfg.Database.MaxIdleConns,
+			Tracer:          telemetry.NewQueryTracer(),
 		}
 
 		dbPool, err = database.Connect(dbCfg, logger)
@@ -113,48 +162,271 @@ func main() {
 	// Initialize database queries
 	queries := database.New(dbPool)
 
+	// Initialize content checker
+	var checker moderation.Checker = moderation.NewDefaultChecker()
+	if cfg.Content.WordListFile != "" {
+		wordList, err := moderation.LoadWordList(cfg.Content.WordListFile)
+		if err != nil {
+			logger.Error("failed to load content word list", "error", err, "path", cfg.Content.WordListFile)
+			os.Exit(1)
+		}
+		checker = wordList
+	}
+
 	// Initialize services
-	jokeService := service.NewJokeService(queries, logger)
+	auditService := service.NewAuditService(queries, logger)
+	jokeHub := live.NewHub()
+	jokeService := service.NewJokeService(queries, dbPool, logger, checker, cfg.Language.Fallbacks, auditService, jokeHub)
+	apiKeyService := service.NewAPIKeyService(queries, dbPool, logger, auditService)
+
+	// Set up rate limits: anonymous callers per IP, authenticated callers by tier
+	tiers := make([]middleware.Tier, 0, len(cfg.RateLimit.Tiers))
+	for name, tier := range cfg.RateLimit.Tiers {
+		tiers = append(tiers, middleware.Tier{
+			Name:       name,
+			Requests:   tier.Requests,
+			Window:     tier.Window,
+			DailyQuota: tier.DailyQuota,
+		})
+	}
+
+	policies := make([]middleware.Policy, 0, len(cfg.RateLimit.Policies))
+	for group, policy := range cfg.RateLimit.Policies {
+		policies = append(policies, middleware.Policy{
+			Name:      group,
+			Requests:  policy.Requests,
+			Window:    policy.Window,
+			Unlimited: policy.Unlimited,
+		})
+	}
+
+	// Register readiness checks
+	checks := health.New(cfg.Server.HealthCheckTimeout)
+	checks.Register(health.Database(dbPool))
+	checks.Register(health.Migrations(dbPool, database.ExpectedSchemaVersion))
+
+	var backend middleware.Backend = middleware.MemoryBackend{MaxEntries: cfg.RateLimit.MaxEntries}
+	if cfg.RateLimit.Backend == "redis" {
+		opts, err := redis.ParseURL(cfg.RateLimit.RedisURL)
+		if err != nil {
+			logger.Error("invalid rate limit Redis URL", "error", err)
+			os.Exit(1)
+		}
+		// Let the backend's per-check timeout cut reads short too
+		opts.ContextTimeoutEnabled = true
+
+		redisClient := redis.NewClient(opts)
+		defer redisClient.Close()
+
+		backend = middleware.NewRedisBackend(redisClient, cfg.RateLimit.RedisPrefix, cfg.RateLimit.RedisTimeout)
+		if cfg.RateLimit.FailOpen {
+			checks.RegisterOptional(health.Redis(redisClient))
+		} else {
+			checks.Register(health.Redis(redisClient))
+		}
+		logger.Info("rate limit state shared via Redis", "addr", opts.Addr, "fail_open", cfg.RateLimit.FailOpen)
+	}
+
+	rateLimiter := middleware.NewRateLimiter(middleware.Tier{
+		Name:     "anonymous",
+		Requests: cfg.RateLimit.Requests,
+		Window:   cfg.RateLimit.Window,
+	}, tiers, cfg.RateLimit.DefaultTier, policies, backend, cfg.RateLimit.FailOpen)
+
+	// Set up metrics
+	appMetrics := metrics.New()
+	appMetrics.RegisterPool(dbPool)
+	appMetrics.RegisterRateLimiter(rateLimiter)
 
 	// Initialize handlers
-	h := handler.New(jokeService, logger, dbPool)
+	var streamLimiter *middleware.RateLimiter
+	if cfg.RateLimit.Enabled {
+		streamLimiter = rateLimiter
+	}
+	h := handler.New(jokeService, logger, dbPool, rateLimiter, appMetrics, auditService, checks, render.Default(), streamLimiter)
+	liveConfig := live.Config{
+		MaxConnections:          cfg.WebSocket.MaxConnections,
+		MaxConnectionsPerCaller: cfg.WebSocket.MaxConnectionsPerCaller,
+		RevealDelay:             cfg.WebSocket.RevealDelay,
+		OriginPatterns:          cfg.WebSocket.AllowedOrigins,
+	}
+	if cfg.RateLimit.Enabled {
+		liveConfig.RateLimiter = rateLimiter
+	}
+	liveServer := live.NewServer(jokeService, jokeHub, appMetrics, logger, liveConfig)
+	appMetrics.RegisterLive(liveServer)
+
+	// Set up authentication: API keys, plus bearer tokens when a JWKS is configured
+	authenticators := []auth.Authenticator{auth.NewAPIKeyAuthenticator(apiKeyService)}
+	if cfg.Auth.JWTEnabled() {
+		var keySource auth.KeySource
+		if cfg.Auth.JWKSFile != "" {
+			jwks, err := auth.LoadJWKSFile(cfg.Auth.JWKSFile)
+			if err != nil {
+				logger.Error("failed to load JWKS", "error", err, "path", cfg.Auth.JWKSFile)
+				os.Exit(1)
+			}
+			keySource = jwks
+		} else {
+			keySource = auth.NewRemoteJWKS(cfg.Auth.JWKSURL, nil, cfg.Auth.JWKSCacheTTL)
+		}
+
+		// Scope and role mappings were validated by config.Load
+		scopeMap := make(map[string][]model.Scope, len(cfg.Auth.JWTScopeMap))
+		for value, scope := range cfg.Auth.JWTScopeMap {
+			scopeMap[value], _ = model.ParseScopeOrRole(scope)
+		}
+
+		authenticators = append(authenticators, auth.NewJWTAuthenticator(keySource, auth.JWTConfig{
+			Issuer:     cfg.Auth.JWTIssuer,
+			Audience:   cfg.Auth.JWTAudience,
+			ScopeClaim: cfg.Auth.JWTScopeClaim,
+			ScopeMap:   scopeMap,
+			Leeway:     cfg.Auth.JWTLeeway,
+			Tier:       cfg.RateLimit.JWTTier,
+		}))
+		logger.Info("bearer token authentication enabled", "issuer", cfg.Auth.JWTIssuer, "audience", cfg.Auth.JWTAudience)
+	}
+
+	// Charge failed authentication attempts to the client address
+	var authThrottle *middleware.AuthThrottle
+	if cfg.RateLimit.Enabled {
+		authThrottle = middleware.NewAuthThrottle(logger, rateLimiter)
+	}
+
+	// Resolve the default content rating ceiling (validated by config.Load)
+	defaultMaxRating, _ := model.ParseContentRating(cfg.Content.DefaultMaxRating)
 
 	// Set up router
 	r := chi.NewRouter()
 
 	// Apply middleware
 	r.Use(middleware.SecurityHeaders())
+	r.Use(middleware.RequestID(logger))
+	r.Use(middleware.ClientIP(cfg.Server.TrustedProxies, cfg.Server.ProxyHeader))
+	r.Use(middleware.Metrics(appMetrics))
+	r.Use(middleware.Tracing())
 	r.Use(middleware.Recovery(logger))
 	r.Use(middleware.Logger(logger))
-
-	// Only apply rate limiting in non-development environments
-	if cfg.Server.Env != "development" {
-		r.Use(middleware.RateLimit(cfg.RateLimit.Requests, cfg.RateLimit.Window))
-		logger.Info("rate limiting enabled", "requests", cfg.RateLimit.Requests, "window", cfg.RateLimit.Window)
+	r.Use(middleware.WebSocketCredentials())
+	r.Use(middleware.Authenticate(logger, authThrottle, authenticators...))
+	r.Use(middleware.AuditActor())
+	r.Use(middleware.RatingCeiling(defaultMaxRating))
+
+	// Rate limit each group of routes by its policy (RATE_LIMIT_POLICIES).
+	// Rate limiting is off in development unless RATE_LIMIT_ENABLED is set.
+	noLimit := func(next http.Handler) http.Handler { return next }
+	rateLimit := func(string) func(http.Handler) http.Handler { return noLimit }
+	searchRateLimit := noLimit
+	if cfg.RateLimit.Enabled {
+		rateLimit = func(group string) func(http.Handler) http.Handler {
+			return middleware.RateLimit(logger, rateLimiter, group)
+		}
+		// Random jokes filtered by a search query count as searches
+		searchRateLimit = middleware.RateLimitFunc(logger, rateLimiter, func(r *http.Request) string {
+			if r.URL.Query().Get("search") != "" {
+				return "search"
+			}
+			return "read"
+		})
+		logger.Info("rate limiting enabled", "requests", cfg.RateLimit.Requests, "window", cfg.RateLimit.Window, "tiers", len(tiers), "policies", len(policies))
 	} else {
-		logger.Info("rate limiting disabled (development mode)")
+		logger.Info("rate limiting disabled", "env", cfg.Server.Env)
 	}
 
 	// Register routes
-	r.Get("/health", h.HandleHealth)
+	r.Group(func(r chi.Router) {
+		r.Use(rateLimit("health"))
+		r.Get("/health", h.HandleHealth)
+		r.Get("/livez", h.HandleLivez)
+		r.Get("/readyz", h.HandleReadyz)
+		r.Get("/version", h.HandleVersion)
+		r.With(middleware.RequireRole(model.RoleAdmin)).Get("/health/details", h.HandleHealthDetails)
+	})
 	r.Route("/api/v1", func(r chi.Router) {
-		r.Get("/joke", h.HandleGetJoke)
-		r.With(middleware.SimpleAuth()).Post("/joke", h.HandleCreateJoke)
-		r.Get("/tags", h.HandleGetTags)
+		r.With(searchRateLimit).Get("/joke", h.HandleGetJoke)
+		r.With(searchRateLimit).Get("/jokes/stream", h.HandleJokeStream)
+		r.With(rateLimit("search")).Get("/jokes/{id}/similar", h.HandleGetSimilarJokes)
+
+		r.Group(func(r chi.Router) {
+			r.Use(rateLimit("read"))
+			r.Get("/jokes/{id}/translations", h.HandleGetJokeTranslations)
+			r.Get("/tags", h.HandleGetTags)
+			r.Get("/usage", h.HandleGetUsage)
+			r.With(middleware.RequireScope()).Get("/ws", liveServer.ServeHTTP)
+		})
+
+		// Contributor routes
+		r.Group(func(r chi.Router) {
+			r.Use(rateLimit("write"))
+			r.Use(middleware.RequireScope(model.ScopeJokesWrite))
+			r.Post("/joke", h.HandleCreateJoke)
+			r.Post("/jokes/import", h.HandleImportJokes)
+		})
+
+		// Moderator routes
+		r.Group(func(r chi.Router) {
+			r.Use(rateLimit("moderate"))
+			r.Use(middleware.RequireScope(model.ScopeModerate))
+			r.Delete("/sources/{source}/jokes", h.HandleDeleteJokesBySource)
+			r.Get("/audit", h.HandleListAudit)
+		})
 	})
 
-	// Swagger documentation
-	r.Get("/swagger/*", httpSwagger.Handler(
+	// GraphQL, for clients that select fields across jokes, tags and
+	// categories in one request; createJoke checks its scope itself, and
+	// mutations and searches are also charged to the write and search policies
+	var graphConfig graph.Config
+	if cfg.RateLimit.Enabled {
+		graphConfig.RateLimiter = rateLimiter
+	}
+	r.With(rateLimit("graphql")).Post("/graphql", graph.New(jokeService, appMetrics, logger, graphConfig).ServeHTTP)
+
+	// Metrics, on the API port unless an admin port is configured
+	var adminServer *http.Server
+	if cfg.Metrics.Enabled && cfg.Metrics.AdminPort != "" {
+		adminRouter := chi.NewRouter()
+		adminRouter.Handle("/metrics", appMetrics.Handler())
+		adminServer = &http.Server{
+			Addr:         ":" + cfg.Metrics.AdminPort,
+			Handler:      adminRouter,
+			ReadTimeout:  15 * time.Second,
+			WriteTimeout: 15 * time.Second,
+		}
+	} else if cfg.Metrics.Enabled {
+		// On the public port, metrics are for admins only
+		r.With(rateLimit("static"), middleware.RequireRole(model.RoleAdmin)).Handle("/metrics", appMetrics.Handler())
+	}
+
+	// gRPC API on its own port, sharing JokeService, the authenticators and
+	// the rate limiter
+	var grpcServer *rpc.Server
+	if cfg.GRPC.Enabled {
+		grpcConfig := rpc.Config{
+			DefaultMaxRating: defaultMaxRating,
+			Reflection:       cfg.GRPC.Reflection,
+		}
+		if cfg.RateLimit.Enabled {
+			grpcConfig.RateLimiter = rateLimiter
+			grpcConfig.AuthThrottle = authThrottle
+		}
+		grpcServer = rpc.NewServer(jokeService, appMetrics, checks, logger, authenticators, grpcConfig)
+	}
+
+	// Swagger documentation, reporting the running build's version
+	docs.SwaggerInfo.Version = build.Version
+	r.With(rateLimit("static")).Get("/swagger/*", httpSwagger.Handler(
 		httpSwagger.URL("/swagger/doc.json"),
 	))
 
 	// Serve static files from public directory
 	fileServer := http.FileServer(http.Dir("public"))
-	r.Get("/*", func(w http.ResponseWriter, r *http.Request) {
+	r.With(rateLimit("static")).Get("/*", func(w http.ResponseWriter, r *http.Request) {
 		fileServer.ServeHTTP(w, r)
 	})
 
-	// Create HTTP server
+	// Create HTTP server. The event stream sets its own write deadlines in
+	// place of WriteTimeout.
 	server := &http.Server{
 		Addr:         ":" + cfg.Server.Port,
 		Handler:      r,
@@ -162,6 +434,7 @@ func main() {
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
+	server.RegisterOnShutdown(h.CloseStreams)
 
 	// Start server in a goroutine
 	serverErrors := make(chan error, 1)
@@ -169,6 +442,24 @@ func main() {
 		logger.Info("server starting", "addr", server.Addr)
 		serverErrors <- server.ListenAndServe()
 	}()
+	if adminServer != nil {
+		go func() {
+			logger.Info("admin server starting", "addr", adminServer.Addr)
+			serverErrors <- adminServer.ListenAndServe()
+		}()
+	}
+	if grpcServer != nil {
+		go func() {
+			addr := ":" + cfg.GRPC.Port
+			logger.Info("grpc server starting", "addr", addr, "reflection", cfg.GRPC.Reflection)
+			lis, err := net.Listen("tcp", addr)
+			if err != nil {
+				serverErrors <- err
+				return
+			}
+			serverErrors <- grpcServer.Serve(lis)
+		}()
+	}
 
 	// Listen for shutdown signals
 	shutdown := make(chan os.Signal, 1)
@@ -182,6 +473,14 @@ func main() {
 	case sig := <-shutdown:
 		logger.Info("shutdown signal received", "signal", sig)
 
+		// Fail readiness first so load balancers stop routing new requests
+		// here before the listener closes
+		checks.SetDraining()
+		if cfg.Server.ShutdownDrainDelay > 0 {
+			logger.Info("draining before shutdown", "delay", cfg.Server.ShutdownDrainDelay)
+			time.Sleep(cfg.Server.ShutdownDrainDelay)
+		}
+
 		// Give outstanding requests 30 seconds to complete
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
@@ -192,6 +491,19 @@ func main() {
 				logger.Error("server close failed", "error", err)
 			}
 		}
+		if err := liveServer.Shutdown(ctx); err != nil {
+			logger.Error("websocket shutdown failed", "error", err)
+		}
+		if grpcServer != nil {
+			if err := grpcServer.Shutdown(ctx); err != nil {
+				logger.Error("grpc server shutdown failed", "error", err)
+			}
+		}
+		if adminServer != nil {
+			if err := adminServer.Shutdown(ctx); err != nil {
+				logger.Error("admin server shutdown failed", "error", err)
+			}
+		}
 
 		logger.Info("server stopped")
 	}
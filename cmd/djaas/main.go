@@ -0,0 +1,305 @@
+// Command djaas provides administrative tasks for a DJaaS deployment, such
+// as managing API keys, and local stand-ins for an identity provider. It
+// reads the same configuration as the API server.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/user"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cdunlap/djaas/internal/audit"
+	"github.com/cdunlap/djaas/internal/config"
+	"github.com/cdunlap/djaas/internal/database"
+	"github.com/cdunlap/djaas/internal/model"
+	"github.com/cdunlap/djaas/internal/service"
+	"github.com/cdunlap/djaas/internal/version"
+)
+
+const usage = `Usage: djaas <command> [arguments]
+
+Commands:
+  keys create   Create an API key
+  keys list     List API keys
+  keys revoke   Revoke an API key
+  jwt keygen    Generate a signing key and JWKS for local bearer token testing
+  jwt sign      Sign a bearer token with a key from jwt keygen
+  version       Print the version, commit and build time
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "keys":
+		err = runKeys(os.Args[2:])
+	case "jwt":
+		err = runJWT(os.Args[2:])
+	case "version":
+		err = printVersion(os.Args[2:])
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runKeys dispatches the keys subcommands
+func runKeys(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("keys requires a subcommand: create, list or revoke")
+	}
+
+	switch args[0] {
+	case "create":
+		return createKey(args[1:])
+	case "list":
+		return listKeys(args[1:])
+	case "revoke":
+		return revokeKey(args[1:])
+	default:
+		return fmt.Errorf("unknown keys subcommand %q", args[0])
+	}
+}
+
+// printVersion prints the build information, or with -short only the version
+func printVersion(args []string) error {
+	fs := flag.NewFlagSet("version", flag.ExitOnError)
+	short := fs.Bool("short", false, "print only the version")
+	fs.Parse(args)
+
+	build := version.Get()
+	if *short {
+		fmt.Println(build.Version)
+		return nil
+	}
+
+	commit := build.Commit
+	if build.Modified {
+		commit += " (modified)"
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Version:\t%s\n", build.Version)
+	fmt.Fprintf(w, "Commit:\t%s\n", commit)
+	fmt.Fprintf(w, "Built:\t%s\n", build.BuildTime)
+	fmt.Fprintf(w, "Go:\t%s\n", build.GoVersion)
+	return w.Flush()
+}
+
+func createKey(args []string) error {
+	fs := flag.NewFlagSet("keys create", flag.ExitOnError)
+	name := fs.String("name", "", "Name describing what the key is used for (required)")
+	owner := fs.String("owner", "", "Person or team responsible for the key (required)")
+	role := fs.String("role", "", "Role granting a bundle of scopes: reader, contributor, moderator, admin")
+	scopes := fs.String("scopes", "", "Comma-separated scopes in addition to the role's: jokes:write, tags:admin, moderate")
+	maxRating := fs.String("max-rating", "", "Content rating ceiling for the key (g, pg, pg13, r); defaults to CONTENT_DEFAULT_MAX_RATING")
+	tier := fs.String("tier", "", "Rate limit tier from RATE_LIMIT_TIERS; defaults to RATE_LIMIT_DEFAULT_TIER")
+	expires := fs.Duration("expires", 0, "Lifetime of the key, e.g. 720h; keys never expire by default")
+	fs.Parse(args)
+
+	if *name == "" || *owner == "" {
+		return fmt.Errorf("-name and -owner are required")
+	}
+
+	input := service.NewAPIKey{
+		Name:  *name,
+		Owner: *owner,
+	}
+	if *role != "" {
+		parsed, err := model.ParseRole(*role)
+		if err != nil {
+			return err
+		}
+		input.Scopes = parsed.Scopes()
+	}
+	for _, s := range strings.Split(*scopes, ",") {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		scope, err := model.ParseScope(s)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(input.Scopes, scope) {
+			input.Scopes = append(input.Scopes, scope)
+		}
+	}
+	if *maxRating != "" {
+		rating, err := model.ParseContentRating(*maxRating)
+		if err != nil {
+			return err
+		}
+		input.MaxRating = &rating
+	}
+	if *expires < 0 {
+		return fmt.Errorf("-expires must be positive")
+	}
+	if *expires > 0 {
+		expiresAt := time.Now().Add(*expires)
+		input.ExpiresAt = &expiresAt
+	}
+
+	keys, cfg, cleanup, err := connect()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	input.Tier = cfg.RateLimit.DefaultTier
+	if *tier != "" {
+		if _, ok := cfg.RateLimit.Tiers[*tier]; !ok {
+			return fmt.Errorf("tier %q is not defined in RATE_LIMIT_TIERS", *tier)
+		}
+		input.Tier = *tier
+	}
+
+	token, key, err := keys.CreateKey(operatorContext(), input)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Created key %d (%s) for %s in tier %s\n", key.ID, key.Name, key.Owner, key.Tier)
+	fmt.Printf("\n  %s\n\n", token)
+	fmt.Println("Store this key now; it cannot be shown again.")
+	return nil
+}
+
+func listKeys(args []string) error {
+	fs := flag.NewFlagSet("keys list", flag.ExitOnError)
+	fs.Parse(args)
+
+	keys, _, cleanup, err := connect()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	list, err := keys.ListKeys(context.Background())
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tOWNER\tPREFIX\tROLE\tSCOPES\tTIER\tMAX RATING\tSTATUS\tLAST USED")
+	for _, key := range list {
+		scopes := make([]string, 0, len(key.Scopes))
+		for _, scope := range key.Scopes {
+			scopes = append(scopes, string(scope))
+		}
+		maxRating := "-"
+		if key.MaxRating != nil {
+			maxRating = string(*key.MaxRating)
+		}
+		lastUsed := "never"
+		if key.LastUsedAt != nil {
+			lastUsed = key.LastUsedAt.Format(time.RFC3339)
+		}
+
+		role := (&model.Principal{Scopes: key.Scopes}).Role()
+
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			key.ID, key.Name, key.Owner, key.Prefix, role, strings.Join(scopes, ","), key.Tier, maxRating, keyStatus(key), lastUsed)
+	}
+	return tw.Flush()
+}
+
+func revokeKey(args []string) error {
+	fs := flag.NewFlagSet("keys revoke", flag.ExitOnError)
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: djaas keys revoke <id>")
+	}
+	id, err := strconv.ParseInt(fs.Arg(0), 10, 32)
+	if err != nil || id <= 0 {
+		return fmt.Errorf("invalid key ID %q", fs.Arg(0))
+	}
+
+	keys, _, cleanup, err := connect()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	if err := keys.RevokeKey(operatorContext(), int32(id)); err != nil {
+		return err
+	}
+
+	fmt.Printf("Revoked key %d\n", id)
+	return nil
+}
+
+// keyStatus describes whether a key can currently be used
+func keyStatus(key model.APIKey) string {
+	switch {
+	case key.RevokedAt != nil:
+		return "revoked"
+	case key.ExpiresAt != nil && time.Now().After(*key.ExpiresAt):
+		return "expired"
+	case key.ExpiresAt != nil:
+		return "expires " + key.ExpiresAt.Format(time.RFC3339)
+	default:
+		return "active"
+	}
+}
+
+// operatorContext identifies the person running the command to the audit
+// log, as "cli:<username>"
+func operatorContext() context.Context {
+	name := os.Getenv("USER")
+	if current, err := user.Current(); err == nil {
+		name = current.Username
+	}
+	if name == "" {
+		name = "unknown"
+	}
+	return audit.WithActor(context.Background(), audit.Actor{Subject: "cli:" + name})
+}
+
+// connect opens the database configured by the environment and returns an
+// API key service backed by it, along with the configuration
+func connect() (*service.APIKeyService, *config.Config, func(), error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	pool, err := database.Connect(database.Config{
+		Host:           cfg.Database.Host,
+		Port:           cfg.Database.Port,
+		User:           cfg.Database.User,
+		Password:       cfg.Database.Password,
+		DBName:         cfg.Database.DBName,
+		SSLMode:        cfg.Database.SSLMode,
+		MaxConnections: 2,
+		MaxIdleConns:   0,
+	}, logger)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	queries := database.New(pool)
+	keys := service.NewAPIKeyService(queries, pool, logger, service.NewAuditService(queries, logger))
+	return keys, cfg, func() { database.Close(pool) }, nil
+}
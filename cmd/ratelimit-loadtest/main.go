@@ -0,0 +1,117 @@
+// Command ratelimit-loadtest hammers the rate limiter store with random IP
+// addresses and reports its size and the heap once a second. With a bounded
+// store the entry count levels off at the configured maximum and the heap
+// stays flat however many distinct addresses are seen.
+//
+// It exits non-zero if the store exceeds its bound or the heap keeps
+// growing after the warmup period.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"net/netip"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cdunlap/djaas/internal/middleware"
+)
+
+func main() {
+	duration := flag.Duration("duration", 30*time.Second, "how long to run")
+	warmup := flag.Duration("warmup", 5*time.Second, "time allowed for the store to fill; the largest heap seen in it is the baseline")
+	workers := flag.Int("workers", runtime.GOMAXPROCS(0), "number of concurrent callers")
+	maxEntries := flag.Int("max-entries", 100000, "store capacity, as RATE_LIMIT_MAX_ENTRIES")
+	requests := flag.Int("requests", 60, "requests allowed per window")
+	window := flag.Duration("window", time.Minute, "rate limit window")
+	growth := flag.Float64("max-growth", 0.25, "largest tolerated heap growth over the baseline, as a fraction")
+	flag.Parse()
+
+	store := middleware.NewLimiterStore(*requests, *window, *maxEntries)
+	ctx := context.Background()
+
+	var calls atomic.Uint64
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for range *workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				_, _ = store.Allow(ctx, randomIP())
+				calls.Add(1)
+			}
+		}()
+	}
+
+	fmt.Printf("%8s %12s %10s %12s %12s %10s\n", "ELAPSED", "CALLS", "ENTRIES", "EVICTIONS", "EXPIRATIONS", "HEAP_MB")
+
+	start := time.Now()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	var baseline, peak uint64
+	failed := false
+	for now := range ticker.C {
+		elapsed := now.Sub(start)
+		stats := store.Stats()
+		heap := heapAlloc()
+
+		fmt.Printf("%8s %12d %10d %12d %12d %10.1f\n",
+			elapsed.Truncate(time.Second), calls.Load(), stats.Entries, stats.Evictions, stats.Expirations, float64(heap)/(1<<20))
+
+		if stats.Entries > *maxEntries {
+			fmt.Fprintf(os.Stderr, "store holds %d entries, above its bound of %d\n", stats.Entries, *maxEntries)
+			failed = true
+		}
+		// Workers keep allocating while the heap is sampled, so samples
+		// jitter; compare peaks rather than single readings
+		if elapsed <= *warmup {
+			baseline = max(baseline, heap)
+		} else {
+			peak = max(peak, heap)
+		}
+		if elapsed >= *duration {
+			break
+		}
+	}
+
+	close(stop)
+	wg.Wait()
+
+	if peak > 0 && float64(peak) > float64(baseline)*(1+*growth) {
+		fmt.Fprintf(os.Stderr, "heap grew from %.1f MB to %.1f MB after warmup\n", float64(baseline)/(1<<20), float64(peak)/(1<<20))
+		failed = true
+	}
+	if failed {
+		os.Exit(1)
+	}
+	fmt.Printf("ok: heap stayed within %.0f%% of %.1f MB\n", *growth*100, float64(baseline)/(1<<20))
+}
+
+// randomIP returns a uniformly random IPv4 address, so nearly every call
+// creates a new store entry
+func randomIP() string {
+	var b [4]byte
+	v := rand.Uint32()
+	b[0], b[1], b[2], b[3] = byte(v>>24), byte(v>>16), byte(v>>8), byte(v)
+	return netip.AddrFrom4(b).String()
+}
+
+// heapAlloc returns the live heap size after a garbage collection
+func heapAlloc() uint64 {
+	runtime.GC()
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return m.HeapAlloc
+}
@@ -23,15 +23,124 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/joke": {
+        "/audit": {
             "get": {
-                "description": "Retrieve a random joke with optional filtering by search query, category, and tags",
+                "description": "List write and admin operations, newest first: who performed them, on what, from where, and the target before and after.\nPass next_cursor from a response as cursor to fetch the following page.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "Audit"
+                ],
+                "summary": "List audit events",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Caller subject, e.g. key:12 or jwt:alice",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Action, e.g. joke.create, joke.delete, tag.create, api_key.create, api_key.revoke",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Target type: joke, tag or api_key",
+                        "name": "target_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Target ID (joke or key ID, or tag name); requires target_type",
+                        "name": "target_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only events at or after this RFC 3339 time",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only events before this RFC 3339 time",
+                        "name": "until",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Events per page (1-200, default 50)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor from the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.AuditEventList"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid filter, limit or cursor",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing the moderate scope",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/joke": {
+            "get": {
+                "description": "Retrieve a random joke with optional filtering by search query, category, and tags.\nJokes in the caller's preferred language are served first, then the configured fallback languages.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/plain",
+                    "text/html",
+                    "text/markdown",
+                    "application/ssml+xml"
+                ],
                 "tags": [
                     "Jokes"
                 ],
@@ -54,6 +163,42 @@ const docTemplate = `{
                         "description": "Comma-separated list of tags (e.g., 'wordplay,puns')",
                         "name": "tags",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Maximum content rating (g, pg, pg13, r); capped by the caller's ceiling",
+                        "name": "max_rating",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Preferred language code (e.g., 'en', 'es', 'de'); overrides Accept-Language",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Preferred languages, negotiated when lang is not set",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Joke type (setup_punchline, one_liner, knock_knock, multi_part)",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Source name filter (e.g., 'reddit')",
+                        "name": "source",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Response format (json, text, html, markdown, ssml); overrides Accept",
+                        "name": "format",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -61,6 +206,18 @@ const docTemplate = `{
                         "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/model.Joke"
+                        },
+                        "headers": {
+                            "Content-Language": {
+                                "type": "string",
+                                "description": "Language of the returned joke"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid max_rating, lang, type or format",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
                         }
                     },
                     "404": {
@@ -69,6 +226,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
                     },
+                    "406": {
+                        "description": "No acceptable format",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -78,7 +241,7 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "Add a new joke to the database with optional category and tags",
+                "description": "Add a new joke to the database with optional category, tags, content rating and content warnings.\nSet language and translation_of to add a translation of an existing joke.\nOne-liners, knock-knock and multi-part jokes are submitted as ordered parts; setup and punchline are derived from them.\nAuthor, source and license fields record where the joke came from.\nSubmissions containing terms above the declared rating are flagged and re-rated.",
                 "consumes": [
                     "application/json"
                 ],
@@ -113,18 +276,38 @@ const docTemplate = `{
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
                     },
+                    "401": {
+                        "description": "Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing the jokes:write scope",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
                     }
-                }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ]
             }
         },
-        "/tags": {
-            "get": {
-                "description": "Retrieve a list of all available tags",
+        "/jokes/import": {
+            "post": {
+                "description": "Add a batch of up to 500 jokes, typically from a single source.\nTop-level author, source and license fields are applied to jokes that do not set their own.\nInvalid jokes are skipped and reported by index; the rest are still created.",
                 "consumes": [
                     "application/json"
                 ],
@@ -132,92 +315,895 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Tags"
+                    "Jokes"
+                ],
+                "summary": "Import jokes",
+                "parameters": [
+                    {
+                        "description": "Jokes to import",
+                        "name": "import",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.ImportJokesRequest"
+                        }
+                    }
                 ],
-                "summary": "Get all tags",
                 "responses": {
                     "200": {
-                        "description": "List of tags",
+                        "description": "Created joke IDs and per-joke errors",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "array",
-                                "items": {
-                                    "type": "string"
-                                }
-                            }
+                            "$ref": "#/definitions/model.ImportJokesResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing the jokes:write scope",
                         "schema": {
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
                     }
-                }
-            }
-        }
-    },
-    "definitions": {
-        "handler.CreateJokeRequest": {
-            "type": "object",
-            "properties": {
-                "category": {
-                    "type": "string"
-                },
-                "punchline": {
-                    "type": "string"
-                },
-                "setup": {
-                    "type": "string"
                 },
-                "tags": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/jokes/stream": {
+            "get": {
+                "description": "Server-Sent Events stream that pushes a random joke every interval, with the same filters as GET /joke.\nEach joke is a \"joke\" event whose data is the joke as JSON. Heartbeat comments are sent every 15 seconds.\nReconnecting with Last-Event-ID resumes the schedule without repeating the last joke.\nEach joke counts against the caller's rate limit and daily quota; a rejected joke ends the stream with an error event.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Jokes"
+                ],
+                "summary": "Stream jokes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Time between jokes, as seconds or a duration such as 1m (5s-1h, default 30s)",
+                        "name": "interval",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search query to filter jokes",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Category filter (e.g., 'general', 'food', 'science')",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated list of tags (e.g., 'wordplay,puns')",
+                        "name": "tags",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Maximum content rating (g, pg, pg13, r); capped by the caller's ceiling",
+                        "name": "max_rating",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Preferred language code (e.g., 'en', 'es', 'de'); overrides Accept-Language",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Joke type (setup_punchline, one_liner, knock_knock, multi_part)",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Source name filter (e.g., 'reddit')",
+                        "name": "source",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the last event received, to resume a stream",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Stream of joke events",
+                        "schema": {
+                            "$ref": "#/definitions/model.Joke"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid interval, max_rating, lang or type",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No jokes found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "model.ErrorResponse": {
-            "type": "object",
+        "/jokes/{id}/similar": {
+            "get": {
+                "description": "Retrieve jokes related to the given joke, ranked by shared tags, same category and text similarity",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/plain",
+                    "text/html",
+                    "text/markdown",
+                    "application/ssml+xml"
+                ],
+                "tags": [
+                    "Jokes"
+                ],
+                "summary": "Get similar jokes",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Joke ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of jokes to return (1-20, default 5)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Maximum content rating (g, pg, pg13, r); capped by the caller's ceiling",
+                        "name": "max_rating",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Response format (json, text, html, markdown, ssml); overrides Accept",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.SimilarJokesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid joke ID, limit, max_rating or format",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Joke not found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "406": {
+                        "description": "No acceptable format",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/jokes/{id}/translations": {
+            "get": {
+                "description": "Retrieve the equivalent jokes in other languages",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/plain",
+                    "text/html",
+                    "text/markdown",
+                    "application/ssml+xml"
+                ],
+                "tags": [
+                    "Jokes"
+                ],
+                "summary": "Get joke translations",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Joke ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Maximum content rating (g, pg, pg13, r); capped by the caller's ceiling",
+                        "name": "max_rating",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Response format (json, text, html, markdown, ssml); overrides Accept",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.TranslationsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid joke ID, max_rating or format",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Joke not found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "406": {
+                        "description": "No acceptable format",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/sources/{source}/jokes": {
+            "delete": {
+                "description": "Remove every joke imported from the given source, e.g. to honour a takedown request",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Jokes"
+                ],
+                "summary": "Delete jokes by source",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Source name",
+                        "name": "source",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Number of jokes deleted",
+                        "schema": {
+                            "$ref": "#/definitions/model.DeleteSourceResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid source",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing the moderate scope",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/tags": {
+            "get": {
+                "description": "Retrieve a list of all available tags",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tags"
+                ],
+                "summary": "Get all tags",
+                "responses": {
+                    "200": {
+                        "description": "List of tags",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/usage": {
+            "get": {
+                "description": "Show the caller's rate limit tier, burst limit and how much of today's quota is left.\nQuotas reset at midnight UTC.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Account"
+                ],
+                "summary": "Get API usage",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Usage"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Rate limit backend unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        }
+    },
+    "definitions": {
+        "handler.CreateJokeRequest": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "type": "string"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "content_warnings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "language": {
+                    "type": "string"
+                },
+                "license": {
+                    "type": "string"
+                },
+                "parts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.JokePart"
+                    }
+                },
+                "punchline": {
+                    "type": "string"
+                },
+                "rating": {
+                    "type": "string"
+                },
+                "setup": {
+                    "type": "string"
+                },
+                "source_name": {
+                    "type": "string"
+                },
+                "source_url": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "translation_of": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "handler.ImportJokesRequest": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "type": "string"
+                },
+                "jokes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.CreateJokeRequest"
+                    }
+                },
+                "license": {
+                    "type": "string"
+                },
+                "source_name": {
+                    "type": "string"
+                },
+                "source_url": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.AuditEvent": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "joke.create"
+                },
+                "actor": {
+                    "description": "Actor is the caller's subject, e.g. \"key:12\" or \"jwt:alice\"",
+                    "type": "string"
+                },
+                "after": {
+                    "type": "object"
+                },
+                "before": {
+                    "description": "Before and After are the target as it was before and after the\noperation; either is omitted when the target did not exist",
+                    "type": "object"
+                },
+                "client_ip": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "occurred_at": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "target_id": {
+                    "type": "string",
+                    "example": "42"
+                },
+                "target_type": {
+                    "type": "string",
+                    "example": "joke"
+                }
+            }
+        },
+        "model.AuditEventList": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.AuditEvent"
+                    }
+                },
+                "next_cursor": {
+                    "description": "NextCursor fetches the next page when passed as cursor; it is omitted\non the last page",
+                    "type": "string"
+                }
+            }
+        },
+        "model.ContentRating": {
+            "type": "string",
+            "enum": [
+                "g",
+                "pg",
+                "pg13",
+                "r"
+            ],
+            "x-enum-varnames": [
+                "RatingG",
+                "RatingPG",
+                "RatingPG13",
+                "RatingR"
+            ]
+        },
+        "model.DeleteSourceResponse": {
+            "type": "object",
+            "properties": {
+                "deleted": {
+                    "type": "integer"
+                },
+                "source": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.ErrorResponse": {
+            "type": "object",
             "properties": {
                 "error": {
                     "type": "string"
                 },
                 "message": {
                     "type": "string"
+                },
+                "request_id": {
+                    "description": "RequestID matches the X-Request-ID response header and the server logs",
+                    "type": "string"
+                }
+            }
+        },
+        "model.ImportError": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "description": "RequestID matches the X-Request-ID response header and the server logs",
+                    "type": "string"
+                }
+            }
+        },
+        "model.ImportJokesResponse": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ImportError"
+                    }
                 }
             }
         },
         "model.Joke": {
             "type": "object",
             "properties": {
+                "author": {
+                    "type": "string"
+                },
                 "category": {
                     "type": "string"
                 },
+                "content_warnings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "created_at": {
                     "type": "string"
                 },
+                "flagged": {
+                    "type": "boolean"
+                },
                 "id": {
                     "type": "integer"
                 },
+                "language": {
+                    "type": "string"
+                },
+                "license": {
+                    "type": "string"
+                },
+                "parts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.JokePart"
+                    }
+                },
                 "punchline": {
                     "type": "string"
                 },
+                "rating": {
+                    "$ref": "#/definitions/model.ContentRating"
+                },
                 "setup": {
                     "type": "string"
                 },
+                "source_name": {
+                    "type": "string"
+                },
+                "source_url": {
+                    "type": "string"
+                },
                 "tags": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
+                "translation_of": {
+                    "type": "integer"
+                },
+                "type": {
+                    "$ref": "#/definitions/model.JokeType"
+                },
                 "updated_at": {
                     "type": "string"
                 }
             }
+        },
+        "model.JokePart": {
+            "type": "object",
+            "properties": {
+                "speaker": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.JokeType": {
+            "type": "string",
+            "enum": [
+                "setup_punchline",
+                "one_liner",
+                "knock_knock",
+                "multi_part"
+            ],
+            "x-enum-varnames": [
+                "TypeSetupPunchline",
+                "TypeOneLiner",
+                "TypeKnockKnock",
+                "TypeMultiPart"
+            ]
+        },
+        "model.SimilarJoke": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "type": "string"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "content_warnings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "flagged": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "license": {
+                    "type": "string"
+                },
+                "parts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.JokePart"
+                    }
+                },
+                "punchline": {
+                    "type": "string"
+                },
+                "rating": {
+                    "$ref": "#/definitions/model.ContentRating"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "setup": {
+                    "type": "string"
+                },
+                "source_name": {
+                    "type": "string"
+                },
+                "source_url": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "translation_of": {
+                    "type": "integer"
+                },
+                "type": {
+                    "$ref": "#/definitions/model.JokeType"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.SimilarJokesResponse": {
+            "type": "object",
+            "properties": {
+                "joke_id": {
+                    "type": "integer"
+                },
+                "jokes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.SimilarJoke"
+                    }
+                }
+            }
+        },
+        "model.TranslationsResponse": {
+            "type": "object",
+            "properties": {
+                "joke_id": {
+                    "type": "integer"
+                },
+                "translations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Joke"
+                    }
+                }
+            }
+        },
+        "model.Usage": {
+            "type": "object",
+            "properties": {
+                "daily_quota": {
+                    "description": "DailyQuota is 0 for tiers without a daily quota",
+                    "type": "integer"
+                },
+                "enforced": {
+                    "description": "Enforced is false when rate limiting is disabled, e.g. in development",
+                    "type": "boolean"
+                },
+                "remaining_today": {
+                    "description": "RemainingToday is omitted for tiers without a daily quota",
+                    "type": "integer"
+                },
+                "requests": {
+                    "description": "Requests and Window describe the burst limit",
+                    "type": "integer"
+                },
+                "resets_at": {
+                    "type": "string"
+                },
+                "subject": {
+                    "type": "string"
+                },
+                "tier": {
+                    "type": "string"
+                },
+                "used_today": {
+                    "type": "integer"
+                },
+                "window": {
+                    "type": "string"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "type": "apiKey",
+            "name": "X-API-Token",
+            "in": "header"
+        },
+        "BearerAuth": {
+            "description": "JWT from the configured identity provider, as \"Bearer \u003ctoken\u003e\"",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
         }
     },
     "tags": [
@@ -228,6 +1214,14 @@ const docTemplate = `{
         {
             "description": "Endpoints for managing tags",
             "name": "Tags"
+        },
+        {
+            "description": "Endpoints describing the authenticated caller",
+            "name": "Account"
+        },
+        {
+            "description": "Record of write and admin operations",
+            "name": "Audit"
         }
     ]
 }`
@@ -0,0 +1,51 @@
+// Package audit identifies who performs an operation, so that the services
+// can record write and admin operations without depending on HTTP.
+package audit
+
+import "context"
+
+// Action names an audited operation
+type Action string
+
+const (
+	ActionJokeCreate   Action = "joke.create"
+	ActionJokeDelete   Action = "joke.delete"
+	ActionTagCreate    Action = "tag.create"
+	ActionAPIKeyCreate Action = "api_key.create"
+	ActionAPIKeyRevoke Action = "api_key.revoke"
+)
+
+// Target types of audited operations
+const (
+	TargetJoke   = "joke"
+	TargetTag    = "tag"
+	TargetAPIKey = "api_key"
+)
+
+// SystemActor is recorded for operations with no actor in their context
+const SystemActor = "system"
+
+// Actor performs an operation
+type Actor struct {
+	// Subject identifies the caller, e.g. "key:12", "jwt:alice" or "cli:root"
+	Subject string
+	// ClientIP and RequestID locate the HTTP request, if there was one
+	ClientIP  string
+	RequestID string
+}
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx carrying actor
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor carried by ctx, or SystemActor if
+// there is none
+func ActorFromContext(ctx context.Context) Actor {
+	if actor, ok := ctx.Value(actorKey{}).(Actor); ok {
+		return actor
+	}
+	return Actor{Subject: SystemActor}
+}
@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/cdunlap/djaas/internal/model"
+	"github.com/cdunlap/djaas/internal/service"
+)
+
+// KeyResolver resolves an API key to the principal it belongs to
+type KeyResolver interface {
+	AuthenticateKey(ctx context.Context, token string) (*model.Principal, error)
+}
+
+// APIKeyAuthenticator authenticates requests carrying an X-API-Token header
+type APIKeyAuthenticator struct {
+	keys KeyResolver
+}
+
+// NewAPIKeyAuthenticator creates an APIKeyAuthenticator backed by keys
+func NewAPIKeyAuthenticator(keys KeyResolver) *APIKeyAuthenticator {
+	return &APIKeyAuthenticator{keys: keys}
+}
+
+// Authenticate implements Authenticator
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (*model.Principal, error) {
+	token := r.Header.Get("X-API-Token")
+	if token == "" {
+		return nil, ErrNoCredentials
+	}
+
+	principal, err := a.keys.AuthenticateKey(r.Context(), token)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidAPIKey) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	return principal, nil
+}
@@ -0,0 +1,26 @@
+// Package auth identifies the callers of API requests. Each supported
+// credential type is implemented as an Authenticator.
+package auth
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/cdunlap/djaas/internal/model"
+)
+
+var (
+	// ErrNoCredentials means the request carries no credentials of the kind
+	// an Authenticator understands, so the next one should be tried
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrInvalidCredentials means credentials were presented but rejected
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Authenticator identifies the caller of a request from its credentials.
+// It returns ErrNoCredentials when the request carries none of its
+// credentials and ErrInvalidCredentials when they are rejected; any other
+// error means the credentials could not be checked.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*model.Principal, error)
+}
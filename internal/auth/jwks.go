@@ -0,0 +1,315 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrUnknownKey is returned by a KeySource that has no key with a given ID
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// KeySource provides the public keys used to verify token signatures
+type KeySource interface {
+	PublicKey(ctx context.Context, kid string) (crypto.PublicKey, error)
+}
+
+// JWK is a single JSON Web Key (RFC 7517). Only public signing keys of type
+// RSA, EC and OKP (Ed25519) are supported.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC and OKP
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS is a static set of public keys indexed by key ID
+type JWKS struct {
+	keys map[string]crypto.PublicKey
+}
+
+// ParseJWKS parses a JSON Web Key Set document. Keys not meant for
+// signatures, and keys of types or curves that cannot be used, are skipped;
+// it fails only if no usable key is left.
+func ParseJWKS(data []byte) (*JWKS, error) {
+	var doc struct {
+		Keys []JWK `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid JWKS document: %w", err)
+	}
+
+	set := &JWKS{keys: make(map[string]crypto.PublicKey, len(doc.Keys))}
+	var skipped error
+	for i, jwk := range doc.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.PublicKey()
+		if err != nil {
+			skipped = errors.Join(skipped, fmt.Errorf("JWKS key %d: %w", i, err))
+			continue
+		}
+		set.keys[jwk.Kid] = key
+	}
+
+	if len(set.keys) == 0 {
+		if skipped != nil {
+			return nil, fmt.Errorf("JWKS contains no usable signing keys: %w", skipped)
+		}
+		return nil, errors.New("JWKS contains no signing keys")
+	}
+	return set, nil
+}
+
+// LoadJWKSFile reads a JSON Web Key Set from a local file
+func LoadJWKSFile(path string) (*JWKS, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS file: %w", err)
+	}
+	return ParseJWKS(data)
+}
+
+// PublicKey implements KeySource. A token without a key ID is accepted when
+// the set holds exactly one key.
+func (s *JWKS) PublicKey(_ context.Context, kid string) (crypto.PublicKey, error) {
+	if key, ok := s.keys[kid]; ok {
+		return key, nil
+	}
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, nil
+		}
+	}
+	return nil, ErrUnknownKey
+}
+
+// PublicKey decodes the key material of a JWK
+func (k JWK) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBase64URL(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA modulus: %w", err)
+		}
+		e, err := decodeBase64URL(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA exponent: %w", err)
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported EC curve %q", k.Crv)
+		}
+		x, err := decodeBase64URL(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EC x coordinate: %w", err)
+		}
+		y, err := decodeBase64URL(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EC y coordinate: %w", err)
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(key.X, key.Y) {
+			return nil, errors.New("EC point is not on the curve")
+		}
+		return key, nil
+
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported OKP curve %q", k.Crv)
+		}
+		x, err := decodeBase64URL(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 public key")
+		}
+		return ed25519.PublicKey(x), nil
+
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBase64URL(s string) ([]byte, error) {
+	if s == "" {
+		return nil, errors.New("missing value")
+	}
+	return base64.RawURLEncoding.DecodeString(s)
+}
+
+// jwksFetchTimeout bounds each fetch of a remote key set
+const jwksFetchTimeout = 10 * time.Second
+
+// RemoteJWKS fetches a JSON Web Key Set from a URL, such as an identity
+// provider's jwks_uri, and caches it. The set is refetched once the cache
+// expires, or early when a token names an unknown key. Fetches happen at
+// most once per minRefresh so that forged key IDs or an unavailable
+// provider cannot cause a fetch per request, and concurrent callers share
+// one fetch.
+type RemoteJWKS struct {
+	url        string
+	client     *http.Client
+	ttl        time.Duration
+	minRefresh time.Duration
+
+	mu          sync.Mutex
+	set         *JWKS
+	fetchedAt   time.Time
+	lastAttempt time.Time
+	lastErr     error
+	// fetching is closed when the fetch in flight ends; nil when none is
+	fetching chan struct{}
+}
+
+// NewRemoteJWKS creates a RemoteJWKS that caches the key set for ttl
+func NewRemoteJWKS(url string, client *http.Client, ttl time.Duration) *RemoteJWKS {
+	if client == nil {
+		client = &http.Client{Timeout: jwksFetchTimeout}
+	}
+	return &RemoteJWKS{
+		url:        url,
+		client:     client,
+		ttl:        ttl,
+		minRefresh: time.Minute,
+	}
+}
+
+// PublicKey implements KeySource
+func (s *RemoteJWKS) PublicKey(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	s.mu.Lock()
+	missing := s.set == nil
+	stale := missing || time.Since(s.fetchedAt) > s.ttl
+	s.mu.Unlock()
+	if stale {
+		// A stale set is still used if the refetch fails, or while another
+		// caller's refetch is in flight
+		s.refreshThrottled(ctx, missing)
+	}
+
+	set, lastErr := s.current()
+	if set == nil {
+		return nil, lastErr
+	}
+
+	key, err := set.PublicKey(ctx, kid)
+	if errors.Is(err, ErrUnknownKey) && s.refreshThrottled(ctx, true) {
+		// The provider may have rotated its keys since the last fetch
+		set, _ = s.current()
+		return set.PublicKey(ctx, kid)
+	}
+	return key, err
+}
+
+// current returns the cached set and the error of the last fetch
+func (s *RemoteJWKS) current() (*JWKS, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.set, s.lastErr
+}
+
+// refreshThrottled refetches the key set unless a fetch was attempted
+// within minRefresh, and reports whether a new set was fetched. A caller
+// arriving during a fetch does not start another: it waits for that fetch
+// if wait is set, and otherwise returns false. The fetch is made without
+// holding s.mu, so requests with cached keys are not held up by it.
+func (s *RemoteJWKS) refreshThrottled(ctx context.Context, wait bool) bool {
+	s.mu.Lock()
+	if fetching := s.fetching; fetching != nil {
+		s.mu.Unlock()
+		if !wait {
+			return false
+		}
+		select {
+		case <-fetching:
+		case <-ctx.Done():
+			return false
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.lastErr == nil
+	}
+	if !s.lastAttempt.IsZero() && time.Since(s.lastAttempt) < s.minRefresh {
+		s.mu.Unlock()
+		return false
+	}
+	s.lastAttempt = time.Now()
+	done := make(chan struct{})
+	s.fetching = done
+	s.mu.Unlock()
+
+	// The fetch serves every caller until minRefresh passes, so it must
+	// not fail because the request that started it was cancelled
+	fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), jwksFetchTimeout)
+	set, err := s.fetch(fetchCtx)
+	cancel()
+
+	s.mu.Lock()
+	if err == nil {
+		s.set = set
+		s.fetchedAt = time.Now()
+	}
+	s.lastErr = err
+	s.fetching = nil
+	s.mu.Unlock()
+	close(done)
+	return err == nil
+}
+
+// fetch downloads and parses the key set
+func (s *RemoteJWKS) fetch(ctx context.Context) (*JWKS, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build JWKS request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: unexpected status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS: %w", err)
+	}
+
+	return ParseJWKS(data)
+}
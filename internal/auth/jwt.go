@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cdunlap/djaas/internal/model"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWTConfig controls how bearer tokens are validated and mapped to scopes
+type JWTConfig struct {
+	// Issuer must match the token's iss claim
+	Issuer string
+	// Audience must appear in the token's aud claim
+	Audience string
+	// ScopeClaim names the claim holding the caller's permissions. It may be
+	// a space-separated string (as in OAuth's "scope") or an array.
+	ScopeClaim string
+	// ScopeMap translates claim values, such as identity provider group
+	// names, to scopes. When empty, claim values are used as scope or role
+	// names.
+	ScopeMap map[string][]model.Scope
+	// Tier names the rate limit tier applied to token holders
+	Tier string
+	// Leeway allows for clock skew when checking exp, nbf and iat
+	Leeway time.Duration
+	// Now overrides the current time, for verifying tokens deterministically
+	Now func() time.Time
+}
+
+// JWTAuthenticator authenticates requests carrying an
+// "Authorization: Bearer" JSON Web Token signed by a key from its KeySource
+type JWTAuthenticator struct {
+	keys   KeySource
+	config JWTConfig
+	parser *jwt.Parser
+}
+
+// NewJWTAuthenticator creates a JWTAuthenticator. Tokens must be signed with
+// an asymmetric algorithm and carry an expiry, the configured issuer and
+// the configured audience.
+func NewJWTAuthenticator(keys KeySource, config JWTConfig) *JWTAuthenticator {
+	if config.ScopeClaim == "" {
+		config.ScopeClaim = "scope"
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}),
+		jwt.WithIssuer(config.Issuer),
+		jwt.WithAudience(config.Audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(config.Leeway),
+	}
+	if config.Now != nil {
+		opts = append(opts, jwt.WithTimeFunc(config.Now))
+	}
+
+	return &JWTAuthenticator{
+		keys:   keys,
+		config: config,
+		parser: jwt.NewParser(opts...),
+	}
+}
+
+// Authenticate implements Authenticator
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (*model.Principal, error) {
+	header := r.Header.Get("Authorization")
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return nil, ErrNoCredentials
+	}
+
+	claims := jwt.MapClaims{}
+	var keyErr error
+	_, err := a.parser.ParseWithClaims(strings.TrimSpace(token), claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, err := a.keys.PublicKey(r.Context(), kid)
+		if err != nil && !errors.Is(err, ErrUnknownKey) {
+			keyErr = err
+		}
+		return key, err
+	})
+	if keyErr != nil {
+		// Failures to reach the key source are not the caller's fault
+		return nil, fmt.Errorf("failed to load token signing key: %w", keyErr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	subject, err := claims.GetSubject()
+	if err != nil || subject == "" {
+		return nil, fmt.Errorf("%w: token has no subject", ErrInvalidCredentials)
+	}
+
+	name, _ := claims["name"].(string)
+	if name == "" {
+		name, _ = claims["email"].(string)
+	}
+
+	return &model.Principal{
+		Subject: "jwt:" + subject,
+		Name:    name,
+		Owner:   a.config.Issuer,
+		Scopes:  a.scopes(claims[a.config.ScopeClaim]),
+		Tier:    a.config.Tier,
+	}, nil
+}
+
+// scopes maps the value of the scope claim to the scopes it grants.
+// Unrecognised values are ignored.
+func (a *JWTAuthenticator) scopes(claim interface{}) []model.Scope {
+	var values []string
+	switch v := claim.(type) {
+	case string:
+		values = strings.Fields(v)
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+
+	var scopes []model.Scope
+	seen := make(map[model.Scope]bool)
+	for _, value := range values {
+		var granted []model.Scope
+		if len(a.config.ScopeMap) > 0 {
+			granted = a.config.ScopeMap[value]
+		} else {
+			granted, _ = model.ParseScopeOrRole(value)
+		}
+
+		for _, scope := range granted {
+			if !seen[scope] {
+				seen[scope] = true
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+	return scopes
+}
@@ -0,0 +1,376 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cdunlap/djaas/internal/model"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testIssuer   = "https://idp.test"
+	testAudience = "djaas"
+)
+
+// testKeys are locally generated signing keys published by a stand-in
+// JWKS server
+type testKeys struct {
+	rsa   *rsa.PrivateKey
+	ec    *ecdsa.PrivateKey
+	jwks  atomic.Pointer[[]byte]
+	hits  atomic.Int32
+	fail  atomic.Bool
+	serve *httptest.Server
+}
+
+func newTestKeys(t *testing.T) *testKeys {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate RSA key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate ECDSA key: %v", err)
+	}
+
+	encode := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	ecBytes, err := ecKey.PublicKey.Bytes()
+	if err != nil {
+		t.Fatalf("encode ECDSA key: %v", err)
+	}
+	// Uncompressed point: 0x04 || X || Y
+	size := (len(ecBytes) - 1) / 2
+	jwks, err := json.Marshal(map[string][]JWK{"keys": {
+		{Kty: "RSA", Kid: "rsa-1", Use: "sig", Alg: "RS256", N: encode(rsaKey.N.Bytes()), E: encode(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{Kty: "EC", Kid: "ec-1", Use: "sig", Alg: "ES256", Crv: "P-256", X: encode(ecBytes[1 : 1+size]), Y: encode(ecBytes[1+size:])},
+	}})
+	if err != nil {
+		t.Fatalf("encode JWKS: %v", err)
+	}
+
+	keys := &testKeys{rsa: rsaKey, ec: ecKey}
+	keys.jwks.Store(&jwks)
+	keys.serve = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys.hits.Add(1)
+		if keys.fail.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(*keys.jwks.Load())
+	}))
+	t.Cleanup(keys.serve.Close)
+	return keys
+}
+
+// validClaims returns claims the test authenticator accepts
+func validClaims() jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":   testIssuer,
+		"aud":   testAudience,
+		"sub":   "alice",
+		"name":  "Alice",
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+		"scope": "jokes:write unknown",
+	}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, claims jwt.MapClaims, key any) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return signed
+}
+
+func authenticate(a *JWTAuthenticator, token string) (*model.Principal, error) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	return a.Authenticate(r)
+}
+
+func newTestAuthenticator(keys *testKeys) *JWTAuthenticator {
+	return NewJWTAuthenticator(NewRemoteJWKS(keys.serve.URL, keys.serve.Client(), time.Hour), JWTConfig{
+		Issuer:   testIssuer,
+		Audience: testAudience,
+		Tier:     "internal",
+	})
+}
+
+func TestJWTAuthenticatorAcceptsValidTokens(t *testing.T) {
+	keys := newTestKeys(t)
+	a := newTestAuthenticator(keys)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"RS256", sign(t, jwt.SigningMethodRS256, "rsa-1", validClaims(), keys.rsa)},
+		{"ES256", sign(t, jwt.SigningMethodES256, "ec-1", validClaims(), keys.ec)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := authenticate(a, tt.token)
+			if err != nil {
+				t.Fatalf("Authenticate: %v", err)
+			}
+			if principal.Subject != "jwt:alice" || principal.Name != "Alice" || principal.Owner != testIssuer || principal.Tier != "internal" {
+				t.Errorf("principal = %+v", principal)
+			}
+			if !slices.Equal(principal.Scopes, []model.Scope{model.ScopeJokesWrite}) {
+				t.Errorf("scopes = %v, want [%s]", principal.Scopes, model.ScopeJokesWrite)
+			}
+		})
+	}
+
+	if hits := keys.hits.Load(); hits != 1 {
+		t.Errorf("JWKS fetched %d times, want 1 (cached)", hits)
+	}
+}
+
+func TestJWTAuthenticatorRejectsInvalidTokens(t *testing.T) {
+	keys := newTestKeys(t)
+	a := newTestAuthenticator(keys)
+
+	with := func(key string, value any) jwt.MapClaims {
+		claims := validClaims()
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+		return claims
+	}
+
+	publicDER, err := x509.MarshalPKIXPublicKey(&keys.rsa.PublicKey)
+	if err != nil {
+		t.Fatalf("encode RSA public key: %v", err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate RSA key: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"bad issuer", sign(t, jwt.SigningMethodRS256, "rsa-1", with("iss", "https://evil.test"), keys.rsa)},
+		{"bad audience", sign(t, jwt.SigningMethodRS256, "rsa-1", with("aud", "other-api"), keys.rsa)},
+		{"expired", sign(t, jwt.SigningMethodRS256, "rsa-1", with("exp", time.Now().Add(-time.Hour).Unix()), keys.rsa)},
+		{"no expiry", sign(t, jwt.SigningMethodRS256, "rsa-1", with("exp", nil), keys.rsa)},
+		{"no subject", sign(t, jwt.SigningMethodRS256, "rsa-1", with("sub", nil), keys.rsa)},
+		// HMAC keyed with the published RSA key, the classic alg confusion
+		{"HS256 with public key", sign(t, jwt.SigningMethodHS256, "rsa-1", validClaims(), publicDER)},
+		{"alg none", sign(t, jwt.SigningMethodNone, "rsa-1", validClaims(), jwt.UnsafeAllowNoneSignatureType)},
+		{"key of another type", sign(t, jwt.SigningMethodES256, "rsa-1", validClaims(), keys.ec)},
+		{"wrong signer", sign(t, jwt.SigningMethodRS256, "rsa-1", validClaims(), otherKey)},
+		{"unknown kid", sign(t, jwt.SigningMethodRS256, "rsa-2", validClaims(), keys.rsa)},
+		{"malformed", "not.a.token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := authenticate(a, tt.token)
+			if !errors.Is(err, ErrInvalidCredentials) {
+				t.Fatalf("Authenticate = %+v, %v; want ErrInvalidCredentials", principal, err)
+			}
+		})
+	}
+}
+
+func TestJWTAuthenticatorNoCredentials(t *testing.T) {
+	a := newTestAuthenticator(newTestKeys(t))
+
+	if _, err := authenticate(a, ""); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("no header: err = %v, want ErrNoCredentials", err)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Basic YWxpY2U6c2VjcmV0")
+	if _, err := a.Authenticate(r); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("basic auth: err = %v, want ErrNoCredentials", err)
+	}
+}
+
+func TestRemoteJWKSUnknownKidRefetchIsThrottled(t *testing.T) {
+	keys := newTestKeys(t)
+	a := newTestAuthenticator(keys)
+
+	for range 5 {
+		token := sign(t, jwt.SigningMethodRS256, "forged", validClaims(), keys.rsa)
+		if _, err := authenticate(a, token); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("err = %v, want ErrInvalidCredentials", err)
+		}
+	}
+	if hits := keys.hits.Load(); hits != 1 {
+		t.Errorf("JWKS fetched %d times for forged key IDs, want 1", hits)
+	}
+}
+
+func TestRemoteJWKSRefetchesRotatedKeys(t *testing.T) {
+	keys := newTestKeys(t)
+	source := NewRemoteJWKS(keys.serve.URL, keys.serve.Client(), time.Hour)
+	source.minRefresh = 0
+	a := NewJWTAuthenticator(source, JWTConfig{Issuer: testIssuer, Audience: testAudience})
+
+	if _, err := authenticate(a, sign(t, jwt.SigningMethodRS256, "rsa-1", validClaims(), keys.rsa)); err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+
+	// Rotate: the provider now publishes the RSA key under a new ID
+	var doc map[string][]JWK
+	if err := json.Unmarshal(*keys.jwks.Load(), &doc); err != nil {
+		t.Fatalf("decode JWKS: %v", err)
+	}
+	doc["keys"][0].Kid = "rsa-2"
+	rotated, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("encode JWKS: %v", err)
+	}
+	keys.jwks.Store(&rotated)
+
+	if _, err := authenticate(a, sign(t, jwt.SigningMethodRS256, "rsa-2", validClaims(), keys.rsa)); err != nil {
+		t.Fatalf("Authenticate after rotation: %v", err)
+	}
+	if hits := keys.hits.Load(); hits != 2 {
+		t.Errorf("JWKS fetched %d times, want 2", hits)
+	}
+}
+
+func TestRemoteJWKSUnavailable(t *testing.T) {
+	keys := newTestKeys(t)
+	keys.fail.Store(true)
+	a := newTestAuthenticator(keys)
+
+	_, err := authenticate(a, sign(t, jwt.SigningMethodRS256, "rsa-1", validClaims(), keys.rsa))
+	if err == nil || errors.Is(err, ErrInvalidCredentials) || errors.Is(err, ErrNoCredentials) {
+		t.Fatalf("err = %v, want a key source failure", err)
+	}
+}
+
+func TestParseJWKSRejectsBadKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+	}{
+		{"not JSON", `{`},
+		{"no keys", `{"keys": []}`},
+		{"only encryption keys", `{"keys": [{"kty": "RSA", "use": "enc", "n": "AQAB", "e": "AQAB"}]}`},
+		{"only unsupported types", `{"keys": [{"kty": "oct", "k": "c2VjcmV0"}, {"kty": "EC", "crv": "secp256k1", "x": "AQ", "y": "AQ"}]}`},
+		{"point off the curve", `{"keys": [{"kty": "EC", "crv": "P-256", "x": "AQ", "y": "AQ"}]}`},
+		{"small RSA exponent", `{"keys": [{"kty": "RSA", "n": "AQAB", "e": "AQ"}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseJWKS([]byte(tt.doc)); err == nil {
+				t.Error("ParseJWKS succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestParseJWKSSkipsUnusableKeys(t *testing.T) {
+	keys := newTestKeys(t)
+	var doc map[string][]json.RawMessage
+	if err := json.Unmarshal(*keys.jwks.Load(), &doc); err != nil {
+		t.Fatalf("decode JWKS: %v", err)
+	}
+	// Identity providers publish keys this service cannot use next to
+	// those it can
+	doc["keys"] = append(doc["keys"],
+		json.RawMessage(`{"kty": "oct", "kid": "hmac", "k": "c2VjcmV0"}`),
+		json.RawMessage(`{"kty": "EC", "kid": "k1", "crv": "secp256k1", "x": "AQ", "y": "AQ"}`),
+	)
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("encode JWKS: %v", err)
+	}
+
+	set, err := ParseJWKS(data)
+	if err != nil {
+		t.Fatalf("ParseJWKS: %v", err)
+	}
+	for _, kid := range []string{"rsa-1", "ec-1"} {
+		if _, err := set.PublicKey(context.Background(), kid); err != nil {
+			t.Errorf("PublicKey(%q): %v", kid, err)
+		}
+	}
+	if _, err := set.PublicKey(context.Background(), "hmac"); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("PublicKey(hmac) = %v, want ErrUnknownKey", err)
+	}
+}
+
+func TestRemoteJWKSIgnoresCallerCancellation(t *testing.T) {
+	keys := newTestKeys(t)
+	source := NewRemoteJWKS(keys.serve.URL, keys.serve.Client(), time.Hour)
+
+	// The client that triggers the first fetch has already gone away
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := source.PublicKey(ctx, "rsa-1"); err != nil {
+		t.Fatalf("PublicKey with a cancelled context: %v", err)
+	}
+	if _, err := source.PublicKey(context.Background(), "ec-1"); err != nil {
+		t.Errorf("PublicKey after the first fetch: %v", err)
+	}
+}
+
+func TestRemoteJWKSSharesOneFetch(t *testing.T) {
+	keys := newTestKeys(t)
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		keys.serve.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(slow.Close)
+	source := NewRemoteJWKS(slow.URL, slow.Client(), time.Hour)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for range 10 {
+		wg.Go(func() {
+			_, err := source.PublicKey(context.Background(), "rsa-1")
+			errs <- err
+		})
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("PublicKey: %v", err)
+		}
+	}
+	if hits := keys.hits.Load(); hits != 1 {
+		t.Errorf("JWKS fetched %d times by concurrent callers, want 1", hits)
+	}
+}
@@ -3,8 +3,14 @@ package config
 import (
 	"fmt"
 	"log"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/cdunlap/djaas/internal/language"
+	"github.com/cdunlap/djaas/internal/model"
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
 )
@@ -13,12 +19,30 @@ type Config struct {
 	Server    ServerConfig
 	Database  DatabaseConfig
 	RateLimit RateLimitConfig
+	Content   ContentConfig
+	Language  LanguageConfig
+	Auth      AuthConfig
+	Metrics   MetricsConfig
+	Tracing   TracingConfig
+	WebSocket WebSocketConfig
+	GRPC      GRPCConfig
 }
 
 type ServerConfig struct {
 	Port     string
 	Env      string
 	LogLevel string
+	// TrustedProxies lists the networks of reverse proxies whose forwarding
+	// headers are believed when resolving the client address
+	TrustedProxies []netip.Prefix
+	// ProxyHeader names the header trusted proxies set: X-Forwarded-For,
+	// Forwarded or X-Real-IP
+	ProxyHeader string
+	// HealthCheckTimeout bounds each dependency check behind /readyz
+	HealthCheckTimeout time.Duration
+	// ShutdownDrainDelay is how long /readyz fails before the server stops
+	// accepting connections on shutdown, so load balancers can react
+	ShutdownDrainDelay time.Duration
 }
 
 type DatabaseConfig struct {
@@ -33,8 +57,129 @@ type DatabaseConfig struct {
 }
 
 type RateLimitConfig struct {
+	// Enabled turns rate limiting on; it defaults to off in development
+	Enabled bool
+	// Requests and Window limit anonymous callers, per IP
 	Requests int
 	Window   time.Duration
+	// Tiers limits authenticated callers, per API key or token subject
+	Tiers map[string]RateLimitTier
+	// Policies override the tier burst limits for groups of routes, by
+	// group name (see RouteGroups)
+	Policies map[string]RateLimitPolicy
+	// DefaultTier applies to API keys whose tier is not configured
+	DefaultTier string
+	// JWTTier applies to callers authenticated with a bearer token
+	JWTTier string
+	// MaxEntries bounds the number of callers tracked per tier; the least
+	// recently seen are evicted first
+	MaxEntries int
+	// Backend is where limiter state is kept: "memory" (per replica) or
+	// "redis" (shared across replicas)
+	Backend string
+	// RedisURL locates the Redis server for the redis backend
+	RedisURL string
+	// RedisPrefix namespaces the keys written to Redis
+	RedisPrefix string
+	// RedisTimeout bounds each call to Redis
+	RedisTimeout time.Duration
+	// FailOpen lets requests through when the backend is unavailable;
+	// otherwise they are rejected with 503
+	FailOpen bool
+}
+
+// RateLimitPolicy is the rate limit for a group of routes
+type RateLimitPolicy struct {
+	Requests int
+	Window   time.Duration
+	// Unlimited exempts the group from rate limiting
+	Unlimited bool
+}
+
+// RouteGroups are the groups of routes that can be given a rate limit
+// policy in RATE_LIMIT_POLICIES
+var RouteGroups = []string{"health", "static", "read", "search", "write", "moderate", "graphql", "auth"}
+
+type RateLimitTier struct {
+	Requests int
+	Window   time.Duration
+	// DailyQuota caps requests per UTC day; 0 means unlimited
+	DailyQuota int
+}
+
+type ContentConfig struct {
+	// DefaultMaxRating is the rating ceiling for anonymous requests and API
+	// keys that do not set their own
+	DefaultMaxRating string
+	// WordListFile is an optional word list for the submission checker
+	WordListFile string
+}
+
+type LanguageConfig struct {
+	// Fallbacks is the ordered list of languages served when none of the
+	// caller's preferred languages have a matching joke. The first entry is
+	// the default language for new jokes.
+	Fallbacks []string
+}
+
+type AuthConfig struct {
+	// JWKSFile and JWKSURL locate the keys used to verify bearer tokens.
+	// JWT authentication is enabled when either is set.
+	JWKSFile string
+	JWKSURL  string
+	// JWKSCacheTTL is how long keys fetched from JWKSURL are cached
+	JWKSCacheTTL time.Duration
+	// JWTIssuer and JWTAudience must match the iss and aud claims
+	JWTIssuer   string
+	JWTAudience string
+	// JWTScopeClaim names the claim listing the caller's permissions
+	JWTScopeClaim string
+	// JWTScopeMap maps scope claim values to DJaaS scopes or roles
+	JWTScopeMap map[string]string
+	// JWTLeeway allows for clock skew between DJaaS and the identity provider
+	JWTLeeway time.Duration
+}
+
+type MetricsConfig struct {
+	// Enabled serves Prometheus metrics at /metrics
+	Enabled bool
+	// AdminPort serves /metrics on a separate port, e.g. one not exposed
+	// publicly. When empty, metrics are served on the API port.
+	AdminPort string
+}
+
+type TracingConfig struct {
+	// Exporter is where spans are sent: "none", "stdout" or "otlp". The
+	// OTLP endpoint is set with the standard OTEL_EXPORTER_OTLP_* variables.
+	Exporter string
+	// SampleRatio is the fraction of new traces recorded, from 0 to 1
+	SampleRatio float64
+}
+
+type WebSocketConfig struct {
+	// MaxConnections caps open WebSocket connections per instance
+	MaxConnections int
+	// MaxConnectionsPerCaller caps open connections per API key or token subject
+	MaxConnectionsPerCaller int
+	// RevealDelay is how long a client waits for a punchline after receiving a joke
+	RevealDelay time.Duration
+	// AllowedOrigins lists the other origins whose pages may connect, e.g.
+	// widget.example.com or *.example.com
+	AllowedOrigins []string
+}
+
+type GRPCConfig struct {
+	// Enabled serves the gRPC API on Port, alongside the REST API
+	Enabled bool
+	Port    string
+	// Reflection lets tools such as grpcurl discover the gRPC API; it
+	// defaults to on in development only
+	Reflection bool
+}
+
+// JWTEnabled reports whether bearer token authentication is configured
+func (c AuthConfig) JWTEnabled() bool {
+	return c.JWKSFile != "" || c.JWKSURL != ""
 }
 
 // Load reads configuration from environment variables
@@ -50,6 +195,10 @@ func Load() (*Config, error) {
 	viper.SetDefault("PORT", "8080")
 	viper.SetDefault("ENV", "development")
 	viper.SetDefault("LOG_LEVEL", "info")
+	viper.SetDefault("TRUSTED_PROXIES", "")
+	viper.SetDefault("TRUSTED_PROXY_HEADER", "X-Forwarded-For")
+	viper.SetDefault("HEALTH_CHECK_TIMEOUT", "2s")
+	viper.SetDefault("SHUTDOWN_DRAIN_DELAY", "0s")
 
 	viper.SetDefault("DB_HOST", "localhost")
 	viper.SetDefault("DB_PORT", "5432")
@@ -60,8 +209,66 @@ func Load() (*Config, error) {
 	viper.SetDefault("DB_MAX_CONNECTIONS", 25)
 	viper.SetDefault("DB_MAX_IDLE_CONNECTIONS", 5)
 
+	viper.SetDefault("RATE_LIMIT_ENABLED", viper.GetString("ENV") != "development")
 	viper.SetDefault("RATE_LIMIT_REQUESTS", 10)
 	viper.SetDefault("RATE_LIMIT_WINDOW", "1m")
+	viper.SetDefault("RATE_LIMIT_TIERS", "free=60/1m/1000,partner=600/1m/100000,internal=3000/1m/0")
+	viper.SetDefault("RATE_LIMIT_POLICIES", "health=off,static=off,search=30/1m,write=10/1m,auth=20/1m")
+	viper.SetDefault("RATE_LIMIT_DEFAULT_TIER", "free")
+	viper.SetDefault("RATE_LIMIT_JWT_TIER", "internal")
+	viper.SetDefault("RATE_LIMIT_MAX_ENTRIES", 100000)
+	viper.SetDefault("RATE_LIMIT_BACKEND", "memory")
+	viper.SetDefault("RATE_LIMIT_REDIS_URL", "")
+	viper.SetDefault("RATE_LIMIT_REDIS_PREFIX", "djaas:")
+	viper.SetDefault("RATE_LIMIT_REDIS_TIMEOUT", "100ms")
+	viper.SetDefault("RATE_LIMIT_FAIL_OPEN", true)
+
+	viper.SetDefault("CONTENT_DEFAULT_MAX_RATING", "pg13")
+	viper.SetDefault("CONTENT_WORDLIST_FILE", "")
+
+	viper.SetDefault("LANGUAGE_FALLBACKS", "en")
+
+	viper.SetDefault("AUTH_JWKS_FILE", "")
+	viper.SetDefault("AUTH_JWKS_URL", "")
+	viper.SetDefault("AUTH_JWKS_CACHE_TTL", "1h")
+	viper.SetDefault("AUTH_JWT_ISSUER", "")
+	viper.SetDefault("AUTH_JWT_AUDIENCE", "")
+	viper.SetDefault("AUTH_JWT_SCOPE_CLAIM", "scope")
+	viper.SetDefault("AUTH_JWT_SCOPE_MAP", "")
+	viper.SetDefault("AUTH_JWT_LEEWAY", "30s")
+
+	viper.SetDefault("METRICS_ENABLED", true)
+	viper.SetDefault("METRICS_ADMIN_PORT", "")
+
+	viper.SetDefault("TRACING_EXPORTER", "none")
+	viper.SetDefault("TRACING_SAMPLE_RATIO", 1.0)
+
+	viper.SetDefault("WS_MAX_CONNECTIONS", 1000)
+	viper.SetDefault("WS_MAX_CONNECTIONS_PER_CALLER", 5)
+	viper.SetDefault("WS_REVEAL_DELAY", "3s")
+	viper.SetDefault("WS_ALLOWED_ORIGINS", "")
+
+	viper.SetDefault("GRPC_ENABLED", false)
+	viper.SetDefault("GRPC_PORT", "9090")
+	viper.SetDefault("GRPC_REFLECTION", viper.GetString("ENV") == "development")
+
+	// Parse trusted proxy networks (CIDRs or single addresses)
+	trustedProxies, err := parsePrefixList(viper.GetString("TRUSTED_PROXIES"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+	}
+	proxyHeader, err := parseProxyHeader(viper.GetString("TRUSTED_PROXY_HEADER"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid TRUSTED_PROXY_HEADER: %w", err)
+	}
+	healthCheckTimeout, err := time.ParseDuration(viper.GetString("HEALTH_CHECK_TIMEOUT"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid HEALTH_CHECK_TIMEOUT: %w", err)
+	}
+	shutdownDrainDelay, err := time.ParseDuration(viper.GetString("SHUTDOWN_DRAIN_DELAY"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SHUTDOWN_DRAIN_DELAY: %w", err)
+	}
 
 	// Parse rate limit window
 	windowStr := viper.GetString("RATE_LIMIT_WINDOW")
@@ -70,11 +277,75 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("invalid RATE_LIMIT_WINDOW: %w", err)
 	}
 
+	// Parse rate limit tiers (name=requests/window/daily_quota,...)
+	tiers, err := parseRateLimitTiers(viper.GetString("RATE_LIMIT_TIERS"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RATE_LIMIT_TIERS: %w", err)
+	}
+
+	// Parse route group policies (group=requests/window or group=off,...)
+	policies, err := parseRateLimitPolicies(viper.GetString("RATE_LIMIT_POLICIES"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RATE_LIMIT_POLICIES: %w", err)
+	}
+
+	redisTimeout, err := time.ParseDuration(viper.GetString("RATE_LIMIT_REDIS_TIMEOUT"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RATE_LIMIT_REDIS_TIMEOUT: %w", err)
+	}
+
+	// Parse JWT settings
+	jwksCacheTTL, err := time.ParseDuration(viper.GetString("AUTH_JWKS_CACHE_TTL"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid AUTH_JWKS_CACHE_TTL: %w", err)
+	}
+	jwtLeeway, err := time.ParseDuration(viper.GetString("AUTH_JWT_LEEWAY"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid AUTH_JWT_LEEWAY: %w", err)
+	}
+
+	// Parse claim value to scope or role mappings (value=scope,value=role);
+	// scopes contain colons, so pairs are separated by "="
+	jwtScopeMap, err := parseKeyValueList(viper.GetString("AUTH_JWT_SCOPE_MAP"), "=")
+	if err != nil {
+		return nil, fmt.Errorf("invalid AUTH_JWT_SCOPE_MAP: %w", err)
+	}
+
+	revealDelay, err := time.ParseDuration(viper.GetString("WS_REVEAL_DELAY"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid WS_REVEAL_DELAY: %w", err)
+	}
+	var allowedOrigins []string
+	for _, origin := range strings.Split(viper.GetString("WS_ALLOWED_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			allowedOrigins = append(allowedOrigins, origin)
+		}
+	}
+
+	// Parse language fallback chain
+	var fallbacks []string
+	for _, lang := range strings.Split(viper.GetString("LANGUAGE_FALLBACKS"), ",") {
+		if lang = strings.TrimSpace(lang); lang == "" {
+			continue
+		}
+		normalized, err := language.Normalize(lang)
+		if err != nil {
+			return nil, fmt.Errorf("invalid LANGUAGE_FALLBACKS: %w", err)
+		}
+		fallbacks = append(fallbacks, normalized)
+	}
+
 	cfg := &Config{
 		Server: ServerConfig{
 			Port:     viper.GetString("PORT"),
 			Env:      viper.GetString("ENV"),
 			LogLevel: viper.GetString("LOG_LEVEL"),
+
+			TrustedProxies: trustedProxies,
+			ProxyHeader:    proxyHeader,
+
+			HealthCheckTimeout: healthCheckTimeout,
+			ShutdownDrainDelay: shutdownDrainDelay,
 		},
 		Database: DatabaseConfig{
 			Host:            viper.GetString("DB_HOST"),
@@ -87,8 +358,56 @@ func Load() (*Config, error) {
 			MaxIdleConns:    int32(viper.GetInt("DB_MAX_IDLE_CONNECTIONS")),
 		},
 		RateLimit: RateLimitConfig{
-			Requests: viper.GetInt("RATE_LIMIT_REQUESTS"),
-			Window:   window,
+			Enabled:     viper.GetBool("RATE_LIMIT_ENABLED"),
+			Requests:    viper.GetInt("RATE_LIMIT_REQUESTS"),
+			Window:      window,
+			Tiers:       tiers,
+			Policies:    policies,
+			DefaultTier: viper.GetString("RATE_LIMIT_DEFAULT_TIER"),
+			JWTTier:     viper.GetString("RATE_LIMIT_JWT_TIER"),
+			MaxEntries:  viper.GetInt("RATE_LIMIT_MAX_ENTRIES"),
+
+			Backend:      viper.GetString("RATE_LIMIT_BACKEND"),
+			RedisURL:     viper.GetString("RATE_LIMIT_REDIS_URL"),
+			RedisPrefix:  viper.GetString("RATE_LIMIT_REDIS_PREFIX"),
+			RedisTimeout: redisTimeout,
+			FailOpen:     viper.GetBool("RATE_LIMIT_FAIL_OPEN"),
+		},
+		Content: ContentConfig{
+			DefaultMaxRating: viper.GetString("CONTENT_DEFAULT_MAX_RATING"),
+			WordListFile:     viper.GetString("CONTENT_WORDLIST_FILE"),
+		},
+		Language: LanguageConfig{
+			Fallbacks: fallbacks,
+		},
+		Auth: AuthConfig{
+			JWKSFile:      viper.GetString("AUTH_JWKS_FILE"),
+			JWKSURL:       viper.GetString("AUTH_JWKS_URL"),
+			JWKSCacheTTL:  jwksCacheTTL,
+			JWTIssuer:     viper.GetString("AUTH_JWT_ISSUER"),
+			JWTAudience:   viper.GetString("AUTH_JWT_AUDIENCE"),
+			JWTScopeClaim: viper.GetString("AUTH_JWT_SCOPE_CLAIM"),
+			JWTScopeMap:   jwtScopeMap,
+			JWTLeeway:     jwtLeeway,
+		},
+		Metrics: MetricsConfig{
+			Enabled:   viper.GetBool("METRICS_ENABLED"),
+			AdminPort: viper.GetString("METRICS_ADMIN_PORT"),
+		},
+		Tracing: TracingConfig{
+			Exporter:    viper.GetString("TRACING_EXPORTER"),
+			SampleRatio: viper.GetFloat64("TRACING_SAMPLE_RATIO"),
+		},
+		WebSocket: WebSocketConfig{
+			MaxConnections:          viper.GetInt("WS_MAX_CONNECTIONS"),
+			MaxConnectionsPerCaller: viper.GetInt("WS_MAX_CONNECTIONS_PER_CALLER"),
+			RevealDelay:             revealDelay,
+			AllowedOrigins:          allowedOrigins,
+		},
+		GRPC: GRPCConfig{
+			Enabled:    viper.GetBool("GRPC_ENABLED"),
+			Port:       viper.GetString("GRPC_PORT"),
+			Reflection: viper.GetBool("GRPC_REFLECTION"),
 		},
 	}
 
@@ -111,12 +430,215 @@ func (c *Config) Validate() error {
 	if c.Database.DBName == "" {
 		return fmt.Errorf("DB_NAME is required")
 	}
+	if c.Server.HealthCheckTimeout <= 0 {
+		return fmt.Errorf("HEALTH_CHECK_TIMEOUT must be greater than 0")
+	}
+	if c.Server.ShutdownDrainDelay < 0 {
+		return fmt.Errorf("SHUTDOWN_DRAIN_DELAY must not be negative")
+	}
 	if c.RateLimit.Requests <= 0 {
 		return fmt.Errorf("RATE_LIMIT_REQUESTS must be greater than 0")
 	}
 	if c.RateLimit.Window <= 0 {
 		return fmt.Errorf("RATE_LIMIT_WINDOW must be greater than 0")
 	}
+	if c.RateLimit.MaxEntries <= 0 {
+		return fmt.Errorf("RATE_LIMIT_MAX_ENTRIES must be greater than 0")
+	}
+	switch c.RateLimit.Backend {
+	case "memory":
+	case "redis":
+		if c.RateLimit.RedisURL == "" {
+			return fmt.Errorf("RATE_LIMIT_REDIS_URL is required when RATE_LIMIT_BACKEND is redis")
+		}
+		if c.RateLimit.RedisTimeout <= 0 {
+			return fmt.Errorf("RATE_LIMIT_REDIS_TIMEOUT must be greater than 0")
+		}
+	default:
+		return fmt.Errorf("RATE_LIMIT_BACKEND must be memory or redis, got %q", c.RateLimit.Backend)
+	}
+	if _, ok := c.RateLimit.Tiers[c.RateLimit.DefaultTier]; !ok {
+		return fmt.Errorf("RATE_LIMIT_DEFAULT_TIER %q is not defined in RATE_LIMIT_TIERS", c.RateLimit.DefaultTier)
+	}
+	if _, ok := c.RateLimit.Tiers[c.RateLimit.JWTTier]; !ok {
+		return fmt.Errorf("RATE_LIMIT_JWT_TIER %q is not defined in RATE_LIMIT_TIERS", c.RateLimit.JWTTier)
+	}
+	if _, err := model.ParseContentRating(c.Content.DefaultMaxRating); err != nil {
+		return fmt.Errorf("invalid CONTENT_DEFAULT_MAX_RATING: %w", err)
+	}
+	if len(c.Language.Fallbacks) == 0 {
+		return fmt.Errorf("LANGUAGE_FALLBACKS must list at least one language")
+	}
+	if c.Metrics.AdminPort != "" && c.Metrics.AdminPort == c.Server.Port {
+		return fmt.Errorf("METRICS_ADMIN_PORT must differ from PORT")
+	}
+	switch c.Tracing.Exporter {
+	case "none", "stdout", "otlp":
+	default:
+		return fmt.Errorf("TRACING_EXPORTER must be none, stdout or otlp, got %q", c.Tracing.Exporter)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		return fmt.Errorf("TRACING_SAMPLE_RATIO must be between 0 and 1")
+	}
+	if c.WebSocket.MaxConnections <= 0 {
+		return fmt.Errorf("WS_MAX_CONNECTIONS must be greater than 0")
+	}
+	if c.WebSocket.MaxConnectionsPerCaller <= 0 {
+		return fmt.Errorf("WS_MAX_CONNECTIONS_PER_CALLER must be greater than 0")
+	}
+	if c.WebSocket.RevealDelay < 0 {
+		return fmt.Errorf("WS_REVEAL_DELAY must not be negative")
+	}
+	if c.GRPC.Enabled {
+		if c.GRPC.Port == "" {
+			return fmt.Errorf("GRPC_PORT is required when GRPC_ENABLED is true")
+		}
+		if c.GRPC.Port == c.Server.Port || c.GRPC.Port == c.Metrics.AdminPort {
+			return fmt.Errorf("GRPC_PORT must differ from PORT and METRICS_ADMIN_PORT")
+		}
+	}
+	if c.Auth.JWKSFile != "" && c.Auth.JWKSURL != "" {
+		return fmt.Errorf("set only one of AUTH_JWKS_FILE and AUTH_JWKS_URL")
+	}
+	if c.Auth.JWTEnabled() && (c.Auth.JWTIssuer == "" || c.Auth.JWTAudience == "") {
+		return fmt.Errorf("AUTH_JWT_ISSUER and AUTH_JWT_AUDIENCE are required when a JWKS is configured")
+	}
+	for _, scope := range c.Auth.JWTScopeMap {
+		if _, err := model.ParseScopeOrRole(scope); err != nil {
+			return fmt.Errorf("invalid AUTH_JWT_SCOPE_MAP: %w", err)
+		}
+	}
 
 	return nil
 }
+
+// parseKeyValueList parses a comma-separated list of key/value pairs joined
+// by sep
+func parseKeyValueList(s, sep string) (map[string]string, error) {
+	result := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(pair, sep)
+		if !ok || key == "" || value == "" {
+			return nil, fmt.Errorf("expected key%svalue, got %q", sep, pair)
+		}
+		result[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return result, nil
+}
+
+// parsePrefixList parses a comma-separated list of CIDR prefixes. Single
+// addresses are treated as prefixes covering just that address.
+func parsePrefixList(s string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		if strings.Contains(item, "/") {
+			prefix, err := netip.ParsePrefix(item)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(item)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// parseProxyHeader returns the canonical spelling of a supported forwarding
+// header
+func parseProxyHeader(s string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "x-forwarded-for":
+		return "X-Forwarded-For", nil
+	case "forwarded":
+		return "Forwarded", nil
+	case "x-real-ip":
+		return "X-Real-IP", nil
+	default:
+		return "", fmt.Errorf("expected X-Forwarded-For, Forwarded or X-Real-IP, got %q", s)
+	}
+}
+
+// parseRateLimitPolicies parses a comma-separated list of
+// group=requests/window or group=off route group policies
+func parseRateLimitPolicies(s string) (map[string]RateLimitPolicy, error) {
+	pairs, err := parseKeyValueList(s, "=")
+	if err != nil {
+		return nil, err
+	}
+
+	policies := make(map[string]RateLimitPolicy, len(pairs))
+	for group, spec := range pairs {
+		if !slices.Contains(RouteGroups, group) {
+			return nil, fmt.Errorf("unknown route group %q, expected one of %s", group, strings.Join(RouteGroups, ", "))
+		}
+		if spec == "off" {
+			policies[group] = RateLimitPolicy{Unlimited: true}
+			continue
+		}
+
+		requestsStr, windowStr, ok := strings.Cut(spec, "/")
+		if !ok {
+			return nil, fmt.Errorf("group %q: expected requests/window or off, got %q", group, spec)
+		}
+		requests, err := strconv.Atoi(requestsStr)
+		if err != nil || requests <= 0 {
+			return nil, fmt.Errorf("group %q: requests must be a positive integer", group)
+		}
+		window, err := time.ParseDuration(windowStr)
+		if err != nil || window <= 0 {
+			return nil, fmt.Errorf("group %q: invalid window %q", group, windowStr)
+		}
+
+		policies[group] = RateLimitPolicy{Requests: requests, Window: window}
+	}
+	return policies, nil
+}
+
+// parseRateLimitTiers parses a comma-separated list of
+// name=requests/window/daily_quota tier definitions
+func parseRateLimitTiers(s string) (map[string]RateLimitTier, error) {
+	pairs, err := parseKeyValueList(s, "=")
+	if err != nil {
+		return nil, err
+	}
+
+	tiers := make(map[string]RateLimitTier, len(pairs))
+	for name, spec := range pairs {
+		parts := strings.Split(spec, "/")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("tier %q: expected requests/window/daily_quota, got %q", name, spec)
+		}
+
+		requests, err := strconv.Atoi(parts[0])
+		if err != nil || requests <= 0 {
+			return nil, fmt.Errorf("tier %q: requests must be a positive integer", name)
+		}
+		window, err := time.ParseDuration(parts[1])
+		if err != nil || window <= 0 {
+			return nil, fmt.Errorf("tier %q: invalid window %q", name, parts[1])
+		}
+		quota, err := strconv.Atoi(parts[2])
+		if err != nil || quota < 0 {
+			return nil, fmt.Errorf("tier %q: daily quota must be 0 or a positive integer", name)
+		}
+
+		tiers[name] = RateLimitTier{Requests: requests, Window: window, DailyQuota: quota}
+	}
+	return tiers, nil
+}
@@ -2,10 +2,12 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -18,6 +20,8 @@ type Config struct {
 	SSLMode         string
 	MaxConnections  int32
 	MaxIdleConns    int32
+	// Tracer, if set, is called for every query run on the pool
+	Tracer          pgx.QueryTracer
 }
 
 // Connect establishes a connection pool to the PostgreSQL database
@@ -34,6 +38,9 @@ func Connect(cfg Config, logger *slog.Logger) (*pgxpool.Pool, error) {
 
 	poolConfig.MaxConns = cfg.MaxConnections
 	poolConfig.MinConns = cfg.MaxIdleConns
+	if cfg.Tracer != nil {
+		poolConfig.ConnConfig.Tracer = cfg.Tracer
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -69,3 +76,22 @@ func Close(pool *pgxpool.Pool) {
 func Ping(ctx context.Context, pool *pgxpool.Pool) error {
 	return pool.Ping(ctx)
 }
+
+// ExpectedSchemaVersion is the latest migration in migrations/; bump it
+// when adding a migration
+const ExpectedSchemaVersion = 11
+
+// SchemaVersion returns the version recorded by golang-migrate and whether
+// the last migration failed partway
+func SchemaVersion(ctx context.Context, pool *pgxpool.Pool) (uint, bool, error) {
+	var version int64
+	var dirty bool
+	err := pool.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("unable to read schema version: %w", err)
+	}
+	return uint(version), dirty, nil
+}
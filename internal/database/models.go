@@ -5,16 +5,102 @@
 package database
 
 import (
+	"database/sql/driver"
+	"fmt"
+
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type ContentRating string
+
+const (
+	ContentRatingG    ContentRating = "g"
+	ContentRatingPg   ContentRating = "pg"
+	ContentRatingPg13 ContentRating = "pg13"
+	ContentRatingR    ContentRating = "r"
+)
+
+func (e *ContentRating) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ContentRating(s)
+	case string:
+		*e = ContentRating(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ContentRating: %T", src)
+	}
+	return nil
+}
+
+type NullContentRating struct {
+	ContentRating ContentRating `json:"content_rating"`
+	Valid         bool          `json:"valid"` // Valid is true if ContentRating is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullContentRating) Scan(value interface{}) error {
+	if value == nil {
+		ns.ContentRating, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.ContentRating.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullContentRating) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.ContentRating), nil
+}
+
+type ApiKey struct {
+	ID         int32              `json:"id"`
+	Name       string             `json:"name"`
+	Owner      string             `json:"owner"`
+	KeyPrefix  string             `json:"key_prefix"`
+	KeyHash    string             `json:"key_hash"`
+	Scopes     []string           `json:"scopes"`
+	MaxRating  NullContentRating  `json:"max_rating"`
+	ExpiresAt  pgtype.Timestamptz `json:"expires_at"`
+	LastUsedAt pgtype.Timestamptz `json:"last_used_at"`
+	RevokedAt  pgtype.Timestamptz `json:"revoked_at"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+	Tier       string             `json:"tier"`
+}
+
+type AuditEvent struct {
+	ID          int64              `json:"id"`
+	OccurredAt  pgtype.Timestamptz `json:"occurred_at"`
+	Actor       string             `json:"actor"`
+	Action      string             `json:"action"`
+	TargetType  string             `json:"target_type"`
+	TargetID    string             `json:"target_id"`
+	BeforeState []byte             `json:"before_state"`
+	AfterState  []byte             `json:"after_state"`
+	ClientIp    pgtype.Text        `json:"client_ip"`
+	RequestID   pgtype.Text        `json:"request_id"`
+}
+
 type Joke struct {
-	ID        int32              `json:"id"`
-	Setup     string             `json:"setup"`
-	Punchline string             `json:"punchline"`
-	Category  pgtype.Text        `json:"category"`
-	CreatedAt pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+	ID              int32              `json:"id"`
+	Setup           string             `json:"setup"`
+	Punchline       string             `json:"punchline"`
+	Category        pgtype.Text        `json:"category"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
+	Rating          ContentRating      `json:"rating"`
+	ContentWarnings []string           `json:"content_warnings"`
+	Flagged         bool               `json:"flagged"`
+	Language        string             `json:"language"`
+	TranslationOf   pgtype.Int4        `json:"translation_of"`
+	JokeType        string             `json:"joke_type"`
+	Parts           []byte             `json:"parts"`
+	Author          pgtype.Text        `json:"author"`
+	SourceName      pgtype.Text        `json:"source_name"`
+	SourceUrl       pgtype.Text        `json:"source_url"`
+	License         pgtype.Text        `json:"license"`
 }
 
 type JokeTag struct {
@@ -23,6 +109,14 @@ type JokeTag struct {
 	CreatedAt pgtype.Timestamptz `json:"created_at"`
 }
 
+type JokeVote struct {
+	JokeID    int32              `json:"joke_id"`
+	Voter     string             `json:"voter"`
+	Vote      int16              `json:"vote"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
 type Tag struct {
 	ID        int32              `json:"id"`
 	Name      string             `json:"name"`
@@ -27,20 +27,122 @@ func (q *Queries) AddJokeTag(ctx context.Context, arg AddJokeTagParams) error {
 	return err
 }
 
+const createAPIKey = `-- name: CreateAPIKey :one
+INSERT INTO api_keys (name, owner, key_prefix, key_hash, scopes, max_rating, expires_at, tier)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, name, owner, key_prefix, key_hash, scopes, max_rating, expires_at, last_used_at, revoked_at, created_at, tier
+`
+
+type CreateAPIKeyParams struct {
+	Name      string             `json:"name"`
+	Owner     string             `json:"owner"`
+	KeyPrefix string             `json:"key_prefix"`
+	KeyHash   string             `json:"key_hash"`
+	Scopes    []string           `json:"scopes"`
+	MaxRating NullContentRating  `json:"max_rating"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	Tier      string             `json:"tier"`
+}
+
+func (q *Queries) CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (ApiKey, error) {
+	row := q.db.QueryRow(ctx, createAPIKey,
+		arg.Name,
+		arg.Owner,
+		arg.KeyPrefix,
+		arg.KeyHash,
+		arg.Scopes,
+		arg.MaxRating,
+		arg.ExpiresAt,
+		arg.Tier,
+	)
+	var i ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Owner,
+		&i.KeyPrefix,
+		&i.KeyHash,
+		&i.Scopes,
+		&i.MaxRating,
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.Tier,
+	)
+	return i, err
+}
+
+const createAuditEvent = `-- name: CreateAuditEvent :exec
+INSERT INTO audit_events (actor, action, target_type, target_id, before_state, after_state, client_ip, request_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+`
+
+type CreateAuditEventParams struct {
+	Actor       string      `json:"actor"`
+	Action      string      `json:"action"`
+	TargetType  string      `json:"target_type"`
+	TargetID    string      `json:"target_id"`
+	BeforeState []byte      `json:"before_state"`
+	AfterState  []byte      `json:"after_state"`
+	ClientIp    pgtype.Text `json:"client_ip"`
+	RequestID   pgtype.Text `json:"request_id"`
+}
+
+func (q *Queries) CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) error {
+	_, err := q.db.Exec(ctx, createAuditEvent,
+		arg.Actor,
+		arg.Action,
+		arg.TargetType,
+		arg.TargetID,
+		arg.BeforeState,
+		arg.AfterState,
+		arg.ClientIp,
+		arg.RequestID,
+	)
+	return err
+}
+
 const createJoke = `-- name: CreateJoke :one
-INSERT INTO jokes (setup, punchline, category)
-VALUES ($1, $2, $3)
-RETURNING id, setup, punchline, category, created_at, updated_at
+INSERT INTO jokes (setup, punchline, category, rating, content_warnings, flagged, language, translation_of, joke_type, parts, author, source_name, source_url, license)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+RETURNING id, setup, punchline, category, created_at, updated_at, rating, content_warnings, flagged, language, translation_of, joke_type, parts, author, source_name, source_url, license
 `
 
 type CreateJokeParams struct {
-	Setup     string      `json:"setup"`
-	Punchline string      `json:"punchline"`
-	Category  pgtype.Text `json:"category"`
+	Setup           string        `json:"setup"`
+	Punchline       string        `json:"punchline"`
+	Category        pgtype.Text   `json:"category"`
+	Rating          ContentRating `json:"rating"`
+	ContentWarnings []string      `json:"content_warnings"`
+	Flagged         bool          `json:"flagged"`
+	Language        string        `json:"language"`
+	TranslationOf   pgtype.Int4   `json:"translation_of"`
+	JokeType        string        `json:"joke_type"`
+	Parts           []byte        `json:"parts"`
+	Author          pgtype.Text   `json:"author"`
+	SourceName      pgtype.Text   `json:"source_name"`
+	SourceUrl       pgtype.Text   `json:"source_url"`
+	License         pgtype.Text   `json:"license"`
 }
 
 func (q *Queries) CreateJoke(ctx context.Context, arg CreateJokeParams) (Joke, error) {
-	row := q.db.QueryRow(ctx, createJoke, arg.Setup, arg.Punchline, arg.Category)
+	row := q.db.QueryRow(ctx, createJoke,
+		arg.Setup,
+		arg.Punchline,
+		arg.Category,
+		arg.Rating,
+		arg.ContentWarnings,
+		arg.Flagged,
+		arg.Language,
+		arg.TranslationOf,
+		arg.JokeType,
+		arg.Parts,
+		arg.Author,
+		arg.SourceName,
+		arg.SourceUrl,
+		arg.License,
+	)
 	var i Joke
 	err := row.Scan(
 		&i.ID,
@@ -49,6 +151,17 @@ func (q *Queries) CreateJoke(ctx context.Context, arg CreateJokeParams) (Joke, e
 		&i.Category,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Rating,
+		&i.ContentWarnings,
+		&i.Flagged,
+		&i.Language,
+		&i.TranslationOf,
+		&i.JokeType,
+		&i.Parts,
+		&i.Author,
+		&i.SourceName,
+		&i.SourceUrl,
+		&i.License,
 	)
 	return i, err
 }
@@ -67,6 +180,109 @@ func (q *Queries) CreateTag(ctx context.Context, name string) (Tag, error) {
 	return i, err
 }
 
+const deleteJokesBySource = `-- name: DeleteJokesBySource :many
+WITH deleted AS (
+    DELETE FROM jokes
+    WHERE source_name = $1
+    RETURNING id, setup, punchline, category, created_at, updated_at, rating, content_warnings, flagged, language, translation_of, joke_type, parts, author, source_name, source_url, license
+)
+SELECT d.id, d.setup, d.punchline, d.category, d.created_at, d.updated_at, d.rating, d.content_warnings, d.flagged, d.language, d.translation_of, d.joke_type, d.parts, d.author, d.source_name, d.source_url, d.license,
+       COALESCE(array_agg(t.name ORDER BY t.name) FILTER (WHERE t.name IS NOT NULL), '{}')::text[] AS tags
+FROM deleted d
+LEFT JOIN joke_tags jt ON jt.joke_id = d.id
+LEFT JOIN tags t ON t.id = jt.tag_id
+GROUP BY d.id, d.setup, d.punchline, d.category, d.created_at, d.updated_at, d.rating, d.content_warnings, d.flagged, d.language, d.translation_of, d.joke_type, d.parts, d.author, d.source_name, d.source_url, d.license
+ORDER BY d.id
+`
+
+type DeleteJokesBySourceRow struct {
+	ID              int32              `json:"id"`
+	Setup           string             `json:"setup"`
+	Punchline       string             `json:"punchline"`
+	Category        pgtype.Text        `json:"category"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
+	Rating          ContentRating      `json:"rating"`
+	ContentWarnings []string           `json:"content_warnings"`
+	Flagged         bool               `json:"flagged"`
+	Language        string             `json:"language"`
+	TranslationOf   pgtype.Int4        `json:"translation_of"`
+	JokeType        string             `json:"joke_type"`
+	Parts           []byte             `json:"parts"`
+	Author          pgtype.Text        `json:"author"`
+	SourceName      pgtype.Text        `json:"source_name"`
+	SourceUrl       pgtype.Text        `json:"source_url"`
+	License         pgtype.Text        `json:"license"`
+	Tags            []string           `json:"tags"`
+}
+
+// Returns the deleted jokes with their tags, which the outer query still
+// sees as the delete only takes effect when the statement ends
+func (q *Queries) DeleteJokesBySource(ctx context.Context, sourceName pgtype.Text) ([]DeleteJokesBySourceRow, error) {
+	rows, err := q.db.Query(ctx, deleteJokesBySource, sourceName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DeleteJokesBySourceRow
+	for rows.Next() {
+		var i DeleteJokesBySourceRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Setup,
+			&i.Punchline,
+			&i.Category,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Rating,
+			&i.ContentWarnings,
+			&i.Flagged,
+			&i.Language,
+			&i.TranslationOf,
+			&i.JokeType,
+			&i.Parts,
+			&i.Author,
+			&i.SourceName,
+			&i.SourceUrl,
+			&i.License,
+			&i.Tags,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAPIKeyByPrefix = `-- name: GetAPIKeyByPrefix :one
+SELECT id, name, owner, key_prefix, key_hash, scopes, max_rating, expires_at, last_used_at, revoked_at, created_at, tier
+FROM api_keys
+WHERE key_prefix = $1
+`
+
+func (q *Queries) GetAPIKeyByPrefix(ctx context.Context, keyPrefix string) (ApiKey, error) {
+	row := q.db.QueryRow(ctx, getAPIKeyByPrefix, keyPrefix)
+	var i ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Owner,
+		&i.KeyPrefix,
+		&i.KeyHash,
+		&i.Scopes,
+		&i.MaxRating,
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.Tier,
+	)
+	return i, err
+}
+
 const getAllTags = `-- name: GetAllTags :many
 SELECT name
 FROM tags
@@ -93,8 +309,42 @@ func (q *Queries) GetAllTags(ctx context.Context) ([]string, error) {
 	return items, nil
 }
 
+const getCategories = `-- name: GetCategories :many
+SELECT category::text AS name, COUNT(*) AS joke_count
+FROM jokes
+WHERE category IS NOT NULL
+  AND rating <= $1
+GROUP BY category
+ORDER BY category
+`
+
+type GetCategoriesRow struct {
+	Name      string `json:"name"`
+	JokeCount int64  `json:"joke_count"`
+}
+
+func (q *Queries) GetCategories(ctx context.Context, rating ContentRating) ([]GetCategoriesRow, error) {
+	rows, err := q.db.Query(ctx, getCategories, rating)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetCategoriesRow
+	for rows.Next() {
+		var i GetCategoriesRow
+		if err := rows.Scan(&i.Name, &i.JokeCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getJokeByAllFilters = `-- name: GetJokeByAllFilters :one
-SELECT j.id, j.setup, j.punchline, j.category, j.created_at, j.updated_at
+SELECT j.id, j.setup, j.punchline, j.category, j.created_at, j.updated_at, j.rating, j.content_warnings, j.flagged, j.language, j.translation_of, j.joke_type, j.parts, j.author, j.source_name, j.source_url, j.license
 FROM jokes j
 WHERE j.category = $2
   AND j.id IN (
@@ -103,19 +353,36 @@ WHERE j.category = $2
     INNER JOIN tags t ON jt.tag_id = t.id
     WHERE t.name = ANY($1::text[])
 )
-  AND (j.setup ILIKE '%' || $3 || '%' OR j.punchline ILIKE '%' || $3 || '%')
-ORDER BY RANDOM()
+  AND (j.setup ILIKE '%' || $3 || '%' OR j.punchline ILIKE '%' || $3 || '%'
+       OR to_tsvector(joke_search_config(j.language), j.setup || ' ' || j.punchline) @@ plainto_tsquery(joke_search_config(j.language), $3))
+  AND j.rating <= $4
+  AND j.language = ANY($5::text[])
+  AND (j.joke_type = $6 OR $6 = '')
+  AND (j.source_name = $7 OR $7 = '')
+ORDER BY array_position($5::text[], j.language), RANDOM()
 LIMIT 1
 `
 
 type GetJokeByAllFiltersParams struct {
-	Column1  []string    `json:"column_1"`
-	Category pgtype.Text `json:"category"`
-	Column3  pgtype.Text `json:"column_3"`
+	Column1    []string      `json:"column_1"`
+	Category   pgtype.Text   `json:"category"`
+	Column3    pgtype.Text   `json:"column_3"`
+	Rating     ContentRating `json:"rating"`
+	Column5    []string      `json:"column_5"`
+	JokeType   string        `json:"joke_type"`
+	SourceName pgtype.Text   `json:"source_name"`
 }
 
 func (q *Queries) GetJokeByAllFilters(ctx context.Context, arg GetJokeByAllFiltersParams) (Joke, error) {
-	row := q.db.QueryRow(ctx, getJokeByAllFilters, arg.Column1, arg.Category, arg.Column3)
+	row := q.db.QueryRow(ctx, getJokeByAllFilters,
+		arg.Column1,
+		arg.Category,
+		arg.Column3,
+		arg.Rating,
+		arg.Column5,
+		arg.JokeType,
+		arg.SourceName,
+	)
 	var i Joke
 	err := row.Scan(
 		&i.ID,
@@ -124,20 +391,49 @@ func (q *Queries) GetJokeByAllFilters(ctx context.Context, arg GetJokeByAllFilte
 		&i.Category,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Rating,
+		&i.ContentWarnings,
+		&i.Flagged,
+		&i.Language,
+		&i.TranslationOf,
+		&i.JokeType,
+		&i.Parts,
+		&i.Author,
+		&i.SourceName,
+		&i.SourceUrl,
+		&i.License,
 	)
 	return i, err
 }
 
 const getJokeByCategory = `-- name: GetJokeByCategory :one
-SELECT id, setup, punchline, category, created_at, updated_at
+SELECT id, setup, punchline, category, created_at, updated_at, rating, content_warnings, flagged, language, translation_of, joke_type, parts, author, source_name, source_url, license
 FROM jokes
 WHERE category = $1
-ORDER BY RANDOM()
+  AND rating <= $2
+  AND language = ANY($3::text[])
+  AND (joke_type = $4 OR $4 = '')
+  AND (source_name = $5 OR $5 = '')
+ORDER BY array_position($3::text[], language), RANDOM()
 LIMIT 1
 `
 
-func (q *Queries) GetJokeByCategory(ctx context.Context, category pgtype.Text) (Joke, error) {
-	row := q.db.QueryRow(ctx, getJokeByCategory, category)
+type GetJokeByCategoryParams struct {
+	Category   pgtype.Text   `json:"category"`
+	Rating     ContentRating `json:"rating"`
+	Column3    []string      `json:"column_3"`
+	JokeType   string        `json:"joke_type"`
+	SourceName pgtype.Text   `json:"source_name"`
+}
+
+func (q *Queries) GetJokeByCategory(ctx context.Context, arg GetJokeByCategoryParams) (Joke, error) {
+	row := q.db.QueryRow(ctx, getJokeByCategory,
+		arg.Category,
+		arg.Rating,
+		arg.Column3,
+		arg.JokeType,
+		arg.SourceName,
+	)
 	var i Joke
 	err := row.Scan(
 		&i.ID,
@@ -146,26 +442,53 @@ func (q *Queries) GetJokeByCategory(ctx context.Context, category pgtype.Text) (
 		&i.Category,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Rating,
+		&i.ContentWarnings,
+		&i.Flagged,
+		&i.Language,
+		&i.TranslationOf,
+		&i.JokeType,
+		&i.Parts,
+		&i.Author,
+		&i.SourceName,
+		&i.SourceUrl,
+		&i.License,
 	)
 	return i, err
 }
 
 const getJokeByCategoryAndSearch = `-- name: GetJokeByCategoryAndSearch :one
-SELECT id, setup, punchline, category, created_at, updated_at
+SELECT id, setup, punchline, category, created_at, updated_at, rating, content_warnings, flagged, language, translation_of, joke_type, parts, author, source_name, source_url, license
 FROM jokes
 WHERE category = $1
-  AND (setup ILIKE '%' || $2 || '%' OR punchline ILIKE '%' || $2 || '%')
-ORDER BY RANDOM()
+  AND (setup ILIKE '%' || $2 || '%' OR punchline ILIKE '%' || $2 || '%'
+       OR to_tsvector(joke_search_config(language), setup || ' ' || punchline) @@ plainto_tsquery(joke_search_config(language), $2))
+  AND rating <= $3
+  AND language = ANY($4::text[])
+  AND (joke_type = $5 OR $5 = '')
+  AND (source_name = $6 OR $6 = '')
+ORDER BY array_position($4::text[], language), RANDOM()
 LIMIT 1
 `
 
 type GetJokeByCategoryAndSearchParams struct {
-	Category pgtype.Text `json:"category"`
-	Column2  pgtype.Text `json:"column_2"`
+	Category   pgtype.Text   `json:"category"`
+	Column2    pgtype.Text   `json:"column_2"`
+	Rating     ContentRating `json:"rating"`
+	Column4    []string      `json:"column_4"`
+	JokeType   string        `json:"joke_type"`
+	SourceName pgtype.Text   `json:"source_name"`
 }
 
 func (q *Queries) GetJokeByCategoryAndSearch(ctx context.Context, arg GetJokeByCategoryAndSearchParams) (Joke, error) {
-	row := q.db.QueryRow(ctx, getJokeByCategoryAndSearch, arg.Category, arg.Column2)
+	row := q.db.QueryRow(ctx, getJokeByCategoryAndSearch,
+		arg.Category,
+		arg.Column2,
+		arg.Rating,
+		arg.Column4,
+		arg.JokeType,
+		arg.SourceName,
+	)
 	var i Joke
 	err := row.Scan(
 		&i.ID,
@@ -174,12 +497,23 @@ func (q *Queries) GetJokeByCategoryAndSearch(ctx context.Context, arg GetJokeByC
 		&i.Category,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Rating,
+		&i.ContentWarnings,
+		&i.Flagged,
+		&i.Language,
+		&i.TranslationOf,
+		&i.JokeType,
+		&i.Parts,
+		&i.Author,
+		&i.SourceName,
+		&i.SourceUrl,
+		&i.License,
 	)
 	return i, err
 }
 
 const getJokeByID = `-- name: GetJokeByID :one
-SELECT id, setup, punchline, category, created_at, updated_at
+SELECT id, setup, punchline, category, created_at, updated_at, rating, content_warnings, flagged, language, translation_of, joke_type, parts, author, source_name, source_url, license
 FROM jokes
 WHERE id = $1
 `
@@ -194,12 +528,23 @@ func (q *Queries) GetJokeByID(ctx context.Context, id int32) (Joke, error) {
 		&i.Category,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Rating,
+		&i.ContentWarnings,
+		&i.Flagged,
+		&i.Language,
+		&i.TranslationOf,
+		&i.JokeType,
+		&i.Parts,
+		&i.Author,
+		&i.SourceName,
+		&i.SourceUrl,
+		&i.License,
 	)
 	return i, err
 }
 
 const getJokeByTags = `-- name: GetJokeByTags :one
-SELECT j.id, j.setup, j.punchline, j.category, j.created_at, j.updated_at
+SELECT j.id, j.setup, j.punchline, j.category, j.created_at, j.updated_at, j.rating, j.content_warnings, j.flagged, j.language, j.translation_of, j.joke_type, j.parts, j.author, j.source_name, j.source_url, j.license
 FROM jokes j
 WHERE j.id IN (
     SELECT DISTINCT jt.joke_id
@@ -207,12 +552,30 @@ WHERE j.id IN (
     INNER JOIN tags t ON jt.tag_id = t.id
     WHERE t.name = ANY($1::text[])
 )
-ORDER BY RANDOM()
+  AND j.rating <= $2
+  AND j.language = ANY($3::text[])
+  AND (j.joke_type = $4 OR $4 = '')
+  AND (j.source_name = $5 OR $5 = '')
+ORDER BY array_position($3::text[], j.language), RANDOM()
 LIMIT 1
 `
 
-func (q *Queries) GetJokeByTags(ctx context.Context, dollar_1 []string) (Joke, error) {
-	row := q.db.QueryRow(ctx, getJokeByTags, dollar_1)
+type GetJokeByTagsParams struct {
+	Column1    []string      `json:"column_1"`
+	Rating     ContentRating `json:"rating"`
+	Column3    []string      `json:"column_3"`
+	JokeType   string        `json:"joke_type"`
+	SourceName pgtype.Text   `json:"source_name"`
+}
+
+func (q *Queries) GetJokeByTags(ctx context.Context, arg GetJokeByTagsParams) (Joke, error) {
+	row := q.db.QueryRow(ctx, getJokeByTags,
+		arg.Column1,
+		arg.Rating,
+		arg.Column3,
+		arg.JokeType,
+		arg.SourceName,
+	)
 	var i Joke
 	err := row.Scan(
 		&i.ID,
@@ -221,12 +584,23 @@ func (q *Queries) GetJokeByTags(ctx context.Context, dollar_1 []string) (Joke, e
 		&i.Category,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Rating,
+		&i.ContentWarnings,
+		&i.Flagged,
+		&i.Language,
+		&i.TranslationOf,
+		&i.JokeType,
+		&i.Parts,
+		&i.Author,
+		&i.SourceName,
+		&i.SourceUrl,
+		&i.License,
 	)
 	return i, err
 }
 
 const getJokeByTagsAndCategory = `-- name: GetJokeByTagsAndCategory :one
-SELECT j.id, j.setup, j.punchline, j.category, j.created_at, j.updated_at
+SELECT j.id, j.setup, j.punchline, j.category, j.created_at, j.updated_at, j.rating, j.content_warnings, j.flagged, j.language, j.translation_of, j.joke_type, j.parts, j.author, j.source_name, j.source_url, j.license
 FROM jokes j
 WHERE j.category = $2
   AND j.id IN (
@@ -235,17 +609,32 @@ WHERE j.category = $2
     INNER JOIN tags t ON jt.tag_id = t.id
     WHERE t.name = ANY($1::text[])
 )
-ORDER BY RANDOM()
+  AND j.rating <= $3
+  AND j.language = ANY($4::text[])
+  AND (j.joke_type = $5 OR $5 = '')
+  AND (j.source_name = $6 OR $6 = '')
+ORDER BY array_position($4::text[], j.language), RANDOM()
 LIMIT 1
 `
 
 type GetJokeByTagsAndCategoryParams struct {
-	Column1  []string    `json:"column_1"`
-	Category pgtype.Text `json:"category"`
+	Column1    []string      `json:"column_1"`
+	Category   pgtype.Text   `json:"category"`
+	Rating     ContentRating `json:"rating"`
+	Column4    []string      `json:"column_4"`
+	JokeType   string        `json:"joke_type"`
+	SourceName pgtype.Text   `json:"source_name"`
 }
 
 func (q *Queries) GetJokeByTagsAndCategory(ctx context.Context, arg GetJokeByTagsAndCategoryParams) (Joke, error) {
-	row := q.db.QueryRow(ctx, getJokeByTagsAndCategory, arg.Column1, arg.Category)
+	row := q.db.QueryRow(ctx, getJokeByTagsAndCategory,
+		arg.Column1,
+		arg.Category,
+		arg.Rating,
+		arg.Column4,
+		arg.JokeType,
+		arg.SourceName,
+	)
 	var i Joke
 	err := row.Scan(
 		&i.ID,
@@ -254,12 +643,23 @@ func (q *Queries) GetJokeByTagsAndCategory(ctx context.Context, arg GetJokeByTag
 		&i.Category,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Rating,
+		&i.ContentWarnings,
+		&i.Flagged,
+		&i.Language,
+		&i.TranslationOf,
+		&i.JokeType,
+		&i.Parts,
+		&i.Author,
+		&i.SourceName,
+		&i.SourceUrl,
+		&i.License,
 	)
 	return i, err
 }
 
 const getJokeByTagsAndSearch = `-- name: GetJokeByTagsAndSearch :one
-SELECT j.id, j.setup, j.punchline, j.category, j.created_at, j.updated_at
+SELECT j.id, j.setup, j.punchline, j.category, j.created_at, j.updated_at, j.rating, j.content_warnings, j.flagged, j.language, j.translation_of, j.joke_type, j.parts, j.author, j.source_name, j.source_url, j.license
 FROM jokes j
 WHERE j.id IN (
     SELECT DISTINCT jt.joke_id
@@ -267,18 +667,34 @@ WHERE j.id IN (
     INNER JOIN tags t ON jt.tag_id = t.id
     WHERE t.name = ANY($1::text[])
 )
-  AND (j.setup ILIKE '%' || $2 || '%' OR j.punchline ILIKE '%' || $2 || '%')
-ORDER BY RANDOM()
+  AND (j.setup ILIKE '%' || $2 || '%' OR j.punchline ILIKE '%' || $2 || '%'
+       OR to_tsvector(joke_search_config(j.language), j.setup || ' ' || j.punchline) @@ plainto_tsquery(joke_search_config(j.language), $2))
+  AND j.rating <= $3
+  AND j.language = ANY($4::text[])
+  AND (j.joke_type = $5 OR $5 = '')
+  AND (j.source_name = $6 OR $6 = '')
+ORDER BY array_position($4::text[], j.language), RANDOM()
 LIMIT 1
 `
 
 type GetJokeByTagsAndSearchParams struct {
-	Column1 []string    `json:"column_1"`
-	Column2 pgtype.Text `json:"column_2"`
+	Column1    []string      `json:"column_1"`
+	Column2    pgtype.Text   `json:"column_2"`
+	Rating     ContentRating `json:"rating"`
+	Column4    []string      `json:"column_4"`
+	JokeType   string        `json:"joke_type"`
+	SourceName pgtype.Text   `json:"source_name"`
 }
 
 func (q *Queries) GetJokeByTagsAndSearch(ctx context.Context, arg GetJokeByTagsAndSearchParams) (Joke, error) {
-	row := q.db.QueryRow(ctx, getJokeByTagsAndSearch, arg.Column1, arg.Column2)
+	row := q.db.QueryRow(ctx, getJokeByTagsAndSearch,
+		arg.Column1,
+		arg.Column2,
+		arg.Rating,
+		arg.Column4,
+		arg.JokeType,
+		arg.SourceName,
+	)
 	var i Joke
 	err := row.Scan(
 		&i.ID,
@@ -287,19 +703,123 @@ func (q *Queries) GetJokeByTagsAndSearch(ctx context.Context, arg GetJokeByTagsA
 		&i.Category,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Rating,
+		&i.ContentWarnings,
+		&i.Flagged,
+		&i.Language,
+		&i.TranslationOf,
+		&i.JokeType,
+		&i.Parts,
+		&i.Author,
+		&i.SourceName,
+		&i.SourceUrl,
+		&i.License,
 	)
 	return i, err
 }
 
+const getJokeTranslations = `-- name: GetJokeTranslations :many
+SELECT j.id, j.setup, j.punchline, j.category, j.created_at, j.updated_at, j.rating, j.content_warnings, j.flagged, j.language, j.translation_of, j.joke_type, j.parts, j.author, j.source_name, j.source_url, j.license
+FROM jokes j
+WHERE COALESCE(j.translation_of, j.id) = (
+    SELECT COALESCE(s.translation_of, s.id)
+    FROM jokes s
+    WHERE s.id = $1
+)
+  AND j.id <> $1
+  AND j.rating <= $2
+ORDER BY j.language, j.id
+`
+
+type GetJokeTranslationsParams struct {
+	ID     int32         `json:"id"`
+	Rating ContentRating `json:"rating"`
+}
+
+// Returns every other joke in the same translation group as the given joke
+func (q *Queries) GetJokeTranslations(ctx context.Context, arg GetJokeTranslationsParams) ([]Joke, error) {
+	rows, err := q.db.Query(ctx, getJokeTranslations, arg.ID, arg.Rating)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Joke
+	for rows.Next() {
+		var i Joke
+		if err := rows.Scan(
+			&i.ID,
+			&i.Setup,
+			&i.Punchline,
+			&i.Category,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Rating,
+			&i.ContentWarnings,
+			&i.Flagged,
+			&i.Language,
+			&i.TranslationOf,
+			&i.JokeType,
+			&i.Parts,
+			&i.Author,
+			&i.SourceName,
+			&i.SourceUrl,
+			&i.License,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getJokeVoteTotals = `-- name: GetJokeVoteTotals :one
+SELECT
+    COUNT(*) FILTER (WHERE vote = 1)::bigint AS up,
+    COUNT(*) FILTER (WHERE vote = -1)::bigint AS down
+FROM joke_votes
+WHERE joke_id = $1
+`
+
+type GetJokeVoteTotalsRow struct {
+	Up   int64 `json:"up"`
+	Down int64 `json:"down"`
+}
+
+func (q *Queries) GetJokeVoteTotals(ctx context.Context, jokeID int32) (GetJokeVoteTotalsRow, error) {
+	row := q.db.QueryRow(ctx, getJokeVoteTotals, jokeID)
+	var i GetJokeVoteTotalsRow
+	err := row.Scan(&i.Up, &i.Down)
+	return i, err
+}
+
 const getRandomJoke = `-- name: GetRandomJoke :one
-SELECT id, setup, punchline, category, created_at, updated_at
+SELECT id, setup, punchline, category, created_at, updated_at, rating, content_warnings, flagged, language, translation_of, joke_type, parts, author, source_name, source_url, license
 FROM jokes
-ORDER BY RANDOM()
+WHERE rating <= $1
+  AND language = ANY($2::text[])
+  AND (joke_type = $3 OR $3 = '')
+  AND (source_name = $4 OR $4 = '')
+ORDER BY array_position($2::text[], language), RANDOM()
 LIMIT 1
 `
 
-func (q *Queries) GetRandomJoke(ctx context.Context) (Joke, error) {
-	row := q.db.QueryRow(ctx, getRandomJoke)
+type GetRandomJokeParams struct {
+	Rating     ContentRating `json:"rating"`
+	Column2    []string      `json:"column_2"`
+	JokeType   string        `json:"joke_type"`
+	SourceName pgtype.Text   `json:"source_name"`
+}
+
+func (q *Queries) GetRandomJoke(ctx context.Context, arg GetRandomJokeParams) (Joke, error) {
+	row := q.db.QueryRow(ctx, getRandomJoke,
+		arg.Rating,
+		arg.Column2,
+		arg.JokeType,
+		arg.SourceName,
+	)
 	var i Joke
 	err := row.Scan(
 		&i.ID,
@@ -308,10 +828,113 @@ func (q *Queries) GetRandomJoke(ctx context.Context) (Joke, error) {
 		&i.Category,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Rating,
+		&i.ContentWarnings,
+		&i.Flagged,
+		&i.Language,
+		&i.TranslationOf,
+		&i.JokeType,
+		&i.Parts,
+		&i.Author,
+		&i.SourceName,
+		&i.SourceUrl,
+		&i.License,
 	)
 	return i, err
 }
 
+const getSimilarJokes = `-- name: GetSimilarJokes :many
+WITH source AS (
+    SELECT s.id, s.category, s.language, s.setup || ' ' || s.punchline AS body
+    FROM jokes s
+    WHERE s.id = $1
+)
+SELECT j.id, j.setup, j.punchline, j.category, j.created_at, j.updated_at, j.rating, j.content_warnings, j.flagged, j.language, j.translation_of, j.joke_type, j.parts, j.author, j.source_name, j.source_url, j.license,
+    (
+        (SELECT COUNT(*)
+         FROM joke_tags jt
+         INNER JOIN joke_tags st ON st.tag_id = jt.tag_id AND st.joke_id = source.id
+         WHERE jt.joke_id = j.id)
+        + CASE WHEN j.category = source.category THEN 1 ELSE 0 END
+        + 2 * similarity(j.setup || ' ' || j.punchline, source.body)
+    )::float8 AS score
+FROM jokes j
+CROSS JOIN source
+WHERE j.id <> source.id
+  AND j.language = source.language
+  AND j.rating <= $2
+ORDER BY score DESC, j.id
+LIMIT $3
+`
+
+type GetSimilarJokesParams struct {
+	ID     int32         `json:"id"`
+	Rating ContentRating `json:"rating"`
+	Limit  int32         `json:"limit"`
+}
+
+type GetSimilarJokesRow struct {
+	ID              int32              `json:"id"`
+	Setup           string             `json:"setup"`
+	Punchline       string             `json:"punchline"`
+	Category        pgtype.Text        `json:"category"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
+	Rating          ContentRating      `json:"rating"`
+	ContentWarnings []string           `json:"content_warnings"`
+	Flagged         bool               `json:"flagged"`
+	Language        string             `json:"language"`
+	TranslationOf   pgtype.Int4        `json:"translation_of"`
+	JokeType        string             `json:"joke_type"`
+	Parts           []byte             `json:"parts"`
+	Author          pgtype.Text        `json:"author"`
+	SourceName      pgtype.Text        `json:"source_name"`
+	SourceUrl       pgtype.Text        `json:"source_url"`
+	License         pgtype.Text        `json:"license"`
+	Score           float64            `json:"score"`
+}
+
+// Ranks jokes by shared tags, matching category and trigram similarity of
+// their text to the source joke
+func (q *Queries) GetSimilarJokes(ctx context.Context, arg GetSimilarJokesParams) ([]GetSimilarJokesRow, error) {
+	rows, err := q.db.Query(ctx, getSimilarJokes, arg.ID, arg.Rating, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSimilarJokesRow
+	for rows.Next() {
+		var i GetSimilarJokesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Setup,
+			&i.Punchline,
+			&i.Category,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Rating,
+			&i.ContentWarnings,
+			&i.Flagged,
+			&i.Language,
+			&i.TranslationOf,
+			&i.JokeType,
+			&i.Parts,
+			&i.Author,
+			&i.SourceName,
+			&i.SourceUrl,
+			&i.License,
+			&i.Score,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getTagByName = `-- name: GetTagByName :one
 SELECT id, name, created_at
 FROM tags
@@ -353,17 +976,311 @@ func (q *Queries) GetTagsForJoke(ctx context.Context, jokeID int32) ([]string, e
 	return items, nil
 }
 
+const getTagsForJokes = `-- name: GetTagsForJokes :many
+SELECT jt.joke_id, t.name
+FROM joke_tags jt
+INNER JOIN tags t ON t.id = jt.tag_id
+WHERE jt.joke_id = ANY($1::int[])
+ORDER BY jt.joke_id, t.name
+`
+
+type GetTagsForJokesRow struct {
+	JokeID int32  `json:"joke_id"`
+	Name   string `json:"name"`
+}
+
+func (q *Queries) GetTagsForJokes(ctx context.Context, dollar_1 []int32) ([]GetTagsForJokesRow, error) {
+	rows, err := q.db.Query(ctx, getTagsForJokes, dollar_1)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTagsForJokesRow
+	for rows.Next() {
+		var i GetTagsForJokesRow
+		if err := rows.Scan(&i.JokeID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAPIKeys = `-- name: ListAPIKeys :many
+SELECT id, name, owner, key_prefix, key_hash, scopes, max_rating, expires_at, last_used_at, revoked_at, created_at, tier
+FROM api_keys
+ORDER BY id
+`
+
+func (q *Queries) ListAPIKeys(ctx context.Context) ([]ApiKey, error) {
+	rows, err := q.db.Query(ctx, listAPIKeys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ApiKey
+	for rows.Next() {
+		var i ApiKey
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Owner,
+			&i.KeyPrefix,
+			&i.KeyHash,
+			&i.Scopes,
+			&i.MaxRating,
+			&i.ExpiresAt,
+			&i.LastUsedAt,
+			&i.RevokedAt,
+			&i.CreatedAt,
+			&i.Tier,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAuditEvents = `-- name: ListAuditEvents :many
+SELECT id, occurred_at, actor, action, target_type, target_id, before_state, after_state, client_ip, request_id
+FROM audit_events
+WHERE (actor = $1 OR $1 = '')
+  AND (action = $2 OR $2 = '')
+  AND (target_type = $3 OR $3 = '')
+  AND (target_id = $4 OR $4 = '')
+  AND (occurred_at >= $5 OR $5 IS NULL)
+  AND (occurred_at < $6 OR $6 IS NULL)
+  AND (id < $7 OR $7::bigint = 0)
+ORDER BY id DESC
+LIMIT $8
+`
+
+type ListAuditEventsParams struct {
+	Actor      string             `json:"actor"`
+	Action     string             `json:"action"`
+	TargetType string             `json:"target_type"`
+	TargetID   string             `json:"target_id"`
+	Since      pgtype.Timestamptz `json:"since"`
+	Until      pgtype.Timestamptz `json:"until"`
+	BeforeID   int64              `json:"before_id"`
+	RowLimit   int32              `json:"row_limit"`
+}
+
+// Pages newest first; before_id is the last ID of the previous page, or 0
+func (q *Queries) ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error) {
+	rows, err := q.db.Query(ctx, listAuditEvents,
+		arg.Actor,
+		arg.Action,
+		arg.TargetType,
+		arg.TargetID,
+		arg.Since,
+		arg.Until,
+		arg.BeforeID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditEvent
+	for rows.Next() {
+		var i AuditEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.OccurredAt,
+			&i.Actor,
+			&i.Action,
+			&i.TargetType,
+			&i.TargetID,
+			&i.BeforeState,
+			&i.AfterState,
+			&i.ClientIp,
+			&i.RequestID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listJokes = `-- name: ListJokes :many
+SELECT j.id, j.setup, j.punchline, j.category, j.created_at, j.updated_at, j.rating, j.content_warnings, j.flagged, j.language, j.translation_of, j.joke_type, j.parts, j.author, j.source_name, j.source_url, j.license
+FROM jokes j
+WHERE (j.category = $1::text OR $1::text = '')
+  AND ($2::text = ''
+       OR j.setup ILIKE '%' || $2::text || '%' OR j.punchline ILIKE '%' || $2::text || '%'
+       OR to_tsvector(joke_search_config(j.language), j.setup || ' ' || j.punchline) @@ plainto_tsquery(joke_search_config(j.language), $2::text))
+  AND (cardinality($3::text[]) = 0 OR j.id IN (
+    SELECT jt.joke_id
+    FROM joke_tags jt
+    INNER JOIN tags t ON jt.tag_id = t.id
+    WHERE t.name = ANY($3::text[])
+))
+  AND j.rating <= $4
+  AND (cardinality($5::text[]) = 0 OR j.language = ANY($5::text[]))
+  AND (j.joke_type = $6::text OR $6::text = '')
+  AND (j.source_name = $7::text OR $7::text = '')
+  AND j.id > $8::int
+ORDER BY j.id
+LIMIT $9
+`
+
+type ListJokesParams struct {
+	Category   string        `json:"category"`
+	Search     string        `json:"search"`
+	Tags       []string      `json:"tags"`
+	Rating     ContentRating `json:"rating"`
+	Languages  []string      `json:"languages"`
+	JokeType   string        `json:"joke_type"`
+	SourceName string        `json:"source_name"`
+	AfterID    int32         `json:"after_id"`
+	RowLimit   int32         `json:"row_limit"`
+}
+
+// Pages oldest first; after_id is the last ID of the previous page, or 0.
+// Empty filters match every joke, including an empty languages array.
+func (q *Queries) ListJokes(ctx context.Context, arg ListJokesParams) ([]Joke, error) {
+	rows, err := q.db.Query(ctx, listJokes,
+		arg.Category,
+		arg.Search,
+		arg.Tags,
+		arg.Rating,
+		arg.Languages,
+		arg.JokeType,
+		arg.SourceName,
+		arg.AfterID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Joke
+	for rows.Next() {
+		var i Joke
+		if err := rows.Scan(
+			&i.ID,
+			&i.Setup,
+			&i.Punchline,
+			&i.Category,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Rating,
+			&i.ContentWarnings,
+			&i.Flagged,
+			&i.Language,
+			&i.TranslationOf,
+			&i.JokeType,
+			&i.Parts,
+			&i.Author,
+			&i.SourceName,
+			&i.SourceUrl,
+			&i.License,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const reparentTranslationsBySource = `-- name: ReparentTranslationsBySource :execrows
+WITH groups AS (
+    SELECT j.translation_of AS old_root, MIN(j.id) AS new_root
+    FROM jokes j
+    JOIN jokes o ON o.id = j.translation_of
+    WHERE o.source_name = $1
+      AND j.source_name IS DISTINCT FROM $1
+    GROUP BY j.translation_of
+)
+UPDATE jokes
+SET translation_of = NULLIF(g.new_root, jokes.id)
+FROM groups g
+WHERE jokes.translation_of = g.old_root
+  AND jokes.source_name IS DISTINCT FROM $1
+`
+
+// Moves the surviving translations of each original from the source under
+// the lowest remaining ID in its group, before the originals are deleted,
+// so that ON DELETE SET NULL does not split the group apart
+func (q *Queries) ReparentTranslationsBySource(ctx context.Context, sourceName pgtype.Text) (int64, error) {
+	result, err := q.db.Exec(ctx, reparentTranslationsBySource, sourceName)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const revokeAPIKey = `-- name: RevokeAPIKey :one
+UPDATE api_keys
+SET revoked_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND revoked_at IS NULL
+RETURNING id, name, owner, key_prefix, key_hash, scopes, max_rating, expires_at, last_used_at, revoked_at, created_at, tier
+`
+
+func (q *Queries) RevokeAPIKey(ctx context.Context, id int32) (ApiKey, error) {
+	row := q.db.QueryRow(ctx, revokeAPIKey, id)
+	var i ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Owner,
+		&i.KeyPrefix,
+		&i.KeyHash,
+		&i.Scopes,
+		&i.MaxRating,
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.Tier,
+	)
+	return i, err
+}
+
 const searchJokes = `-- name: SearchJokes :one
-SELECT id, setup, punchline, category, created_at, updated_at
+SELECT id, setup, punchline, category, created_at, updated_at, rating, content_warnings, flagged, language, translation_of, joke_type, parts, author, source_name, source_url, license
 FROM jokes
-WHERE setup ILIKE '%' || $1 || '%'
-   OR punchline ILIKE '%' || $1 || '%'
-ORDER BY RANDOM()
+WHERE (setup ILIKE '%' || $1 || '%' OR punchline ILIKE '%' || $1 || '%'
+       OR to_tsvector(joke_search_config(language), setup || ' ' || punchline) @@ plainto_tsquery(joke_search_config(language), $1))
+  AND rating <= $2
+  AND language = ANY($3::text[])
+  AND (joke_type = $4 OR $4 = '')
+  AND (source_name = $5 OR $5 = '')
+ORDER BY array_position($3::text[], language), RANDOM()
 LIMIT 1
 `
 
-func (q *Queries) SearchJokes(ctx context.Context, dollar_1 pgtype.Text) (Joke, error) {
-	row := q.db.QueryRow(ctx, searchJokes, dollar_1)
+type SearchJokesParams struct {
+	Column1    pgtype.Text   `json:"column_1"`
+	Rating     ContentRating `json:"rating"`
+	Column3    []string      `json:"column_3"`
+	JokeType   string        `json:"joke_type"`
+	SourceName pgtype.Text   `json:"source_name"`
+}
+
+func (q *Queries) SearchJokes(ctx context.Context, arg SearchJokesParams) (Joke, error) {
+	row := q.db.QueryRow(ctx, searchJokes,
+		arg.Column1,
+		arg.Rating,
+		arg.Column3,
+		arg.JokeType,
+		arg.SourceName,
+	)
 	var i Joke
 	err := row.Scan(
 		&i.ID,
@@ -372,6 +1289,48 @@ func (q *Queries) SearchJokes(ctx context.Context, dollar_1 pgtype.Text) (Joke,
 		&i.Category,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Rating,
+		&i.ContentWarnings,
+		&i.Flagged,
+		&i.Language,
+		&i.TranslationOf,
+		&i.JokeType,
+		&i.Parts,
+		&i.Author,
+		&i.SourceName,
+		&i.SourceUrl,
+		&i.License,
 	)
 	return i, err
 }
+
+const touchAPIKey = `-- name: TouchAPIKey :exec
+UPDATE api_keys
+SET last_used_at = CURRENT_TIMESTAMP
+WHERE id = $1
+  AND (last_used_at IS NULL OR last_used_at < CURRENT_TIMESTAMP - INTERVAL '1 minute')
+`
+
+// Records key usage at most once a minute to avoid a write per request
+func (q *Queries) TouchAPIKey(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, touchAPIKey, id)
+	return err
+}
+
+const upsertJokeVote = `-- name: UpsertJokeVote :exec
+INSERT INTO joke_votes (joke_id, voter, vote)
+VALUES ($1, $2, $3)
+ON CONFLICT (joke_id, voter)
+DO UPDATE SET vote = EXCLUDED.vote, updated_at = CURRENT_TIMESTAMP
+`
+
+type UpsertJokeVoteParams struct {
+	JokeID int32  `json:"joke_id"`
+	Voter  string `json:"voter"`
+	Vote   int16  `json:"vote"`
+}
+
+func (q *Queries) UpsertJokeVote(ctx context.Context, arg UpsertJokeVoteParams) error {
+	_, err := q.db.Exec(ctx, upsertJokeVote, arg.JokeID, arg.Voter, arg.Vote)
+	return err
+}
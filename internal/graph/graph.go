@@ -0,0 +1,156 @@
+// Package graph serves the joke API over GraphQL at /graphql, so clients
+// can fetch a joke with its tags, similar jokes and category in one request
+// and select only the fields they need. Resolvers share JokeService with
+// the HTTP handlers, and requests pass through the same middleware.
+//
+// Jokes arrive with their tags: lists load them for every joke at once
+// (see JokeService.GetTagsForJokes), and category counts are loaded once
+// per request, so resolvers never query per joke for them.
+package graph
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/cdunlap/djaas/internal/logging"
+	"github.com/cdunlap/djaas/internal/middleware"
+	"github.com/cdunlap/djaas/internal/service"
+	graphql "github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+)
+
+//go:embed schema.graphql
+var schema string
+
+// Query limits, bounding the work one request can ask for
+const (
+	maxBodyBytes   = 1 << 20
+	maxQueryLength = 10000
+	maxQueryDepth  = 7
+)
+
+// JokeMetrics records the jokes served, for metrics
+type JokeMetrics interface {
+	JokeServed(category *string)
+	NoJokesFound()
+}
+
+// Handler serves GraphQL requests, POSTed as JSON
+type Handler struct {
+	schema *graphql.Schema
+	logger *slog.Logger
+}
+
+// Config configures a Handler
+type Config struct {
+	// RateLimiter charges mutations to the write policy and searches to the
+	// search policy, on top of the graphql policy of the route; nil
+	// disables this
+	RateLimiter *middleware.RateLimiter
+}
+
+// New creates a Handler resolving queries with jokes
+func New(jokes *service.JokeService, metrics JokeMetrics, logger *slog.Logger, config Config) *Handler {
+	h := &Handler{logger: logger}
+	h.schema = graphql.MustParseSchema(schema,
+		&resolver{jokes: jokes, metrics: metrics, logger: logger, limiter: config.RateLimiter},
+		graphql.UseStringDescriptions(),
+		graphql.MaxQueryLength(maxQueryLength),
+		graphql.MaxDepth(maxQueryDepth),
+		graphql.Logger(h),
+		graphql.PanicHandler(h),
+	)
+	return h
+}
+
+// graphqlRequest is the body of a GraphQL request
+type graphqlRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// ServeHTTP executes a GraphQL request. Errors in the query or its
+// resolvers are reported in the response's errors list, each with an
+// extensions.code matching the REST API's error codes.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req graphqlRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes)).Decode(&req); err != nil {
+		h.writeResponse(w, r, http.StatusBadRequest, errorResponse(r.Context(), "invalid_json", "Invalid JSON request body"))
+		return
+	}
+	if req.Query == "" {
+		h.writeResponse(w, r, http.StatusBadRequest, errorResponse(r.Context(), "missing_query", "query is required"))
+		return
+	}
+
+	ctx := withRequestState(r.Context(), r.Header.Get("Accept-Language"))
+	h.writeResponse(w, r, http.StatusOK, h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables))
+}
+
+// writeResponse writes a GraphQL response
+func (h *Handler) writeResponse(w http.ResponseWriter, r *http.Request, status int, resp *graphql.Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logging.FromContext(r.Context(), h.logger).ErrorContext(r.Context(), "failed to encode GraphQL response", "error", err)
+	}
+}
+
+// LogPanic logs a panic recovered from a resolver with its stack trace
+func (h *Handler) LogPanic(ctx context.Context, value any) {
+	logging.FromContext(ctx, h.logger).ErrorContext(ctx, "panic recovered",
+		"error", value,
+		"stack", string(debug.Stack()),
+	)
+}
+
+// MakePanicError reports a panic recovered from a resolver without
+// revealing its cause
+func (h *Handler) MakePanicError(ctx context.Context, _ any) *gqlerrors.QueryError {
+	return queryError(ctx, "internal_error", "An internal error occurred")
+}
+
+// errorResponse builds a response carrying a single error
+func errorResponse(ctx context.Context, code, message string) *graphql.Response {
+	return &graphql.Response{Errors: []*gqlerrors.QueryError{queryError(ctx, code, message)}}
+}
+
+// queryError builds a GraphQL error that did not come from a resolver
+func queryError(ctx context.Context, code, message string) *gqlerrors.QueryError {
+	err := &resolverError{code: code, message: message, requestID: middleware.RequestIDFromContext(ctx)}
+	return &gqlerrors.QueryError{Message: message, Extensions: err.Extensions()}
+}
+
+// resolverError is an error returned by a resolver. Its code, the request
+// ID and any retry delay are reported in the GraphQL error's extensions.
+type resolverError struct {
+	code       string
+	message    string
+	requestID  string
+	retryAfter time.Duration
+}
+
+func (e *resolverError) Error() string {
+	return e.message
+}
+
+// Extensions implements the graphql-go interface for error extensions
+func (e *resolverError) Extensions() map[string]any {
+	extensions := map[string]any{"code": e.code, "request_id": e.requestID}
+	if e.retryAfter > 0 {
+		extensions["retry_after_ms"] = e.retryAfter.Milliseconds()
+	}
+	return extensions
+}
+
+// newError returns a resolverError for the request in ctx
+func newError(ctx context.Context, code, message string) error {
+	return &resolverError{code: code, message: message, requestID: middleware.RequestIDFromContext(ctx)}
+}
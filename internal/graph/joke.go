@@ -0,0 +1,233 @@
+package graph
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/cdunlap/djaas/internal/middleware"
+	"github.com/cdunlap/djaas/internal/model"
+	"github.com/cdunlap/djaas/internal/service"
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+const (
+	minSimilarLimit = 1
+	maxSimilarLimit = 20
+)
+
+// jokeResolver resolves the Joke type
+type jokeResolver struct {
+	root *resolver
+	joke model.Joke
+}
+
+// newJoke returns a resolver for joke
+func (r *resolver) newJoke(joke model.Joke) *jokeResolver {
+	return &jokeResolver{root: r, joke: joke}
+}
+
+// newJokes returns resolvers for jokes
+func (r *resolver) newJokes(jokes []model.Joke) []*jokeResolver {
+	resolvers := make([]*jokeResolver, 0, len(jokes))
+	for _, joke := range jokes {
+		resolvers = append(resolvers, r.newJoke(joke))
+	}
+	return resolvers
+}
+
+func (j *jokeResolver) ID() graphql.ID {
+	return graphql.ID(strconv.Itoa(int(j.joke.ID)))
+}
+
+func (j *jokeResolver) Setup() string {
+	return j.joke.Setup
+}
+
+func (j *jokeResolver) Punchline() string {
+	return j.joke.Punchline
+}
+
+func (j *jokeResolver) Type() string {
+	return strings.ToUpper(string(j.joke.Type))
+}
+
+func (j *jokeResolver) Parts() []*jokePartResolver {
+	parts := make([]*jokePartResolver, 0, len(j.joke.Parts))
+	for _, part := range j.joke.Parts {
+		parts = append(parts, &jokePartResolver{part: part})
+	}
+	return parts
+}
+
+// Category resolves the joke's category; its joke count is loaded only
+// when selected
+func (j *jokeResolver) Category() *categoryResolver {
+	if j.joke.Category == nil {
+		return nil
+	}
+	return &categoryResolver{root: j.root, name: *j.joke.Category}
+}
+
+func (j *jokeResolver) Tags() []string {
+	return j.joke.Tags
+}
+
+func (j *jokeResolver) Rating() string {
+	return strings.ToUpper(string(j.joke.Rating))
+}
+
+func (j *jokeResolver) ContentWarnings() []string {
+	return j.joke.ContentWarnings
+}
+
+func (j *jokeResolver) Flagged() bool {
+	return j.joke.Flagged
+}
+
+func (j *jokeResolver) Language() string {
+	return j.joke.Language
+}
+
+func (j *jokeResolver) TranslationOf() *graphql.ID {
+	if j.joke.TranslationOf == nil {
+		return nil
+	}
+	id := graphql.ID(strconv.Itoa(int(*j.joke.TranslationOf)))
+	return &id
+}
+
+func (j *jokeResolver) Author() *string {
+	return j.joke.Author
+}
+
+func (j *jokeResolver) SourceName() *string {
+	return j.joke.SourceName
+}
+
+func (j *jokeResolver) SourceURL() *string {
+	return j.joke.SourceURL
+}
+
+func (j *jokeResolver) License() *string {
+	return j.joke.License
+}
+
+func (j *jokeResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: j.joke.CreatedAt}
+}
+
+func (j *jokeResolver) UpdatedAt() graphql.Time {
+	return graphql.Time{Time: j.joke.UpdatedAt}
+}
+
+// Similar resolves the jokes most like this one, within the caller's
+// rating ceiling. It counts towards maxLookups.
+func (j *jokeResolver) Similar(ctx context.Context, args struct{ Limit int32 }) ([]*similarJokeResolver, error) {
+	if args.Limit < minSimilarLimit || args.Limit > maxSimilarLimit {
+		return nil, newError(ctx, "invalid_limit", "limit must be between 1 and 20")
+	}
+	if err := lookup(ctx); err != nil {
+		return nil, err
+	}
+
+	filter := service.JokeFilter{MaxRating: middleware.RatingCeilingFromContext(ctx)}
+	similar, err := j.root.jokes.GetSimilarJokes(ctx, j.joke.ID, args.Limit, filter)
+	if err != nil {
+		return nil, j.root.serviceError(ctx, err)
+	}
+
+	resolvers := make([]*similarJokeResolver, 0, len(similar))
+	for _, s := range similar {
+		resolvers = append(resolvers, &similarJokeResolver{joke: j.root.newJoke(s.Joke), score: s.Score})
+	}
+	return resolvers, nil
+}
+
+// Translations resolves the joke's translations, within the caller's
+// rating ceiling. It counts towards maxLookups.
+func (j *jokeResolver) Translations(ctx context.Context) ([]*jokeResolver, error) {
+	if err := lookup(ctx); err != nil {
+		return nil, err
+	}
+
+	filter := service.JokeFilter{MaxRating: middleware.RatingCeilingFromContext(ctx)}
+	translations, err := j.root.jokes.GetJokeTranslations(ctx, j.joke.ID, filter)
+	if err != nil {
+		return nil, j.root.serviceError(ctx, err)
+	}
+	return j.root.newJokes(translations), nil
+}
+
+// jokePartResolver resolves the JokePart type
+type jokePartResolver struct {
+	part model.JokePart
+}
+
+func (p *jokePartResolver) Speaker() *string {
+	if p.part.Speaker == "" {
+		return nil
+	}
+	return &p.part.Speaker
+}
+
+func (p *jokePartResolver) Text() string {
+	return p.part.Text
+}
+
+// similarJokeResolver resolves the SimilarJoke type
+type similarJokeResolver struct {
+	joke  *jokeResolver
+	score float64
+}
+
+func (s *similarJokeResolver) Joke() *jokeResolver {
+	return s.joke
+}
+
+func (s *similarJokeResolver) Score() float64 {
+	return s.score
+}
+
+// jokeListResolver resolves the JokeList type
+type jokeListResolver struct {
+	list  *model.JokeList
+	jokes []*jokeResolver
+}
+
+func (l *jokeListResolver) Jokes() []*jokeResolver {
+	return l.jokes
+}
+
+func (l *jokeListResolver) NextCursor() *string {
+	if l.list.NextCursor == "" {
+		return nil
+	}
+	return &l.list.NextCursor
+}
+
+// categoryResolver resolves the Category type. count is set when the
+// category was listed with its count, and is otherwise loaded with the
+// request's other category counts.
+type categoryResolver struct {
+	root  *resolver
+	name  string
+	count *int64
+}
+
+func (c *categoryResolver) Name() string {
+	return c.name
+}
+
+func (c *categoryResolver) JokeCount(ctx context.Context) (int32, error) {
+	if c.count != nil {
+		return int32(*c.count), nil
+	}
+
+	filter := service.JokeFilter{MaxRating: middleware.RatingCeilingFromContext(ctx)}
+	count, err := requestStateFromContext(ctx).categoryCount(ctx, c.root.jokes, filter, c.name)
+	if err != nil {
+		return 0, c.root.serviceError(ctx, err)
+	}
+	return int32(count), nil
+}
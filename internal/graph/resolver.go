@@ -0,0 +1,422 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/cdunlap/djaas/internal/language"
+	"github.com/cdunlap/djaas/internal/logging"
+	"github.com/cdunlap/djaas/internal/middleware"
+	"github.com/cdunlap/djaas/internal/model"
+	"github.com/cdunlap/djaas/internal/service"
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// maxJokesCreated is how many jokes one request may create, so aliases
+// cannot turn a request into a bulk import
+const maxJokesCreated = 1
+
+// maxLookups is how many database lookups one request may make: every
+// joke, randomJoke, jokes, tags, categories, similar and translations
+// field resolved counts, so neither aliases nor similar on a list of jokes
+// can turn one request into hundreds of queries. Category counts are
+// loaded at most once per request and do not count.
+const maxLookups = 20
+
+// resolver resolves the Query and Mutation types
+type resolver struct {
+	jokes   *service.JokeService
+	metrics JokeMetrics
+	logger  *slog.Logger
+	limiter *middleware.RateLimiter
+}
+
+// jokeFilterInput is the JokeFilter input type
+type jokeFilterInput struct {
+	Search    *string
+	Category  *string
+	Tags      *[]string
+	MaxRating *string
+	Lang      *string
+	Type      *string
+	Source    *string
+}
+
+// Joke resolves Query.joke
+func (r *resolver) Joke(ctx context.Context, args struct{ ID graphql.ID }) (*jokeResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, newError(ctx, "invalid_id", "id must be a positive integer")
+	}
+	if err := lookup(ctx); err != nil {
+		return nil, err
+	}
+
+	filter := service.JokeFilter{MaxRating: middleware.RatingCeilingFromContext(ctx)}
+	joke, err := r.jokes.GetJoke(ctx, id, filter)
+	if errors.Is(err, service.ErrJokeNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, r.serviceError(ctx, err)
+	}
+
+	return r.newJoke(*joke), nil
+}
+
+// RandomJoke resolves Query.randomJoke
+func (r *resolver) RandomJoke(ctx context.Context, args struct{ Filter *jokeFilterInput }) (*jokeResolver, error) {
+	query, filter, err := jokeFilter(ctx, args.Filter, requestStateFromContext(ctx).acceptLanguage)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.chargeSearch(ctx, query); err != nil {
+		return nil, err
+	}
+
+	joke, err := r.jokes.FindJoke(ctx, query, filter)
+	if errors.Is(err, service.ErrNoJokesFound) {
+		r.metrics.NoJokesFound()
+		return nil, nil
+	}
+	if err != nil {
+		return nil, r.serviceError(ctx, err)
+	}
+
+	r.metrics.JokeServed(joke.Category)
+	return r.newJoke(*joke), nil
+}
+
+// Jokes resolves Query.jokes
+func (r *resolver) Jokes(ctx context.Context, args struct {
+	Filter *jokeFilterInput
+	Limit  int32
+	Cursor *string
+}) (*jokeListResolver, error) {
+	query, filter, err := jokeFilter(ctx, args.Filter, "")
+	if err != nil {
+		return nil, err
+	}
+	if args.Limit < 1 || args.Limit > service.MaxJokeListLimit {
+		return nil, newError(ctx, "invalid_limit", "limit must be between 1 and 100")
+	}
+	if err := r.chargeSearch(ctx, query); err != nil {
+		return nil, err
+	}
+
+	page := service.JokePage{Limit: int(args.Limit)}
+	if args.Cursor != nil {
+		page.Cursor = *args.Cursor
+	}
+
+	list, err := r.jokes.ListJokes(ctx, query, filter, page)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidInput) && page.Cursor != "" {
+			return nil, newError(ctx, "invalid_cursor", "cursor must be the nextCursor of a previous page")
+		}
+		return nil, r.serviceError(ctx, err)
+	}
+
+	return &jokeListResolver{list: list, jokes: r.newJokes(list.Jokes)}, nil
+}
+
+// Tags resolves Query.tags
+func (r *resolver) Tags(ctx context.Context) ([]string, error) {
+	if err := lookup(ctx); err != nil {
+		return nil, err
+	}
+	tags, err := r.jokes.GetAllTags(ctx)
+	if err != nil {
+		return nil, r.serviceError(ctx, err)
+	}
+	return tags, nil
+}
+
+// Categories resolves Query.categories
+func (r *resolver) Categories(ctx context.Context) ([]*categoryResolver, error) {
+	if err := lookup(ctx); err != nil {
+		return nil, err
+	}
+	filter := service.JokeFilter{MaxRating: middleware.RatingCeilingFromContext(ctx)}
+	categories, err := r.jokes.GetCategories(ctx, filter)
+	if err != nil {
+		return nil, r.serviceError(ctx, err)
+	}
+
+	resolvers := make([]*categoryResolver, 0, len(categories))
+	for _, category := range categories {
+		count := category.JokeCount
+		resolvers = append(resolvers, &categoryResolver{root: r, name: category.Name, count: &count})
+	}
+	return resolvers, nil
+}
+
+// createJokeInput is the CreateJokeInput input type
+type createJokeInput struct {
+	Setup           *string
+	Punchline       *string
+	Type            *string
+	Parts           *[]jokePartInput
+	Category        *string
+	Tags            *[]string
+	Rating          *string
+	ContentWarnings *[]string
+	Language        *string
+	TranslationOf   *graphql.ID
+	Author          *string
+	SourceName      *string
+	SourceURL       *string
+	License         *string
+}
+
+// jokePartInput is the JokePartInput input type
+type jokePartInput struct {
+	Speaker *string
+	Text    string
+}
+
+// CreateJoke resolves Mutation.createJoke. The route is open to anonymous
+// callers for queries, so the jokes:write scope is checked here.
+func (r *resolver) CreateJoke(ctx context.Context, args struct{ Input createJokeInput }) (*jokeResolver, error) {
+	principal := middleware.PrincipalFromContext(ctx)
+	if principal == nil {
+		return nil, newError(ctx, "unauthorized", "Authentication required: send an X-API-Token header or an Authorization: Bearer token")
+	}
+	if !principal.HasScope(model.ScopeJokesWrite) {
+		return nil, newError(ctx, "forbidden", "This operation requires the jokes:write scope")
+	}
+
+	state := requestStateFromContext(ctx)
+	if state.jokesCreated >= maxJokesCreated {
+		return nil, newError(ctx, "too_many_mutations", "Only one joke may be created per request; use POST /api/v1/jokes/import for bulk imports")
+	}
+	state.jokesCreated++
+
+	// Charged like POST /joke, beyond the graphql policy of the request
+	if err := r.charge(ctx, "write"); err != nil {
+		return nil, err
+	}
+
+	newJoke, err := toNewJoke(ctx, args.Input)
+	if err != nil {
+		return nil, err
+	}
+
+	joke, err := r.jokes.CreateJoke(ctx, newJoke)
+	if err != nil {
+		return nil, r.serviceError(ctx, err)
+	}
+
+	return r.newJoke(*joke), nil
+}
+
+// lookup counts a database lookup against the request's maxLookups
+func lookup(ctx context.Context) error {
+	if !requestStateFromContext(ctx).takeLookup() {
+		return newError(ctx, "too_many_lookups", "A request may resolve joke, randomJoke, jokes, tags, categories, similar and translations at most "+strconv.Itoa(maxLookups)+" times in all")
+	}
+	return nil
+}
+
+// chargeSearch counts a joke lookup against maxLookups and, when it has a
+// search query, against the caller's search policy, as GET /joke?search=
+// is
+func (r *resolver) chargeSearch(ctx context.Context, query service.JokeQuery) error {
+	if err := lookup(ctx); err != nil {
+		return err
+	}
+	if query.Search == "" {
+		return nil
+	}
+	return r.charge(ctx, "search")
+}
+
+// charge counts work against the caller's rate limit and daily quota under
+// the named policy, as the REST route doing the same work would be. It is
+// in addition to the graphql policy charged for the request.
+func (r *resolver) charge(ctx context.Context, policy string) error {
+	if r.limiter == nil {
+		return nil
+	}
+
+	v := r.limiter.Check(ctx, r.logger, middleware.PrincipalFromContext(ctx), middleware.ClientIPFromContext(ctx), policy)
+	if v.Allowed {
+		return nil
+	}
+	return &resolverError{code: v.Reason, message: v.Message, requestID: middleware.RequestIDFromContext(ctx), retryAfter: v.RetryAfter}
+}
+
+// jokeFilter converts a JokeFilter input, capping its rating at the
+// caller's ceiling as the REST API does. Its lang takes precedence over
+// acceptLanguage.
+func jokeFilter(ctx context.Context, input *jokeFilterInput, acceptLanguage string) (service.JokeQuery, service.JokeFilter, error) {
+	ceiling := middleware.RatingCeilingFromContext(ctx)
+	filter := service.JokeFilter{MaxRating: ceiling}
+	if input == nil {
+		input = &jokeFilterInput{}
+	}
+
+	if input.MaxRating != nil {
+		maxRating, err := parseRating(*input.MaxRating)
+		if err != nil {
+			return service.JokeQuery{}, filter, newError(ctx, "invalid_rating", "maxRating must be one of G, PG, PG13, R")
+		}
+		if !maxRating.Exceeds(ceiling) {
+			filter.MaxRating = maxRating
+		}
+	}
+
+	languages, err := language.Preferences(valueOf(input.Lang), acceptLanguage)
+	if err != nil {
+		return service.JokeQuery{}, filter, newError(ctx, "invalid_language", "lang must be a language code such as en, es or de")
+	}
+	filter.Languages = languages
+
+	if input.Type != nil {
+		jokeType, err := parseJokeType(*input.Type)
+		if err != nil {
+			return service.JokeQuery{}, filter, newError(ctx, "invalid_type", "type must be one of SETUP_PUNCHLINE, ONE_LINER, KNOCK_KNOCK, MULTI_PART")
+		}
+		filter.Type = jokeType
+	}
+	filter.Source = valueOf(input.Source)
+
+	query := service.JokeQuery{Search: valueOf(input.Search), Category: valueOf(input.Category)}
+	if input.Tags != nil {
+		for _, tag := range *input.Tags {
+			if trimmed := strings.TrimSpace(tag); trimmed != "" {
+				query.Tags = append(query.Tags, trimmed)
+			}
+		}
+	}
+
+	return query, filter, nil
+}
+
+// toNewJoke validates a CreateJokeInput and converts it for the service
+// layer. Structural checks, such as the parts each joke type needs, are
+// left to JokeService.
+func toNewJoke(ctx context.Context, input createJokeInput) (service.NewJoke, error) {
+	invalid := func(code, message string) (service.NewJoke, error) {
+		return service.NewJoke{}, newError(ctx, code, message)
+	}
+
+	newJoke := service.NewJoke{
+		Setup:      valueOf(input.Setup),
+		Punchline:  valueOf(input.Punchline),
+		Type:       model.TypeSetupPunchline,
+		Category:   input.Category,
+		Rating:     model.RatingG,
+		Language:   valueOf(input.Language),
+		Author:     input.Author,
+		SourceName: input.SourceName,
+		SourceURL:  input.SourceURL,
+		License:    input.License,
+	}
+
+	if input.Type != nil {
+		jokeType, err := parseJokeType(*input.Type)
+		if err != nil {
+			return invalid("invalid_type", "type must be one of SETUP_PUNCHLINE, ONE_LINER, KNOCK_KNOCK, MULTI_PART")
+		}
+		newJoke.Type = jokeType
+	}
+
+	if input.Parts != nil {
+		for _, part := range *input.Parts {
+			if strings.TrimSpace(part.Text) == "" {
+				return invalid("missing_fields", "Every part needs text")
+			}
+			newJoke.Parts = append(newJoke.Parts, model.JokePart{Speaker: valueOf(part.Speaker), Text: part.Text})
+		}
+	}
+
+	if input.Rating != nil {
+		rating, err := parseRating(*input.Rating)
+		if err != nil {
+			return invalid("invalid_rating", "rating must be one of G, PG, PG13, R")
+		}
+		newJoke.Rating = rating
+	}
+
+	if newJoke.Language != "" {
+		if _, err := language.Normalize(newJoke.Language); err != nil {
+			return invalid("invalid_language", "language must be a language code such as en, es or de")
+		}
+	}
+
+	if input.TranslationOf != nil {
+		id, err := parseID(*input.TranslationOf)
+		if err != nil {
+			return invalid("invalid_translation", "translationOf must be the ID of an existing joke")
+		}
+		newJoke.TranslationOf = &id
+	}
+
+	if input.SourceURL != nil {
+		if u, err := url.Parse(*input.SourceURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return invalid("invalid_source_url", "sourceUrl must be an absolute http or https URL")
+		}
+	}
+
+	if input.Tags != nil {
+		newJoke.Tags = *input.Tags
+	}
+	if input.ContentWarnings != nil {
+		newJoke.ContentWarnings = *input.ContentWarnings
+	}
+
+	return newJoke, nil
+}
+
+// serviceError maps a JokeService error to a GraphQL error
+func (r *resolver) serviceError(ctx context.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrNoJokesFound):
+		r.metrics.NoJokesFound()
+		return newError(ctx, "not_found", "No jokes found matching your criteria")
+	case errors.Is(err, service.ErrJokeNotFound):
+		return newError(ctx, "not_found", "Joke not found")
+	case errors.Is(err, service.ErrInvalidTranslation):
+		return newError(ctx, "invalid_translation", "translationOf must reference an existing joke with no translation in this language")
+	case errors.Is(err, service.ErrInvalidInput):
+		return newError(ctx, "invalid_input", "Invalid search query, category, tags or joke structure")
+	default:
+		logging.FromContext(ctx, r.logger).ErrorContext(ctx, "graphql resolver failed", "error", err)
+		return newError(ctx, "internal_error", "An internal error occurred")
+	}
+}
+
+// parseID parses a joke ID
+func parseID(id graphql.ID) (int32, error) {
+	parsed, err := strconv.ParseInt(string(id), 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	if parsed <= 0 {
+		return 0, strconv.ErrRange
+	}
+	return int32(parsed), nil
+}
+
+// parseJokeType parses a JokeType enum value
+func parseJokeType(value string) (model.JokeType, error) {
+	return model.ParseJokeType(strings.ToLower(value))
+}
+
+// parseRating parses a ContentRating enum value
+func parseRating(value string) (model.ContentRating, error) {
+	return model.ParseContentRating(strings.ToLower(value))
+}
+
+// valueOf returns the string s points to, or "" for nil
+func valueOf(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
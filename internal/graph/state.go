@@ -0,0 +1,66 @@
+package graph
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/cdunlap/djaas/internal/service"
+)
+
+type requestStateKey struct{}
+
+// requestState is shared by the resolvers of one request
+type requestState struct {
+	acceptLanguage string
+
+	categoriesOnce sync.Once
+	categoryCounts map[string]int64
+	categoriesErr  error
+
+	// jokesCreated counts createJoke calls. Mutations run one after
+	// another, so it needs no lock.
+	jokesCreated int
+
+	// lookups counts the database lookups of resolvers, which may run
+	// concurrently
+	lookups atomic.Int32
+}
+
+// withRequestState returns a context carrying new request state
+func withRequestState(ctx context.Context, acceptLanguage string) context.Context {
+	return context.WithValue(ctx, requestStateKey{}, &requestState{acceptLanguage: acceptLanguage})
+}
+
+// requestStateFromContext returns the request's state, or empty state if
+// ctx has none
+func requestStateFromContext(ctx context.Context) *requestState {
+	if state, ok := ctx.Value(requestStateKey{}).(*requestState); ok {
+		return state
+	}
+	return &requestState{}
+}
+
+// categoryCount returns the number of jokes in category at or below the
+// filter's rating ceiling. Every category is counted with the first call,
+// so a list of jokes costs one query however many categories it shows.
+func (s *requestState) categoryCount(ctx context.Context, jokes *service.JokeService, filter service.JokeFilter, category string) (int64, error) {
+	s.categoriesOnce.Do(func() {
+		categories, err := jokes.GetCategories(ctx, filter)
+		if err != nil {
+			s.categoriesErr = err
+			return
+		}
+		s.categoryCounts = make(map[string]int64, len(categories))
+		for _, c := range categories {
+			s.categoryCounts[c.Name] = c.JokeCount
+		}
+	})
+	return s.categoryCounts[category], s.categoriesErr
+}
+
+// takeLookup counts a database lookup, and reports whether the request is
+// still within maxLookups
+func (s *requestState) takeLookup() bool {
+	return s.lookups.Add(1) <= maxLookups
+}
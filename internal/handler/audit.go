@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cdunlap/djaas/internal/service"
+)
+
+// HandleListAudit handles GET /api/v1/audit requests
+// @Summary List audit events
+// @Description List write and admin operations, newest first: who performed them, on what, from where, and the target before and after.
+// @Description Pass next_cursor from a response as cursor to fetch the following page.
+// @Tags Audit
+// @Accept json
+// @Produce json
+// @Param actor query string false "Caller subject, e.g. key:12 or jwt:alice"
+// @Param action query string false "Action, e.g. joke.create, joke.delete, tag.create, api_key.create, api_key.revoke"
+// @Param target_type query string false "Target type: joke, tag or api_key"
+// @Param target_id query string false "Target ID (joke or key ID, or tag name); requires target_type"
+// @Param since query string false "Only events at or after this RFC 3339 time"
+// @Param until query string false "Only events before this RFC 3339 time"
+// @Param limit query int false "Events per page (1-200, default 50)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Success 200 {object} model.AuditEventList
+// @Failure 400 {object} model.ErrorResponse "Invalid filter, limit or cursor"
+// @Failure 401 {object} model.ErrorResponse "Missing or invalid credentials"
+// @Failure 403 {object} model.ErrorResponse "Missing the moderate scope"
+// @Failure 500 {object} model.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /audit [get]
+func (h *Handler) HandleListAudit(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	filter := service.AuditFilter{
+		Actor:      query.Get("actor"),
+		Action:     query.Get("action"),
+		TargetType: query.Get("target_type"),
+		TargetID:   query.Get("target_id"),
+		Cursor:     query.Get("cursor"),
+	}
+	if filter.TargetID != "" && filter.TargetType == "" {
+		h.writeErrorJSON(w, r, http.StatusBadRequest, "invalid_filter", "target_id requires target_type")
+		return
+	}
+
+	var err error
+	if filter.Since, err = parseTimeParam(query.Get("since")); err != nil {
+		h.writeErrorJSON(w, r, http.StatusBadRequest, "invalid_since", err.Error())
+		return
+	}
+	if filter.Until, err = parseTimeParam(query.Get("until")); err != nil {
+		h.writeErrorJSON(w, r, http.StatusBadRequest, "invalid_until", err.Error())
+		return
+	}
+
+	if limitParam := query.Get("limit"); limitParam != "" {
+		limit, err := strconv.Atoi(limitParam)
+		if err != nil || limit < 1 || limit > service.MaxAuditLimit {
+			h.writeErrorJSON(w, r, http.StatusBadRequest, "invalid_limit", fmt.Sprintf("Limit must be between 1 and %d", service.MaxAuditLimit))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	events, err := h.audit.List(ctx, filter)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidInput) {
+			h.writeErrorJSON(w, r, http.StatusBadRequest, "invalid_cursor", "cursor must be a next_cursor returned by this endpoint")
+			return
+		}
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, events)
+}
+
+// parseTimeParam parses an optional RFC 3339 query parameter
+func parseTimeParam(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not an RFC 3339 time such as 2024-01-02T15:04:05Z", value)
+	}
+	return &t, nil
+}
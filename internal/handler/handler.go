@@ -1,24 +1,74 @@
 package handler
 
 import (
+	"context"
 	"log/slog"
+	"sync"
 
+	"github.com/cdunlap/djaas/internal/health"
+	"github.com/cdunlap/djaas/internal/logging"
+	"github.com/cdunlap/djaas/internal/middleware"
+	"github.com/cdunlap/djaas/internal/model"
+	"github.com/cdunlap/djaas/internal/render"
 	"github.com/cdunlap/djaas/internal/service"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// UsageReporter reports a caller's rate limits and quota usage
+type UsageReporter interface {
+	Usage(ctx context.Context, principal *model.Principal) (model.Usage, error)
+}
+
+// JokeMetrics records the jokes served, for metrics
+type JokeMetrics interface {
+	JokeServed(category *string)
+	NoJokesFound()
+}
+
 // Handler holds dependencies for HTTP handlers
 type Handler struct {
 	jokeService *service.JokeService
 	logger      *slog.Logger
 	dbPool      *pgxpool.Pool
+	usage       UsageReporter
+	metrics     JokeMetrics
+	audit       *service.AuditService
+	health      *health.Health
+	renderers   *render.Registry
+	// streamLimiter charges the jokes sent on event streams after the
+	// first; nil leaves streams unlimited
+	streamLimiter *middleware.RateLimiter
+
+	// streamsClosed is closed on shutdown to end open event streams
+	streamsClosed chan struct{}
+	closeStreams  sync.Once
 }
 
-// New creates a new Handler
-func New(jokeService *service.JokeService, logger *slog.Logger, dbPool *pgxpool.Pool) *Handler {
+// New creates a new Handler. Jokes sent on event streams after the first
+// are charged to streamLimiter, which may be nil.
+func New(jokeService *service.JokeService, logger *slog.Logger, dbPool *pgxpool.Pool, usage UsageReporter, metrics JokeMetrics, audit *service.AuditService, checks *health.Health, renderers *render.Registry, streamLimiter *middleware.RateLimiter) *Handler {
 	return &Handler{
 		jokeService: jokeService,
 		logger:      logger,
 		dbPool:      dbPool,
+		usage:       usage,
+		metrics:     metrics,
+		audit:       audit,
+		health:      checks,
+		renderers:   renderers,
+
+		streamLimiter: streamLimiter,
+		streamsClosed: make(chan struct{}),
 	}
 }
+
+// CloseStreams ends open event streams so a graceful shutdown is not held
+// up by them. Clients reconnect and resume elsewhere.
+func (h *Handler) CloseStreams() {
+	h.closeStreams.Do(func() { close(h.streamsClosed) })
+}
+
+// log returns the request's logger, which carries its request ID
+func (h *Handler) log(ctx context.Context) *slog.Logger {
+	return logging.FromContext(ctx, h.logger)
+}
@@ -16,7 +16,7 @@ func (h *Handler) HandleHealth(w http.ResponseWriter, r *http.Request) {
 	// Check database connectivity
 	dbStatus := "connected"
 	if err := h.dbPool.Ping(ctx); err != nil {
-		h.logger.Error("database health check failed", "error", err)
+		h.log(ctx).ErrorContext(ctx, "database health check failed", "error", err)
 		dbStatus = "disconnected"
 	}
 
@@ -34,5 +34,5 @@ func (h *Handler) HandleHealth(w http.ResponseWriter, r *http.Request) {
 		Timestamp: time.Now().UTC().Format(time.RFC3339),
 	}
 
-	h.writeJSON(w, httpStatus, response)
+	h.writeJSON(w, r, httpStatus, response)
 }
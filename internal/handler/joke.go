@@ -1,117 +1,329 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 
+	"github.com/cdunlap/djaas/internal/language"
+	"github.com/cdunlap/djaas/internal/middleware"
 	"github.com/cdunlap/djaas/internal/model"
+	"github.com/cdunlap/djaas/internal/render"
 	"github.com/cdunlap/djaas/internal/service"
+	"github.com/go-chi/chi/v5"
 )
 
 // HandleGetJoke handles GET /api/v1/joke requests
 // @Summary Get a random joke
-// @Description Retrieve a random joke with optional filtering by search query, category, and tags
+// @Description Retrieve a random joke with optional filtering by search query, category, and tags.
+// @Description Jokes in the caller's preferred language are served first, then the configured fallback languages.
 // @Tags Jokes
 // @Accept json
 // @Produce json
+// @Produce plain
+// @Produce html
+// @Produce text/markdown
+// @Produce application/ssml+xml
 // @Param search query string false "Search query to filter jokes"
 // @Param category query string false "Category filter (e.g., 'general', 'food', 'science')"
 // @Param tags query string false "Comma-separated list of tags (e.g., 'wordplay,puns')"
+// @Param max_rating query string false "Maximum content rating (g, pg, pg13, r); capped by the caller's ceiling"
+// @Param lang query string false "Preferred language code (e.g., 'en', 'es', 'de'); overrides Accept-Language"
+// @Param Accept-Language header string false "Preferred languages, negotiated when lang is not set"
+// @Param type query string false "Joke type (setup_punchline, one_liner, knock_knock, multi_part)"
+// @Param source query string false "Source name filter (e.g., 'reddit')"
+// @Param format query string false "Response format (json, text, html, markdown, ssml); overrides Accept"
 // @Success 200 {object} model.Joke
+// @Header 200 {string} Content-Language "Language of the returned joke"
+// @Failure 400 {object} model.ErrorResponse "Invalid max_rating, lang, type or format"
 // @Failure 404 {object} model.ErrorResponse "No jokes found"
+// @Failure 406 {object} model.ErrorResponse "No acceptable format"
 // @Failure 500 {object} model.ErrorResponse "Internal server error"
 // @Router /joke [get]
 func (h *Handler) HandleGetJoke(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	// Parse query parameters
-	searchQuery := r.URL.Query().Get("search")
-	category := r.URL.Query().Get("category")
-	tagsParam := r.URL.Query().Get("tags")
-
-	// Parse tags (comma-separated)
-	var tags []string
-	if tagsParam != "" {
-		rawTags := strings.Split(tagsParam, ",")
-		for _, tag := range rawTags {
-			trimmed := strings.TrimSpace(tag)
-			if trimmed != "" {
-				tags = append(tags, trimmed)
+	query := parseJokeQuery(r)
+
+	filter, ok := h.parseJokeFilter(w, r)
+	if !ok {
+		return
+	}
+
+	renderer, ok := h.negotiate(w, r)
+	if !ok {
+		return
+	}
+
+	joke, err := h.jokeService.FindJoke(ctx, query, filter)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.metrics.JokeServed(joke.Category)
+	w.Header().Set("Content-Language", joke.Language)
+	w.Header().Add("Vary", "Accept-Language")
+	h.writeJokes(w, r, renderer, joke, *joke)
+}
+
+// parseJokeQuery reads the search, category and comma-separated tags
+// query parameters
+func parseJokeQuery(r *http.Request) service.JokeQuery {
+	query := service.JokeQuery{
+		Search:   r.URL.Query().Get("search"),
+		Category: r.URL.Query().Get("category"),
+	}
+
+	if tagsParam := r.URL.Query().Get("tags"); tagsParam != "" {
+		for _, tag := range strings.Split(tagsParam, ",") {
+			if trimmed := strings.TrimSpace(tag); trimmed != "" {
+				query.Tags = append(query.Tags, trimmed)
 			}
 		}
 	}
 
-	var joke *model.Joke
-	var err error
+	return query
+}
 
-	// Route to appropriate service method based on query param combinations
-	switch {
-	case len(tags) > 0 && category != "" && searchQuery != "":
-		// All three filters
-		joke, err = h.jokeService.GetJokeByAllFilters(ctx, tags, category, searchQuery)
-	case len(tags) > 0 && category != "":
-		// Tags + category
-		joke, err = h.jokeService.GetJokeByTagsAndCategory(ctx, tags, category)
-	case len(tags) > 0 && searchQuery != "":
-		// Tags + search
-		joke, err = h.jokeService.GetJokeByTagsAndSearch(ctx, tags, searchQuery)
-	case len(tags) > 0:
-		// Tags only
-		joke, err = h.jokeService.GetJokeByTags(ctx, tags)
-	case category != "" && searchQuery != "":
-		// Category + search (existing)
-		joke, err = h.jokeService.GetJokeByCategoryAndSearch(ctx, category, searchQuery)
-	case category != "":
-		// Category only (existing)
-		joke, err = h.jokeService.GetJokeByCategory(ctx, category)
-	case searchQuery != "":
-		// Search only (existing)
-		joke, err = h.jokeService.SearchJokes(ctx, searchQuery)
-	default:
-		// Random (existing)
-		joke, err = h.jokeService.GetRandomJoke(ctx)
+const (
+	defaultSimilarLimit = 5
+	maxSimilarLimit     = 20
+)
+
+// HandleGetSimilarJokes handles GET /api/v1/jokes/{id}/similar requests
+// @Summary Get similar jokes
+// @Description Retrieve jokes related to the given joke, ranked by shared tags, same category and text similarity
+// @Tags Jokes
+// @Accept json
+// @Produce json
+// @Produce plain
+// @Produce html
+// @Produce text/markdown
+// @Produce application/ssml+xml
+// @Param id path int true "Joke ID"
+// @Param limit query int false "Maximum number of jokes to return (1-20, default 5)"
+// @Param max_rating query string false "Maximum content rating (g, pg, pg13, r); capped by the caller's ceiling"
+// @Param format query string false "Response format (json, text, html, markdown, ssml); overrides Accept"
+// @Success 200 {object} model.SimilarJokesResponse
+// @Failure 400 {object} model.ErrorResponse "Invalid joke ID, limit, max_rating or format"
+// @Failure 404 {object} model.ErrorResponse "Joke not found"
+// @Failure 406 {object} model.ErrorResponse "No acceptable format"
+// @Failure 500 {object} model.ErrorResponse "Internal server error"
+// @Router /jokes/{id}/similar [get]
+func (h *Handler) HandleGetSimilarJokes(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil || id <= 0 {
+		h.writeErrorJSON(w, r, http.StatusBadRequest, "invalid_id", "Joke ID must be a positive integer")
+		return
+	}
+
+	limit := int64(defaultSimilarLimit)
+	if limitParam := r.URL.Query().Get("limit"); limitParam != "" {
+		limit, err = strconv.ParseInt(limitParam, 10, 32)
+		if err != nil || limit < 1 || limit > maxSimilarLimit {
+			h.writeErrorJSON(w, r, http.StatusBadRequest, "invalid_limit", "Limit must be between 1 and 20")
+			return
+		}
+	}
+
+	filter, ok := h.parseJokeFilter(w, r)
+	if !ok {
+		return
+	}
+
+	renderer, ok := h.negotiate(w, r)
+	if !ok {
+		return
+	}
+
+	jokes, err := h.jokeService.GetSimilarJokes(ctx, int32(id), int32(limit), filter)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	plain := make([]model.Joke, 0, len(jokes))
+	for _, joke := range jokes {
+		h.metrics.JokeServed(joke.Category)
+		plain = append(plain, joke.Joke)
+	}
+
+	h.writeJokes(w, r, renderer, model.SimilarJokesResponse{
+		JokeID: int32(id),
+		Jokes:  jokes,
+	}, plain...)
+}
+
+// HandleGetJokeTranslations handles GET /api/v1/jokes/{id}/translations requests
+// @Summary Get joke translations
+// @Description Retrieve the equivalent jokes in other languages
+// @Tags Jokes
+// @Accept json
+// @Produce json
+// @Produce plain
+// @Produce html
+// @Produce text/markdown
+// @Produce application/ssml+xml
+// @Param id path int true "Joke ID"
+// @Param max_rating query string false "Maximum content rating (g, pg, pg13, r); capped by the caller's ceiling"
+// @Param format query string false "Response format (json, text, html, markdown, ssml); overrides Accept"
+// @Success 200 {object} model.TranslationsResponse
+// @Failure 400 {object} model.ErrorResponse "Invalid joke ID, max_rating or format"
+// @Failure 404 {object} model.ErrorResponse "Joke not found"
+// @Failure 406 {object} model.ErrorResponse "No acceptable format"
+// @Failure 500 {object} model.ErrorResponse "Internal server error"
+// @Router /jokes/{id}/translations [get]
+func (h *Handler) HandleGetJokeTranslations(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil || id <= 0 {
+		h.writeErrorJSON(w, r, http.StatusBadRequest, "invalid_id", "Joke ID must be a positive integer")
+		return
+	}
+
+	filter, ok := h.parseJokeFilter(w, r)
+	if !ok {
+		return
+	}
+
+	renderer, ok := h.negotiate(w, r)
+	if !ok {
+		return
 	}
 
+	translations, err := h.jokeService.GetJokeTranslations(ctx, int32(id), filter)
 	if err != nil {
-		h.handleError(w, err)
+		h.handleError(w, r, err)
 		return
 	}
 
-	h.writeJSON(w, http.StatusOK, joke)
+	h.writeJokes(w, r, renderer, model.TranslationsResponse{
+		JokeID:       int32(id),
+		Translations: translations,
+	}, translations...)
+}
+
+// parseJokeFilter builds the retrieval constraints for a request, writing a
+// 400 response and returning false if a parameter is invalid. The max_rating
+// query parameter may lower, but never raise, the caller's ceiling. The lang
+// query parameter takes precedence over the Accept-Language header.
+// The type and source query parameters restrict results to one joke format
+// or source.
+func (h *Handler) parseJokeFilter(w http.ResponseWriter, r *http.Request) (service.JokeFilter, bool) {
+	ceiling := middleware.RatingCeilingFromContext(r.Context())
+	filter := service.JokeFilter{MaxRating: ceiling}
+
+	if maxRatingParam := r.URL.Query().Get("max_rating"); maxRatingParam != "" {
+		maxRating, err := model.ParseContentRating(maxRatingParam)
+		if err != nil {
+			h.writeErrorJSON(w, r, http.StatusBadRequest, "invalid_rating", "max_rating must be one of g, pg, pg13, r")
+			return filter, false
+		}
+		if !maxRating.Exceeds(ceiling) {
+			filter.MaxRating = maxRating
+		}
+	}
+
+	languages, err := language.Preferences(r.URL.Query().Get("lang"), r.Header.Get("Accept-Language"))
+	if err != nil {
+		h.writeErrorJSON(w, r, http.StatusBadRequest, "invalid_language", "lang must be a language code such as en, es or de")
+		return filter, false
+	}
+	filter.Languages = languages
+
+	if typeParam := r.URL.Query().Get("type"); typeParam != "" {
+		jokeType, err := model.ParseJokeType(typeParam)
+		if err != nil {
+			h.writeErrorJSON(w, r, http.StatusBadRequest, "invalid_type", "type must be one of setup_punchline, one_liner, knock_knock, multi_part")
+			return filter, false
+		}
+		filter.Type = jokeType
+	}
+
+	filter.Source = r.URL.Query().Get("source")
+
+	return filter, true
 }
 
 // handleError handles service errors and sends appropriate HTTP responses
-func (h *Handler) handleError(w http.ResponseWriter, err error) {
+func (h *Handler) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	status, errorResponse := h.errorResponse(r.Context(), err)
+	errorResponse.RequestID = middleware.RequestIDFromContext(r.Context())
+	h.writeJSON(w, r, status, errorResponse)
+}
+
+// errorResponse maps a service error to an HTTP status and error body
+func (h *Handler) errorResponse(ctx context.Context, err error) (int, model.ErrorResponse) {
 	switch {
 	case errors.Is(err, service.ErrNoJokesFound):
-		h.writeErrorJSON(w, http.StatusNotFound, "not_found", "No jokes found matching your criteria")
+		h.metrics.NoJokesFound()
+		return http.StatusNotFound, model.ErrorResponse{Error: "not_found", Message: "No jokes found matching your criteria"}
+	case errors.Is(err, service.ErrJokeNotFound):
+		return http.StatusNotFound, model.ErrorResponse{Error: "not_found", Message: "Joke not found"}
+	case errors.Is(err, service.ErrInvalidTranslation):
+		return http.StatusBadRequest, model.ErrorResponse{Error: "invalid_translation", Message: "translation_of must reference an existing joke with no translation in this language"}
 	case errors.Is(err, service.ErrInvalidInput):
-		h.writeErrorJSON(w, http.StatusBadRequest, "invalid_input", "Invalid search query, category, or tags")
+		return http.StatusBadRequest, model.ErrorResponse{Error: "invalid_input", Message: "Invalid search query, category, or tags"}
 	default:
-		h.logger.Error("internal server error", "error", err)
-		h.writeErrorJSON(w, http.StatusInternalServerError, "internal_error", "An internal error occurred")
+		h.log(ctx).ErrorContext(ctx, "internal server error", "error", err)
+		return http.StatusInternalServerError, model.ErrorResponse{Error: "internal_error", Message: "An internal error occurred"}
 	}
 }
 
 // writeJSON writes a JSON response
-func (h *Handler) writeJSON(w http.ResponseWriter, status int, data interface{}) {
+func (h *Handler) writeJSON(w http.ResponseWriter, r *http.Request, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 
 	if err := json.NewEncoder(w).Encode(data); err != nil {
-		h.logger.Error("failed to encode JSON response", "error", err)
+		h.log(r.Context()).ErrorContext(r.Context(), "failed to encode JSON response", "error", err)
 	}
 }
 
 // writeErrorJSON writes an error JSON response
-func (h *Handler) writeErrorJSON(w http.ResponseWriter, status int, error string, message string) {
+func (h *Handler) writeErrorJSON(w http.ResponseWriter, r *http.Request, status int, error string, message string) {
 	errorResponse := model.ErrorResponse{
-		Error:   error,
-		Message: message,
+		Error:     error,
+		Message:   message,
+		RequestID: middleware.RequestIDFromContext(r.Context()),
+	}
+	h.writeJSON(w, r, status, errorResponse)
+}
+
+// negotiate picks the format for a joke response from the format query
+// parameter or the Accept header, writing an error response and returning
+// false if none fits. Errors are always written as JSON.
+func (h *Handler) negotiate(w http.ResponseWriter, r *http.Request) (render.Renderer, bool) {
+	w.Header().Add("Vary", "Accept")
+
+	renderer, err := h.renderers.Negotiate(r.URL.Query().Get("format"), r.Header.Get("Accept"))
+	switch {
+	case errors.Is(err, render.ErrUnknownFormat):
+		h.writeErrorJSON(w, r, http.StatusBadRequest, "invalid_format", "format must be one of "+strings.Join(h.renderers.Formats(), ", "))
+		return nil, false
+	case err != nil:
+		h.writeErrorJSON(w, r, http.StatusNotAcceptable, "not_acceptable", "Jokes are available as "+strings.Join(h.renderers.Formats(), ", ")+"; use the format query parameter or a matching Accept header")
+		return nil, false
+	}
+	return renderer, true
+}
+
+// writeJokes writes a successful joke response with the negotiated renderer.
+// body is the JSON document; other formats show only the jokes.
+func (h *Handler) writeJokes(w http.ResponseWriter, r *http.Request, renderer render.Renderer, body any, jokes ...model.Joke) {
+	w.Header().Set("Content-Type", renderer.ContentType())
+	w.WriteHeader(http.StatusOK)
+
+	if err := renderer.Render(w, render.Response{Body: body, Jokes: jokes}); err != nil {
+		h.log(r.Context()).ErrorContext(r.Context(), "failed to render response", "content_type", renderer.ContentType(), "error", err)
 	}
-	h.writeJSON(w, status, errorResponse)
 }
 
 // HandleGetTags returns all available tags
@@ -128,27 +340,122 @@ func (h *Handler) HandleGetTags(w http.ResponseWriter, r *http.Request) {
 
 	tags, err := h.jokeService.GetAllTags(ctx)
 	if err != nil {
-		h.logger.Error("failed to get tags", "error", err)
-		h.writeErrorJSON(w, http.StatusInternalServerError, "internal_error", "Failed to retrieve tags")
+		h.log(ctx).ErrorContext(ctx, "failed to get tags", "error", err)
+		h.writeErrorJSON(w, r, http.StatusInternalServerError, "internal_error", "Failed to retrieve tags")
 		return
 	}
 
-	h.writeJSON(w, http.StatusOK, map[string][]string{
+	h.writeJSON(w, r, http.StatusOK, map[string][]string{
 		"tags": tags,
 	})
 }
 
 // CreateJokeRequest represents the request body for creating a joke
 type CreateJokeRequest struct {
-	Setup     string   `json:"setup"`
-	Punchline string   `json:"punchline"`
-	Category  *string  `json:"category,omitempty"`
-	Tags      []string `json:"tags,omitempty"`
+	Setup           string           `json:"setup"`
+	Punchline       string           `json:"punchline"`
+	Type            string           `json:"type,omitempty"`
+	Parts           []model.JokePart `json:"parts,omitempty"`
+	Category        *string          `json:"category,omitempty"`
+	Tags            []string         `json:"tags,omitempty"`
+	Rating          string           `json:"rating,omitempty"`
+	ContentWarnings []string         `json:"content_warnings,omitempty"`
+	Language        string           `json:"language,omitempty"`
+	TranslationOf   *int32           `json:"translation_of,omitempty"`
+	Author          *string          `json:"author,omitempty"`
+	SourceName      *string          `json:"source_name,omitempty"`
+	SourceURL       *string          `json:"source_url,omitempty"`
+	License         *string          `json:"license,omitempty"`
+}
+
+// toNewJoke validates the request and converts it for the service layer.
+// It returns an error response describing the first invalid field.
+func (req CreateJokeRequest) toNewJoke() (service.NewJoke, *model.ErrorResponse) {
+	invalid := func(code, message string) (service.NewJoke, *model.ErrorResponse) {
+		return service.NewJoke{}, &model.ErrorResponse{Error: code, Message: message}
+	}
+
+	jokeType := model.TypeSetupPunchline
+	if req.Type != "" {
+		parsed, err := model.ParseJokeType(req.Type)
+		if err != nil {
+			return invalid("invalid_type", "Type must be one of setup_punchline, one_liner, knock_knock, multi_part")
+		}
+		jokeType = parsed
+	}
+
+	for _, part := range req.Parts {
+		if strings.TrimSpace(part.Text) == "" {
+			return invalid("missing_fields", "Every part needs text")
+		}
+	}
+
+	// Validate required fields for the joke type
+	switch jokeType {
+	case model.TypeSetupPunchline:
+		if (req.Setup == "" || req.Punchline == "") && len(req.Parts) != 2 {
+			return invalid("missing_fields", "Setup and punchline are required")
+		}
+	case model.TypeOneLiner:
+		if req.Setup == "" && len(req.Parts) != 1 {
+			return invalid("missing_fields", "One-liners need a setup or exactly one part")
+		}
+	case model.TypeKnockKnock:
+		if len(req.Parts) < 3 {
+			return invalid("missing_fields", "Knock-knock jokes need at least 3 parts")
+		}
+	case model.TypeMultiPart:
+		if len(req.Parts) < 2 {
+			return invalid("missing_fields", "Multi-part jokes need at least 2 parts")
+		}
+	}
+
+	rating := model.RatingG
+	if req.Rating != "" {
+		parsed, err := model.ParseContentRating(req.Rating)
+		if err != nil {
+			return invalid("invalid_rating", "Rating must be one of g, pg, pg13, r")
+		}
+		rating = parsed
+	}
+
+	if req.Language != "" {
+		if _, err := language.Normalize(req.Language); err != nil {
+			return invalid("invalid_language", "Language must be a language code such as en, es or de")
+		}
+	}
+
+	if req.SourceURL != nil {
+		if u, err := url.Parse(*req.SourceURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return invalid("invalid_source_url", "Source URL must be an absolute http or https URL")
+		}
+	}
+
+	return service.NewJoke{
+		Setup:           req.Setup,
+		Punchline:       req.Punchline,
+		Type:            jokeType,
+		Parts:           req.Parts,
+		Category:        req.Category,
+		Tags:            req.Tags,
+		Rating:          rating,
+		ContentWarnings: req.ContentWarnings,
+		Language:        req.Language,
+		TranslationOf:   req.TranslationOf,
+		Author:          req.Author,
+		SourceName:      req.SourceName,
+		SourceURL:       req.SourceURL,
+		License:         req.License,
+	}, nil
 }
 
 // HandleCreateJoke handles POST /api/v1/joke requests
 // @Summary Create a new joke
-// @Description Add a new joke to the database with optional category and tags
+// @Description Add a new joke to the database with optional category, tags, content rating and content warnings.
+// @Description Set language and translation_of to add a translation of an existing joke.
+// @Description One-liners, knock-knock and multi-part jokes are submitted as ordered parts; setup and punchline are derived from them.
+// @Description Author, source and license fields record where the joke came from.
+// @Description Submissions containing terms above the declared rating are flagged and re-rated.
 // @Tags Jokes
 // @Accept json
 // @Produce json
@@ -156,28 +463,32 @@ type CreateJokeRequest struct {
 // @Success 201 {object} model.Joke "Created joke"
 // @Failure 400 {object} model.ErrorResponse "Invalid request"
 // @Failure 500 {object} model.ErrorResponse "Internal server error"
+// @Failure 401 {object} model.ErrorResponse "Missing or invalid credentials"
+// @Failure 403 {object} model.ErrorResponse "Missing the jokes:write scope"
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /joke [post]
 func (h *Handler) HandleCreateJoke(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	var req CreateJokeRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeErrorJSON(w, http.StatusBadRequest, "invalid_json", "Invalid JSON request body")
+		h.writeErrorJSON(w, r, http.StatusBadRequest, "invalid_json", "Invalid JSON request body")
 		return
 	}
 
-	// Validate required fields
-	if req.Setup == "" || req.Punchline == "" {
-		h.writeErrorJSON(w, http.StatusBadRequest, "missing_fields", "Setup and punchline are required")
+	newJoke, errResp := req.toNewJoke()
+	if errResp != nil {
+		h.writeErrorJSON(w, r, http.StatusBadRequest, errResp.Error, errResp.Message)
 		return
 	}
 
 	// Create the joke
-	joke, err := h.jokeService.CreateJoke(ctx, req.Setup, req.Punchline, req.Category, req.Tags)
+	joke, err := h.jokeService.CreateJoke(ctx, newJoke)
 	if err != nil {
-		h.handleError(w, err)
+		h.handleError(w, r, err)
 		return
 	}
 
-	h.writeJSON(w, http.StatusCreated, joke)
+	h.writeJSON(w, r, http.StatusCreated, joke)
 }
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/cdunlap/djaas/internal/health"
+	"github.com/cdunlap/djaas/internal/model"
+	"github.com/cdunlap/djaas/internal/version"
+)
+
+// HandleLivez handles GET /livez requests. It succeeds whenever the process
+// is up and serving HTTP; dependencies are not checked.
+func (h *Handler) HandleLivez(w http.ResponseWriter, r *http.Request) {
+	h.writeJSON(w, r, http.StatusOK, model.StatusResponse{Status: "ok"})
+}
+
+// HandleReadyz handles GET /readyz requests. It succeeds when every
+// required check passes and the service is not draining, and returns 503
+// otherwise. Check errors are left out as they can reveal internal addresses.
+func (h *Handler) HandleReadyz(w http.ResponseWriter, r *http.Request) {
+	report := h.health.Check(r.Context())
+
+	status := http.StatusOK
+	if !report.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	h.writeJSON(w, r, status, model.ReadinessResponse{
+		Status: readinessStatus(report),
+		Checks: checkResults(report, false),
+	})
+}
+
+// HandleHealthDetails handles GET /health/details requests, for callers
+// with the admin role: the readiness checks with their latency and errors,
+// database pool statistics, uptime and the running build
+func (h *Handler) HandleHealthDetails(w http.ResponseWriter, r *http.Request) {
+	report := h.health.Check(r.Context())
+	stat := h.dbPool.Stat()
+	build := version.Get()
+
+	status := http.StatusOK
+	if !report.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	h.writeJSON(w, r, status, model.HealthDetailsResponse{
+		Status:        readinessStatus(report),
+		UptimeSeconds: int64(h.health.Uptime().Seconds()),
+		Version:       build.Version,
+		Commit:        build.Commit,
+		Checks:        checkResults(report, true),
+		DBPool: model.DBPoolStats{
+			AcquiredConns:     stat.AcquiredConns(),
+			IdleConns:         stat.IdleConns(),
+			TotalConns:        stat.TotalConns(),
+			MaxConns:          stat.MaxConns(),
+			AcquireCount:      stat.AcquireCount(),
+			EmptyAcquireCount: stat.EmptyAcquireCount(),
+			AcquireWaitMS:     milliseconds(stat.EmptyAcquireWaitTime()),
+		},
+	})
+}
+
+// HandleVersion handles GET /version requests with the running build's
+// version, commit and build time
+func (h *Handler) HandleVersion(w http.ResponseWriter, r *http.Request) {
+	build := version.Get()
+	h.writeJSON(w, r, http.StatusOK, model.VersionResponse{
+		Version:   build.Version,
+		Commit:    build.Commit,
+		BuildTime: build.BuildTime,
+		Modified:  build.Modified,
+		GoVersion: build.GoVersion,
+	})
+}
+
+// readinessStatus summarizes a report for the status field
+func readinessStatus(report health.Report) string {
+	switch {
+	case report.Draining:
+		return "draining"
+	case !report.Ready:
+		return "not_ready"
+	default:
+		return "ready"
+	}
+}
+
+// checkResults converts a report's results, with the errors only when
+// withErrors is set
+func checkResults(report health.Report, withErrors bool) []model.CheckResult {
+	results := make([]model.CheckResult, 0, len(report.Results))
+	for _, result := range report.Results {
+		check := model.CheckResult{
+			Name:      result.Name,
+			Status:    "ok",
+			LatencyMS: milliseconds(result.Latency),
+			Optional:  result.Optional,
+		}
+		if !result.OK() {
+			check.Status = "fail"
+			if withErrors {
+				check.Error = result.Err.Error()
+			}
+		}
+		results = append(results, check)
+	}
+	return results
+}
+
+// milliseconds converts d to fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/cdunlap/djaas/internal/model"
+	"github.com/go-chi/chi/v5"
+)
+
+// maxImportJokes caps the number of jokes accepted in a single import
+const maxImportJokes = 500
+
+// ImportJokesRequest represents the request body for a bulk joke import.
+// Attribution fields apply to every joke that does not set its own.
+type ImportJokesRequest struct {
+	Author     *string             `json:"author,omitempty"`
+	SourceName *string             `json:"source_name,omitempty"`
+	SourceURL  *string             `json:"source_url,omitempty"`
+	License    *string             `json:"license,omitempty"`
+	Jokes      []CreateJokeRequest `json:"jokes"`
+}
+
+// HandleImportJokes handles POST /api/v1/jokes/import requests
+// @Summary Import jokes
+// @Description Add a batch of up to 500 jokes, typically from a single source.
+// @Description Top-level author, source and license fields are applied to jokes that do not set their own.
+// @Description Invalid jokes are skipped and reported by index; the rest are still created.
+// @Tags Jokes
+// @Accept json
+// @Produce json
+// @Param import body ImportJokesRequest true "Jokes to import"
+// @Success 200 {object} model.ImportJokesResponse "Created joke IDs and per-joke errors"
+// @Failure 400 {object} model.ErrorResponse "Invalid request"
+// @Failure 401 {object} model.ErrorResponse "Missing or invalid credentials"
+// @Failure 403 {object} model.ErrorResponse "Missing the jokes:write scope"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /jokes/import [post]
+func (h *Handler) HandleImportJokes(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req ImportJokesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorJSON(w, r, http.StatusBadRequest, "invalid_json", "Invalid JSON request body")
+		return
+	}
+
+	if len(req.Jokes) == 0 || len(req.Jokes) > maxImportJokes {
+		h.writeErrorJSON(w, r, http.StatusBadRequest, "invalid_batch", "Imports must contain between 1 and 500 jokes")
+		return
+	}
+
+	resp := model.ImportJokesResponse{
+		Created: []int32{},
+		Errors:  []model.ImportError{},
+	}
+
+	for i, jokeReq := range req.Jokes {
+		jokeReq.Author = withDefault(jokeReq.Author, req.Author)
+		jokeReq.SourceName = withDefault(jokeReq.SourceName, req.SourceName)
+		jokeReq.SourceURL = withDefault(jokeReq.SourceURL, req.SourceURL)
+		jokeReq.License = withDefault(jokeReq.License, req.License)
+
+		newJoke, errResp := jokeReq.toNewJoke()
+		if errResp == nil {
+			joke, err := h.jokeService.CreateJoke(ctx, newJoke)
+			if err == nil {
+				resp.Created = append(resp.Created, joke.ID)
+				continue
+			}
+			_, serviceErr := h.errorResponse(ctx, err)
+			errResp = &serviceErr
+		}
+
+		resp.Errors = append(resp.Errors, model.ImportError{
+			Index:   i,
+			Error:   errResp.Error,
+			Message: errResp.Message,
+		})
+	}
+
+	h.log(ctx).InfoContext(ctx, "jokes imported", "created", len(resp.Created), "failed", len(resp.Errors))
+	h.writeJSON(w, r, http.StatusOK, resp)
+}
+
+// HandleDeleteJokesBySource handles DELETE /api/v1/sources/{source}/jokes requests
+// @Summary Delete jokes by source
+// @Description Remove every joke imported from the given source, e.g. to honour a takedown request
+// @Tags Jokes
+// @Accept json
+// @Produce json
+// @Param source path string true "Source name"
+// @Success 200 {object} model.DeleteSourceResponse "Number of jokes deleted"
+// @Failure 400 {object} model.ErrorResponse "Invalid source"
+// @Failure 500 {object} model.ErrorResponse "Internal server error"
+// @Failure 401 {object} model.ErrorResponse "Missing or invalid credentials"
+// @Failure 403 {object} model.ErrorResponse "Missing the moderate scope"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /sources/{source}/jokes [delete]
+func (h *Handler) HandleDeleteJokesBySource(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	source := strings.TrimSpace(chi.URLParam(r, "source"))
+	if source == "" {
+		h.writeErrorJSON(w, r, http.StatusBadRequest, "invalid_source", "Source name is required")
+		return
+	}
+
+	deleted, err := h.jokeService.DeleteJokesBySource(ctx, source)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.log(ctx).InfoContext(ctx, "jokes deleted by source", "source", source, "deleted", deleted)
+	h.writeJSON(w, r, http.StatusOK, model.DeleteSourceResponse{
+		Source:  source,
+		Deleted: deleted,
+	})
+}
+
+// withDefault returns value, or fallback when value is unset
+func withDefault(value, fallback *string) *string {
+	if value != nil {
+		return value
+	}
+	return fallback
+}
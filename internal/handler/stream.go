@@ -0,0 +1,253 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cdunlap/djaas/internal/middleware"
+	"github.com/cdunlap/djaas/internal/model"
+	"github.com/cdunlap/djaas/internal/service"
+)
+
+const (
+	defaultStreamInterval = 30 * time.Second
+	minStreamInterval     = 5 * time.Second
+	maxStreamInterval     = time.Hour
+
+	// streamHeartbeat is how often a comment is sent between jokes, so proxies
+	// do not close an idle stream
+	streamHeartbeat = 15 * time.Second
+	// streamWriteTimeout bounds each write, replacing the server's write
+	// timeout, which would otherwise end every stream after 15 seconds
+	streamWriteTimeout = 10 * time.Second
+	// streamRetry is the reconnect delay suggested to clients
+	streamRetry = 5 * time.Second
+)
+
+// HandleJokeStream handles GET /api/v1/jokes/stream requests
+// @Summary Stream jokes
+// @Description Server-Sent Events stream that pushes a random joke every interval, with the same filters as GET /joke.
+// @Description Each joke is a "joke" event whose data is the joke as JSON. Heartbeat comments are sent every 15 seconds.
+// @Description Reconnecting with Last-Event-ID resumes the schedule without repeating the last joke.
+// @Description Each joke counts against the caller's rate limit and daily quota; a rejected joke ends the stream with an error event.
+// @Tags Jokes
+// @Produce text/event-stream
+// @Param interval query string false "Time between jokes, as seconds or a duration such as 1m (5s-1h, default 30s)"
+// @Param search query string false "Search query to filter jokes"
+// @Param category query string false "Category filter (e.g., 'general', 'food', 'science')"
+// @Param tags query string false "Comma-separated list of tags (e.g., 'wordplay,puns')"
+// @Param max_rating query string false "Maximum content rating (g, pg, pg13, r); capped by the caller's ceiling"
+// @Param lang query string false "Preferred language code (e.g., 'en', 'es', 'de'); overrides Accept-Language"
+// @Param type query string false "Joke type (setup_punchline, one_liner, knock_knock, multi_part)"
+// @Param source query string false "Source name filter (e.g., 'reddit')"
+// @Param Last-Event-ID header string false "ID of the last event received, to resume a stream"
+// @Success 200 {object} model.Joke "Stream of joke events"
+// @Failure 400 {object} model.ErrorResponse "Invalid interval, max_rating, lang or type"
+// @Failure 404 {object} model.ErrorResponse "No jokes found"
+// @Failure 500 {object} model.ErrorResponse "Internal server error"
+// @Router /jokes/stream [get]
+func (h *Handler) HandleJokeStream(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	interval, err := parseStreamInterval(r.URL.Query().Get("interval"))
+	if err != nil {
+		h.writeErrorJSON(w, r, http.StatusBadRequest, "invalid_interval", "interval must be between 5s and 1h, as seconds or a duration such as 1m")
+		return
+	}
+
+	query := parseJokeQuery(r)
+	filter, ok := h.parseJokeFilter(w, r)
+	if !ok {
+		return
+	}
+
+	// A resumed stream keeps its schedule and does not repeat the last joke
+	now := time.Now()
+	next := now
+	lastJokeID, lastSent, resumed := parseStreamEventID(r.Header.Get("Last-Event-ID"))
+	if resumed {
+		if due := lastSent.Add(interval); due.After(now) && !due.After(now.Add(interval)) {
+			next = due
+		}
+	}
+
+	// Find the first joke before committing to a stream, so a filter that
+	// matches nothing gets a 404
+	joke, err := h.nextStreamJoke(ctx, query, filter, lastJokeID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	send := func(event string) bool {
+		if err := rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout)); err != nil {
+			h.log(ctx).ErrorContext(ctx, "event stream unsupported", "error", err)
+			return false
+		}
+		if _, err := fmt.Fprint(w, event); err != nil {
+			return false
+		}
+		return rc.Flush() == nil
+	}
+
+	if !send(fmt.Sprintf("retry: %d\n\n", streamRetry.Milliseconds())) {
+		return
+	}
+
+	timer := time.NewTimer(time.Until(next))
+	defer timer.Stop()
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-h.streamsClosed:
+			return
+		case <-heartbeat.C:
+			if !send(": heartbeat\n\n") {
+				return
+			}
+		case <-timer.C:
+			if joke == nil {
+				if event, ok := h.chargeStreamJoke(ctx, query); !ok {
+					send(event)
+					return
+				}
+				joke, err = h.nextStreamJoke(ctx, query, filter, lastJokeID)
+			}
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				// Keep the stream open; the next attempt may succeed
+				_, errorResponse := h.errorResponse(ctx, err)
+				errorResponse.RequestID = middleware.RequestIDFromContext(ctx)
+				if !send(streamEvent("error", "", errorResponse)) {
+					return
+				}
+			} else {
+				sent := time.Now()
+				h.metrics.JokeServed(joke.Category)
+				if !send(streamEvent("joke", streamEventID(joke.ID, sent), joke)) {
+					return
+				}
+				lastJokeID = joke.ID
+			}
+			joke, err = nil, nil
+			timer.Reset(interval)
+		}
+	}
+}
+
+// chargeStreamJoke charges a joke after a stream's first to the caller's
+// rate limit and daily quota, as a request to GET /joke with the same
+// query would be. It returns the events ending the stream when the joke is
+// rejected, suggesting the delay before it may reconnect.
+func (h *Handler) chargeStreamJoke(ctx context.Context, query service.JokeQuery) (string, bool) {
+	if h.streamLimiter == nil {
+		return "", true
+	}
+
+	policy := "read"
+	if query.Search != "" {
+		policy = "search"
+	}
+	v := h.streamLimiter.Check(ctx, h.log(ctx), middleware.PrincipalFromContext(ctx), middleware.ClientIPFromContext(ctx), policy)
+	if v.Allowed {
+		return "", true
+	}
+
+	event := streamEvent("error", "", model.ErrorResponse{
+		Error:     v.Reason,
+		Message:   v.Message,
+		RequestID: middleware.RequestIDFromContext(ctx),
+	})
+	if v.RetryAfter > 0 {
+		event = fmt.Sprintf("retry: %d\n\n", v.RetryAfter.Milliseconds()) + event
+	}
+	return event, false
+}
+
+// nextStreamJoke finds a random joke for a stream, trying once more if it
+// repeats the previous joke
+func (h *Handler) nextStreamJoke(ctx context.Context, query service.JokeQuery, filter service.JokeFilter, previousID int32) (*model.Joke, error) {
+	joke, err := h.jokeService.FindJoke(ctx, query, filter)
+	if err == nil && joke.ID == previousID {
+		joke, err = h.jokeService.FindJoke(ctx, query, filter)
+	}
+	return joke, err
+}
+
+// parseStreamInterval parses a stream interval given as seconds or a
+// duration such as "1m", defaulting to 30 seconds
+func parseStreamInterval(s string) (time.Duration, error) {
+	if s == "" {
+		return defaultStreamInterval, nil
+	}
+
+	interval, err := time.ParseDuration(s)
+	if err != nil {
+		seconds, convErr := strconv.Atoi(s)
+		if convErr != nil {
+			return 0, err
+		}
+		interval = time.Duration(seconds) * time.Second
+	}
+	if interval < minStreamInterval || interval > maxStreamInterval {
+		return 0, fmt.Errorf("interval %s out of range", interval)
+	}
+	return interval, nil
+}
+
+// streamEventID identifies a joke event by when it was sent and which joke
+// it carried, e.g. "1767225600000-42"
+func streamEventID(jokeID int32, sent time.Time) string {
+	return fmt.Sprintf("%d-%d", sent.UnixMilli(), jokeID)
+}
+
+// parseStreamEventID parses an ID from streamEventID, reporting false for
+// anything else
+func parseStreamEventID(id string) (jokeID int32, sent time.Time, ok bool) {
+	millisPart, jokePart, found := strings.Cut(id, "-")
+	if !found {
+		return 0, time.Time{}, false
+	}
+	millis, err := strconv.ParseInt(millisPart, 10, 64)
+	if err != nil || millis <= 0 {
+		return 0, time.Time{}, false
+	}
+	parsedID, err := strconv.ParseInt(jokePart, 10, 32)
+	if err != nil || parsedID <= 0 {
+		return 0, time.Time{}, false
+	}
+	return int32(parsedID), time.UnixMilli(millis), true
+}
+
+// streamEvent formats a Server-Sent Event with JSON data
+func streamEvent(event, id string, data any) string {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		encoded = []byte(`{"error":"internal_error"}`)
+	}
+
+	var b strings.Builder
+	if id != "" {
+		b.WriteString("id: " + id + "\n")
+	}
+	b.WriteString("event: " + event + "\n")
+	b.WriteString("data: " + string(encoded) + "\n\n")
+	return b.String()
+}
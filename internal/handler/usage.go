@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/cdunlap/djaas/internal/middleware"
+)
+
+// HandleGetUsage handles GET /api/v1/usage requests
+// @Summary Get API usage
+// @Description Show the caller's rate limit tier, burst limit and how much of today's quota is left.
+// @Description Quotas reset at midnight UTC.
+// @Tags Account
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.Usage
+// @Failure 401 {object} model.ErrorResponse "Missing or invalid credentials"
+// @Failure 503 {object} model.ErrorResponse "Rate limit backend unavailable"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /usage [get]
+func (h *Handler) HandleGetUsage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	principal := middleware.PrincipalFromContext(ctx)
+	if principal == nil {
+		h.writeErrorJSON(w, r, http.StatusUnauthorized, "unauthorized", "Authentication required: send an X-API-Token or Authorization: Bearer header")
+		return
+	}
+
+	usage, err := h.usage.Usage(ctx, principal)
+	if err != nil {
+		h.log(ctx).ErrorContext(ctx, "failed to read usage", "error", err, "principal", principal.Subject)
+		h.writeErrorJSON(w, r, http.StatusServiceUnavailable, "usage_unavailable", "Usage could not be read, please try again later")
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, usage)
+}
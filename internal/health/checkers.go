@@ -0,0 +1,42 @@
+package health
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cdunlap/djaas/internal/database"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+)
+
+// Database checks that the database answers a ping
+func Database(pool *pgxpool.Pool) Checker {
+	return Func("database", func(ctx context.Context) error {
+		return pool.Ping(ctx)
+	})
+}
+
+// Migrations checks that the schema is at the version this build expects
+// and that no migration was left half applied
+func Migrations(pool *pgxpool.Pool, expected uint) Checker {
+	return Func("migrations", func(ctx context.Context) error {
+		version, dirty, err := database.SchemaVersion(ctx, pool)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("migration %d failed and must be fixed by hand", version)
+		}
+		if version != expected {
+			return fmt.Errorf("schema is at version %d, expected %d", version, expected)
+		}
+		return nil
+	})
+}
+
+// Redis checks that the rate limit Redis server answers a ping
+func Redis(client redis.UniversalClient) Checker {
+	return Func("redis", func(ctx context.Context) error {
+		return client.Ping(ctx).Err()
+	})
+}
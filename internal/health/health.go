@@ -0,0 +1,195 @@
+// Package health runs the checks behind the liveness, readiness and
+// detailed health endpoints. Dependencies register a Checker; the service
+// is ready when every check passes and it is not shutting down.
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Checker checks one dependency of the service
+type Checker interface {
+	// Name identifies the dependency in health reports, e.g. "database"
+	Name() string
+	// Check returns an error if the dependency is unusable
+	Check(ctx context.Context) error
+}
+
+// Func creates a Checker named name that calls check
+func Func(name string, check func(ctx context.Context) error) Checker {
+	return funcChecker{name: name, check: check}
+}
+
+type funcChecker struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+func (c funcChecker) Name() string { return c.name }
+
+func (c funcChecker) Check(ctx context.Context) error { return c.check(ctx) }
+
+// Result is the outcome of one check
+type Result struct {
+	Name    string
+	Err     error
+	Latency time.Duration
+	// Optional checks are reported but do not affect readiness
+	Optional bool
+}
+
+// OK reports whether the check passed
+func (r Result) OK() bool {
+	return r.Err == nil
+}
+
+// Report is the outcome of running every registered check
+type Report struct {
+	// Ready is true when every check passed and the service is not draining
+	Ready    bool
+	Draining bool
+	Results  []Result
+}
+
+// resultTTL is how long check results are reused, so that probes sent in
+// quick succession, by any number of callers, do not each query the
+// dependencies
+const resultTTL = time.Second
+
+// Health holds the registered checkers and whether the service is draining
+type Health struct {
+	timeout  time.Duration
+	started  time.Time
+	draining atomic.Bool
+
+	mu       sync.RWMutex
+	checkers []registration
+
+	// checkMu guards the latest results and the run in progress, if any
+	checkMu   sync.Mutex
+	results   []Result
+	checkedAt time.Time
+	running   chan struct{}
+}
+
+type registration struct {
+	checker  Checker
+	optional bool
+}
+
+// New creates a Health whose checks each give up after timeout
+func New(timeout time.Duration) *Health {
+	return &Health{timeout: timeout, started: time.Now()}
+}
+
+// Register adds a checker; readiness requires it to pass
+func (h *Health) Register(checker Checker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checkers = append(h.checkers, registration{checker: checker})
+}
+
+// RegisterOptional adds a checker for a dependency the service can run
+// without, e.g. one it fails open on. It is reported but never fails
+// readiness.
+func (h *Health) RegisterOptional(checker Checker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checkers = append(h.checkers, registration{checker: checker, optional: true})
+}
+
+// SetDraining marks the service as shutting down, so readiness fails and
+// load balancers stop sending new requests
+func (h *Health) SetDraining() {
+	h.draining.Store(true)
+}
+
+// Draining reports whether SetDraining has been called
+func (h *Health) Draining() bool {
+	return h.draining.Load()
+}
+
+// Uptime returns how long the service has been running
+func (h *Health) Uptime() time.Duration {
+	return time.Since(h.started)
+}
+
+// Check reports the results of every registered checker in registration
+// order. Results less than a second old are reused, and concurrent callers
+// share one run of the checkers; draining is always reported as it is now.
+// The Results slice is shared and must not be modified.
+func (h *Health) Check(ctx context.Context) Report {
+	results := h.latest(ctx)
+
+	draining := h.Draining()
+	report := Report{Ready: !draining, Draining: draining, Results: results}
+	for _, result := range results {
+		if !result.OK() && !result.Optional {
+			report.Ready = false
+		}
+	}
+	return report
+}
+
+// latest returns fresh enough results, running the checkers if there are
+// none and no run is in progress, or waiting for the run in progress
+func (h *Health) latest(ctx context.Context) []Result {
+	h.checkMu.Lock()
+	if h.results != nil && time.Since(h.checkedAt) < resultTTL {
+		results := h.results
+		h.checkMu.Unlock()
+		return results
+	}
+	if running := h.running; running != nil {
+		h.checkMu.Unlock()
+		<-running
+		h.checkMu.Lock()
+		defer h.checkMu.Unlock()
+		return h.results
+	}
+	running := make(chan struct{})
+	h.running = running
+	h.checkMu.Unlock()
+
+	// Other callers wait for this run, so it must not end with this one
+	results := h.run(context.WithoutCancel(ctx))
+
+	h.checkMu.Lock()
+	h.results = results
+	h.checkedAt = time.Now()
+	h.running = nil
+	h.checkMu.Unlock()
+	close(running)
+	return results
+}
+
+// run runs every registered checker concurrently and returns the results
+// in registration order
+func (h *Health) run(ctx context.Context) []Result {
+	h.mu.RLock()
+	checkers := h.checkers
+	h.mu.RUnlock()
+
+	results := make([]Result, len(checkers))
+	var wg sync.WaitGroup
+	for i, reg := range checkers {
+		wg.Go(func() {
+			ctx, cancel := context.WithTimeout(ctx, h.timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := reg.checker.Check(ctx)
+			results[i] = Result{
+				Name:     reg.checker.Name(),
+				Err:      err,
+				Latency:  time.Since(start),
+				Optional: reg.optional,
+			}
+		})
+	}
+	wg.Wait()
+	return results
+}
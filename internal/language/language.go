@@ -0,0 +1,101 @@
+package language
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Normalize reduces a language tag such as "es-MX" or "DE" to its lowercase
+// primary subtag ("es", "de"), which is how joke languages are stored
+func Normalize(tag string) (string, error) {
+	primary, _, _ := strings.Cut(strings.TrimSpace(tag), "-")
+	primary, _, _ = strings.Cut(primary, "_")
+	primary = strings.ToLower(primary)
+
+	if len(primary) < 2 || len(primary) > 3 {
+		return "", fmt.Errorf("invalid language code %q", tag)
+	}
+	for _, r := range primary {
+		if r < 'a' || r > 'z' {
+			return "", fmt.Errorf("invalid language code %q", tag)
+		}
+	}
+
+	return primary, nil
+}
+
+// ParseAcceptLanguage returns the languages in an Accept-Language header,
+// most preferred first. Wildcards, invalid tags and q=0 entries are skipped.
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		lang    string
+		quality float64
+	}
+
+	var entries []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if quality <= 0 {
+			continue
+		}
+
+		lang, err := Normalize(tag)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, weighted{lang: lang, quality: quality})
+	}
+
+	// Stable sort keeps header order for equal weights
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].quality > entries[j].quality
+	})
+
+	langs := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		langs = append(langs, entry.lang)
+	}
+	return langs
+}
+
+// Preferences returns the languages requested by the caller, most preferred
+// first. An explicit language takes precedence over the Accept-Language header.
+func Preferences(explicit, acceptLanguage string) ([]string, error) {
+	if explicit != "" {
+		lang, err := Normalize(explicit)
+		if err != nil {
+			return nil, err
+		}
+		return []string{lang}, nil
+	}
+	return ParseAcceptLanguage(acceptLanguage), nil
+}
+
+// WithFallbacks appends the fallback chain to the preferred languages,
+// removing duplicates while keeping the first occurrence of each language
+func WithFallbacks(preferred, fallbacks []string) []string {
+	seen := make(map[string]bool)
+	langs := make([]string, 0, len(preferred)+len(fallbacks))
+	for _, lang := range append(append([]string{}, preferred...), fallbacks...) {
+		if seen[lang] {
+			continue
+		}
+		seen[lang] = true
+		langs = append(langs, lang)
+	}
+	return langs
+}
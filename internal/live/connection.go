@@ -0,0 +1,341 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/cdunlap/djaas/internal/language"
+	"github.com/cdunlap/djaas/internal/middleware"
+	"github.com/cdunlap/djaas/internal/model"
+	"github.com/cdunlap/djaas/internal/service"
+	"github.com/coder/websocket"
+	"golang.org/x/time/rate"
+)
+
+// connection is one client's WebSocket session. Requests are handled one
+// at a time by the read loop; a write loop owns all writes to the socket.
+type connection struct {
+	server         *Server
+	conn           *websocket.Conn
+	principal      *model.Principal
+	ceiling        model.ContentRating
+	clientIP       string
+	acceptLanguage string
+	out            chan ServerMessage
+	limiter        *rate.Limiter
+
+	// pending holds the punchlines of jokes sent on this connection, until
+	// they are revealed; order lists their joke IDs oldest first
+	mu      sync.Mutex
+	pending map[int32]pendingReveal
+	order   []int32
+}
+
+// pendingReveal is a punchline waiting to be revealed
+type pendingReveal struct {
+	punchline model.JokePart
+	revealAt  time.Time
+}
+
+// run serves the connection until it closes or ctx ends
+func (c *connection) run(ctx context.Context, cancel context.CancelFunc) {
+	defer c.conn.CloseNow()
+
+	notifications, unsubscribe := c.server.hub.Subscribe(sendQueueSize)
+	defer unsubscribe()
+
+	go c.writeLoop(ctx, cancel)
+	go c.notifyLoop(ctx, notifications)
+	go func() {
+		select {
+		case <-c.server.closing:
+			c.conn.Close(websocket.StatusGoingAway, "server shutting down")
+		case <-ctx.Done():
+		}
+	}()
+
+	for {
+		typ, data, err := c.conn.Read(ctx)
+		if err != nil {
+			if !isNormalClose(err) {
+				c.server.log(ctx).DebugContext(ctx, "websocket connection closed", "error", err)
+			}
+			return
+		}
+
+		// Requests over the rate wait here, which stops reading from the
+		// client until it is back within its rate
+		if err := c.limiter.Wait(ctx); err != nil {
+			return
+		}
+
+		var reply ServerMessage
+		var msg ClientMessage
+		if typ != websocket.MessageText || json.Unmarshal(data, &msg) != nil {
+			reply = c.errorMessage(ctx, "", "invalid_message", "Messages must be JSON objects with a type")
+		} else {
+			reply = c.handle(ctx, msg)
+		}
+
+		if !c.send(ctx, reply) {
+			return
+		}
+	}
+}
+
+// writeLoop writes queued messages and pings the client while idle. A
+// failed write ends the connection.
+func (c *connection) writeLoop(ctx context.Context, cancel context.CancelFunc) {
+	defer cancel()
+
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-c.out:
+			data, err := json.Marshal(msg)
+			if err != nil {
+				c.server.log(ctx).ErrorContext(ctx, "failed to encode websocket message", "error", err)
+				continue
+			}
+			writeCtx, writeCancel := context.WithTimeout(ctx, writeTimeout)
+			err = c.conn.Write(writeCtx, websocket.MessageText, data)
+			writeCancel()
+			if err != nil {
+				return
+			}
+		case <-ping.C:
+			pingCtx, pingCancel := context.WithTimeout(ctx, writeTimeout)
+			err := c.conn.Ping(pingCtx)
+			pingCancel()
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// notifyLoop announces newly approved jokes within the caller's rating
+// ceiling
+func (c *connection) notifyLoop(ctx context.Context, jokes <-chan model.Joke) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case joke := <-jokes:
+			if joke.Rating.Exceeds(c.ceiling) {
+				continue
+			}
+			msg := ServerMessage{Type: TypeNewJoke, Joke: c.tease(joke)}
+			select {
+			case c.out <- msg:
+			default:
+				c.server.dropped.Add(1)
+			}
+		}
+	}
+}
+
+// send queues a reply, waiting for room in the queue. It returns false if
+// the connection ended first.
+func (c *connection) send(ctx context.Context, msg ServerMessage) bool {
+	select {
+	case c.out <- msg:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// handle answers one client request
+func (c *connection) handle(ctx context.Context, msg ClientMessage) ServerMessage {
+	switch msg.Type {
+	case TypeJoke:
+		if reply, ok := c.checkLimits(ctx, msg); !ok {
+			return reply
+		}
+		return c.handleJoke(ctx, msg)
+	case TypeReveal:
+		return c.handleReveal(ctx, msg)
+	case TypeVote:
+		if reply, ok := c.checkLimits(ctx, msg); !ok {
+			return reply
+		}
+		return c.handleVote(ctx, msg)
+	default:
+		return c.errorMessage(ctx, msg.ID, "unknown_type", "type must be one of joke, reveal, vote")
+	}
+}
+
+// checkLimits charges a request to the caller's rate limit and daily
+// quota, under the search policy when it has a search query and the read
+// policy otherwise. Reveals are not charged, as they are answered from
+// memory. It returns the error reply for a rejected request.
+func (c *connection) checkLimits(ctx context.Context, msg ClientMessage) (ServerMessage, bool) {
+	limiter := c.server.config.RateLimiter
+	if limiter == nil {
+		return ServerMessage{}, true
+	}
+
+	policy := "read"
+	if msg.Search != "" {
+		policy = "search"
+	}
+	v := limiter.Check(ctx, c.server.logger, c.principal, c.clientIP, policy)
+	if v.Allowed {
+		return ServerMessage{}, true
+	}
+
+	reply := c.errorMessage(ctx, msg.ID, v.Reason, v.Message)
+	reply.RetryAfterMS = v.RetryAfter.Milliseconds()
+	return reply, false
+}
+
+// handleJoke finds a random joke and sends it without its punchline
+func (c *connection) handleJoke(ctx context.Context, msg ClientMessage) ServerMessage {
+	filter := service.JokeFilter{MaxRating: c.ceiling, Source: msg.Source}
+
+	if msg.MaxRating != "" {
+		maxRating, err := model.ParseContentRating(msg.MaxRating)
+		if err != nil {
+			return c.errorMessage(ctx, msg.ID, "invalid_rating", "max_rating must be one of g, pg, pg13, r")
+		}
+		if !maxRating.Exceeds(c.ceiling) {
+			filter.MaxRating = maxRating
+		}
+	}
+
+	languages, err := language.Preferences(msg.Lang, c.acceptLanguage)
+	if err != nil {
+		return c.errorMessage(ctx, msg.ID, "invalid_language", "lang must be a language code such as en, es or de")
+	}
+	filter.Languages = languages
+
+	if msg.JokeType != "" {
+		jokeType, err := model.ParseJokeType(msg.JokeType)
+		if err != nil {
+			return c.errorMessage(ctx, msg.ID, "invalid_type", "joke_type must be one of setup_punchline, one_liner, knock_knock, multi_part")
+		}
+		filter.Type = jokeType
+	}
+
+	query := service.JokeQuery{Search: msg.Search, Category: msg.Category, Tags: msg.Tags}
+	joke, err := c.server.jokes.FindJoke(ctx, query, filter)
+	if err != nil {
+		return c.serviceError(ctx, msg.ID, err)
+	}
+
+	c.server.metrics.JokeServed(joke.Category)
+	return ServerMessage{Type: TypeJoke, ID: msg.ID, Joke: c.tease(*joke)}
+}
+
+// handleReveal sends the punchline of a joke received on this connection
+// once its reveal delay has passed
+func (c *connection) handleReveal(ctx context.Context, msg ClientMessage) ServerMessage {
+	c.mu.Lock()
+	reveal, ok := c.pending[msg.JokeID]
+	wait := time.Until(reveal.revealAt)
+	if ok && wait <= 0 {
+		c.forget(msg.JokeID)
+	}
+	c.mu.Unlock()
+
+	switch {
+	case !ok:
+		return c.errorMessage(ctx, msg.ID, "unknown_joke", "Only the punchlines of jokes received on this connection can be revealed, once each")
+	case wait > 0:
+		reply := c.errorMessage(ctx, msg.ID, "too_early", "The punchline is not ready yet")
+		reply.JokeID = msg.JokeID
+		reply.RetryAfterMS = max(wait.Milliseconds(), 1)
+		return reply
+	}
+
+	return ServerMessage{Type: TypePunchline, ID: msg.ID, JokeID: msg.JokeID, Punchline: &reveal.punchline}
+}
+
+// handleVote records the caller's vote on a joke
+func (c *connection) handleVote(ctx context.Context, msg ClientMessage) ServerMessage {
+	if msg.JokeID <= 0 {
+		return c.errorMessage(ctx, msg.ID, "invalid_id", "joke_id must be a positive integer")
+	}
+	vote, err := model.ParseVote(msg.Vote)
+	if err != nil {
+		return c.errorMessage(ctx, msg.ID, "invalid_vote", "vote must be up or down")
+	}
+
+	totals, err := c.server.jokes.VoteJoke(ctx, msg.JokeID, c.principal.Subject, vote)
+	if err != nil {
+		return c.serviceError(ctx, msg.ID, err)
+	}
+	return ServerMessage{Type: TypeVote, ID: msg.ID, JokeID: msg.JokeID, Votes: totals}
+}
+
+// tease hides a joke's punchline, remembering it to be revealed after the
+// reveal delay
+func (c *connection) tease(joke model.Joke) *Teaser {
+	lead, punchline := splitJoke(joke)
+	teaser := newTeaser(joke, lead, punchline != nil)
+	if punchline == nil {
+		return teaser
+	}
+
+	delay := c.server.config.RevealDelay
+	teaser.RevealAfterMS = delay.Milliseconds()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.pending[joke.ID]; ok {
+		c.forget(joke.ID)
+	}
+	if len(c.order) >= maxPendingReveals {
+		c.forget(c.order[0])
+	}
+	c.pending[joke.ID] = pendingReveal{punchline: *punchline, revealAt: time.Now().Add(delay)}
+	c.order = append(c.order, joke.ID)
+	return teaser
+}
+
+// forget drops a pending punchline; c.mu must be held
+func (c *connection) forget(jokeID int32) {
+	delete(c.pending, jokeID)
+	for i, id := range c.order {
+		if id == jokeID {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// errorMessage builds an error reply
+func (c *connection) errorMessage(ctx context.Context, id, code, message string) ServerMessage {
+	return ServerMessage{
+		Type: TypeError,
+		ID:   id,
+		Error: &model.ErrorResponse{
+			Error:     code,
+			Message:   message,
+			RequestID: middleware.RequestIDFromContext(ctx),
+		},
+	}
+}
+
+// serviceError maps a JokeService error to an error reply
+func (c *connection) serviceError(ctx context.Context, id string, err error) ServerMessage {
+	switch {
+	case errors.Is(err, service.ErrNoJokesFound):
+		return c.errorMessage(ctx, id, "not_found", "No jokes found matching your criteria")
+	case errors.Is(err, service.ErrJokeNotFound):
+		return c.errorMessage(ctx, id, "not_found", "Joke not found")
+	case errors.Is(err, service.ErrInvalidInput):
+		return c.errorMessage(ctx, id, "invalid_input", "Invalid search query, category, or tags")
+	default:
+		c.server.log(ctx).ErrorContext(ctx, "websocket request failed", "error", err, "principal", c.principal.Subject)
+		return c.errorMessage(ctx, id, "internal_error", "An internal error occurred")
+	}
+}
@@ -0,0 +1,64 @@
+// Package live delivers jokes over WebSocket connections: jokes on request
+// with the punchline revealed separately, votes, and notifications of newly
+// approved jokes.
+package live
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/cdunlap/djaas/internal/model"
+)
+
+// Hub fans newly approved jokes out to subscribers. It implements
+// service.JokePublisher. Jokes are only delivered to subscribers on this
+// instance.
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[chan model.Joke]struct{}
+	dropped     atomic.Uint64
+}
+
+// NewHub creates a Hub with no subscribers
+func NewHub() *Hub {
+	return &Hub{subscribers: make(map[chan model.Joke]struct{})}
+}
+
+// Publish sends joke to every subscriber without blocking. Subscribers
+// whose buffer is full miss it.
+func (h *Hub) Publish(joke model.Joke) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subscribers {
+		select {
+		case ch <- joke:
+		default:
+			h.dropped.Add(1)
+		}
+	}
+}
+
+// Subscribe returns a channel receiving published jokes, buffered to size,
+// and a function that ends the subscription
+func (h *Hub) Subscribe(size int) (<-chan model.Joke, func()) {
+	ch := make(chan model.Joke, size)
+
+	h.mu.Lock()
+	h.subscribers[ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subscribers, ch)
+			h.mu.Unlock()
+		})
+	}
+}
+
+// Dropped returns the number of deliveries missed by slow subscribers
+func (h *Hub) Dropped() uint64 {
+	return h.dropped.Load()
+}
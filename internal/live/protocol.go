@@ -0,0 +1,115 @@
+package live
+
+import (
+	"github.com/cdunlap/djaas/internal/model"
+)
+
+// Message types sent by clients
+const (
+	// TypeJoke requests a random joke; the reply carries a Teaser
+	TypeJoke = "joke"
+	// TypeReveal requests the punchline of a joke received on the connection
+	TypeReveal = "reveal"
+	// TypeVote votes a joke up or down; the reply carries the new totals
+	TypeVote = "vote"
+)
+
+// Message types sent only by the server
+const (
+	// TypePunchline answers a reveal
+	TypePunchline = "punchline"
+	// TypeNewJoke announces a newly approved joke
+	TypeNewJoke = "new_joke"
+	// TypeError answers a request that failed
+	TypeError = "error"
+)
+
+// ClientMessage is a request from a client. ID is optional and echoed in
+// the reply, so clients can match replies to requests.
+type ClientMessage struct {
+	Type string `json:"type"`
+	ID   string `json:"id,omitempty"`
+
+	// Filters for joke requests, as in GET /api/v1/joke
+	Search    string   `json:"search,omitempty"`
+	Category  string   `json:"category,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+	MaxRating string   `json:"max_rating,omitempty"`
+	Lang      string   `json:"lang,omitempty"`
+	JokeType  string   `json:"joke_type,omitempty"`
+	Source    string   `json:"source,omitempty"`
+
+	// JokeID identifies the joke to reveal or vote on
+	JokeID int32 `json:"joke_id,omitempty"`
+	// Vote is "up" or "down"
+	Vote string `json:"vote,omitempty"`
+}
+
+// ServerMessage is a reply or notification sent to a client
+type ServerMessage struct {
+	Type string `json:"type"`
+	ID   string `json:"id,omitempty"`
+
+	Joke      *Teaser              `json:"joke,omitempty"`
+	JokeID    int32                `json:"joke_id,omitempty"`
+	Punchline *model.JokePart      `json:"punchline,omitempty"`
+	Votes     *model.VoteTotals    `json:"votes,omitempty"`
+	Error     *model.ErrorResponse `json:"error,omitempty"`
+	// RetryAfterMS tells a client that asked for a punchline too early, or
+	// that is over its rate limit or quota, how long to wait
+	RetryAfterMS int64 `json:"retry_after_ms,omitempty"`
+}
+
+// Teaser is a joke without its punchline. Parts holds every line before
+// the punchline; one-liners have no punchline, so Parts is the whole joke.
+type Teaser struct {
+	ID              int32               `json:"id"`
+	Type            model.JokeType      `json:"type"`
+	Parts           []model.JokePart    `json:"parts"`
+	Category        *string             `json:"category,omitempty"`
+	Tags            []string            `json:"tags"`
+	Rating          model.ContentRating `json:"rating"`
+	ContentWarnings []string            `json:"content_warnings"`
+	Language        string              `json:"language"`
+	Author          *string             `json:"author,omitempty"`
+	SourceName      *string             `json:"source_name,omitempty"`
+	SourceURL       *string             `json:"source_url,omitempty"`
+	License         *string             `json:"license,omitempty"`
+	// HasPunchline is set when the punchline can be revealed
+	HasPunchline bool `json:"has_punchline"`
+	// RevealAfterMS is how long to wait before asking for the punchline
+	RevealAfterMS int64 `json:"reveal_after_ms,omitempty"`
+}
+
+// splitJoke separates a joke's punchline from the lines leading up to it.
+// One-liners have no punchline.
+func splitJoke(joke model.Joke) ([]model.JokePart, *model.JokePart) {
+	parts := joke.Parts
+	if len(parts) == 0 {
+		parts = []model.JokePart{{Text: joke.Setup}, {Text: joke.Punchline}}
+	}
+	if joke.Type == model.TypeOneLiner || len(parts) < 2 {
+		return parts, nil
+	}
+	punchline := parts[len(parts)-1]
+	return parts[:len(parts)-1], &punchline
+}
+
+// newTeaser hides a joke's punchline
+func newTeaser(joke model.Joke, lead []model.JokePart, hasPunchline bool) *Teaser {
+	return &Teaser{
+		ID:              joke.ID,
+		Type:            joke.Type,
+		Parts:           lead,
+		Category:        joke.Category,
+		Tags:            joke.Tags,
+		Rating:          joke.Rating,
+		ContentWarnings: joke.ContentWarnings,
+		Language:        joke.Language,
+		Author:          joke.Author,
+		SourceName:      joke.SourceName,
+		SourceURL:       joke.SourceURL,
+		License:         joke.License,
+		HasPunchline:    hasPunchline,
+	}
+}
@@ -0,0 +1,264 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cdunlap/djaas/internal/logging"
+	"github.com/cdunlap/djaas/internal/middleware"
+	"github.com/cdunlap/djaas/internal/model"
+	"github.com/cdunlap/djaas/internal/service"
+	"github.com/coder/websocket"
+	"golang.org/x/time/rate"
+)
+
+// Subprotocol is the WebSocket subprotocol the server speaks
+const Subprotocol = "djaas.v1"
+
+const (
+	// maxMessageSize bounds client messages, which are small JSON requests
+	maxMessageSize = 4096
+	// sendQueueSize is how many messages may wait to be written to a client.
+	// Replies wait for room; notifications are dropped when it is full.
+	sendQueueSize = 16
+	// writeTimeout bounds each write and ping; slower clients are dropped
+	writeTimeout = 10 * time.Second
+	// pingInterval is how often idle connections are checked
+	pingInterval = 30 * time.Second
+	// maxPendingReveals bounds the punchlines remembered per connection
+	maxPendingReveals = 32
+
+	// messageRate and messageBurst pace each connection's requests; reading
+	// pauses while a client is over its rate. They are a backstop against
+	// floods, and requests are also held to the caller's rate limits.
+	messageRate  = 5
+	messageBurst = 10
+)
+
+// Reasons a connection is refused, as reported in Stats
+const (
+	RejectServerFull  = "server_full"
+	RejectCallerLimit = "caller_limit"
+)
+
+// JokeMetrics records the jokes served, for metrics
+type JokeMetrics interface {
+	JokeServed(category *string)
+}
+
+// Config limits WebSocket connections
+type Config struct {
+	// MaxConnections caps open connections on this instance
+	MaxConnections int
+	// MaxConnectionsPerCaller caps open connections per API key or token subject
+	MaxConnectionsPerCaller int
+	// RevealDelay is how long a client waits between receiving a joke and
+	// being given its punchline
+	RevealDelay time.Duration
+	// OriginPatterns lists other origins whose pages may connect, such as
+	// "widget.example.com" or "*.example.com". The API's own host is
+	// always allowed.
+	OriginPatterns []string
+	// RateLimiter holds joke and vote requests to the caller's rate limit
+	// and daily quota, as if each were an HTTP request; nil disables this
+	RateLimiter *middleware.RateLimiter
+}
+
+// Stats describes the server's connections, for metrics
+type Stats struct {
+	Connections int
+	// Rejected counts refused connections by reason
+	Rejected map[string]uint64
+	// DroppedNotifications counts notifications not sent to slow clients
+	DroppedNotifications uint64
+}
+
+// Server accepts WebSocket connections and serves the live protocol. It
+// must be mounted behind middleware.Authenticate and RequireScope.
+type Server struct {
+	jokes   *service.JokeService
+	hub     *Hub
+	metrics JokeMetrics
+	logger  *slog.Logger
+	config  Config
+
+	mu          sync.Mutex
+	connections int
+	perCaller   map[string]int
+	rejected    map[string]uint64
+	closing     chan struct{}
+	closed      bool
+	active      sync.WaitGroup
+
+	dropped atomic.Uint64
+}
+
+// NewServer creates a Server that finds jokes with jokes and announces the
+// jokes published to hub
+func NewServer(jokes *service.JokeService, hub *Hub, metrics JokeMetrics, logger *slog.Logger, config Config) *Server {
+	return &Server{
+		jokes:     jokes,
+		hub:       hub,
+		metrics:   metrics,
+		logger:    logger,
+		config:    config,
+		perCaller: make(map[string]int),
+		rejected:  make(map[string]uint64),
+		closing:   make(chan struct{}),
+	}
+}
+
+// log returns the request's logger, which carries its request ID
+func (s *Server) log(ctx context.Context) *slog.Logger {
+	return logging.FromContext(ctx, s.logger)
+}
+
+// ServeHTTP upgrades the request to a WebSocket connection and serves it
+// until either side closes it
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	principal := middleware.PrincipalFromContext(r.Context())
+	if principal == nil {
+		writeError(w, r, http.StatusUnauthorized, "unauthorized", "Authentication is required")
+		return
+	}
+
+	switch reason := s.acquire(principal.Subject); reason {
+	case "":
+	case RejectCallerLimit:
+		writeError(w, r, http.StatusTooManyRequests, "too_many_connections", "This caller has too many open connections")
+		return
+	default:
+		writeError(w, r, http.StatusServiceUnavailable, "too_many_connections", "The server is at its connection limit, please try again later")
+		return
+	}
+	defer s.release(principal.Subject)
+
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+		Subprotocols:   []string{Subprotocol},
+		OriginPatterns: s.config.OriginPatterns,
+	})
+	if err != nil {
+		s.log(r.Context()).DebugContext(r.Context(), "websocket upgrade failed", "error", err)
+		return
+	}
+	conn.SetReadLimit(maxMessageSize)
+
+	// The request context ends when the handler returns, not when the
+	// hijacked connection closes, so the connection manages its own
+	ctx, cancel := context.WithCancel(context.WithoutCancel(r.Context()))
+	defer cancel()
+
+	c := &connection{
+		server:         s,
+		conn:           conn,
+		principal:      principal,
+		ceiling:        middleware.RatingCeilingFromContext(r.Context()),
+		clientIP:       middleware.ClientIPFromContext(r.Context()),
+		acceptLanguage: r.Header.Get("Accept-Language"),
+		out:            make(chan ServerMessage, sendQueueSize),
+		limiter:        rate.NewLimiter(messageRate, messageBurst),
+		pending:        make(map[int32]pendingReveal),
+	}
+	c.run(ctx, cancel)
+}
+
+// acquire reserves a connection slot for subject, returning the reason it
+// was refused or "" on success
+func (s *Server) acquire(subject string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case s.closed || s.connections >= s.config.MaxConnections:
+		s.rejected[RejectServerFull]++
+		return RejectServerFull
+	case s.perCaller[subject] >= s.config.MaxConnectionsPerCaller:
+		s.rejected[RejectCallerLimit]++
+		return RejectCallerLimit
+	}
+
+	s.connections++
+	s.perCaller[subject]++
+	s.active.Add(1)
+	return ""
+}
+
+// release frees a slot reserved by acquire
+func (s *Server) release(subject string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.connections--
+	if s.perCaller[subject]--; s.perCaller[subject] <= 0 {
+		delete(s.perCaller, subject)
+	}
+	s.active.Done()
+}
+
+// Shutdown closes every connection with a going-away status and waits for
+// them to finish, or for ctx to end. New connections are refused.
+// http.Server.Shutdown does not track WebSocket connections, so call this too.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.closing)
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.active.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stats implements metrics.LiveStats
+func (s *Server) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rejected := make(map[string]uint64, len(s.rejected))
+	for reason, count := range s.rejected {
+		rejected[reason] = count
+	}
+	return Stats{
+		Connections:          s.connections,
+		Rejected:             rejected,
+		DroppedNotifications: s.dropped.Load() + s.hub.Dropped(),
+	}
+}
+
+// writeError writes a JSON error response for a refused upgrade
+func writeError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(model.ErrorResponse{
+		Error:     code,
+		Message:   message,
+		RequestID: middleware.RequestIDFromContext(r.Context()),
+	})
+}
+
+// isNormalClose reports whether err is the peer closing the connection
+// normally, rather than a failure worth logging
+func isNormalClose(err error) bool {
+	switch websocket.CloseStatus(err) {
+	case websocket.StatusNormalClosure, websocket.StatusGoingAway, websocket.StatusNoStatusRcvd:
+		return true
+	}
+	return errors.Is(err, context.Canceled)
+}
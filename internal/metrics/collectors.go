@@ -0,0 +1,163 @@
+package metrics
+
+import (
+	"github.com/cdunlap/djaas/internal/live"
+	"github.com/cdunlap/djaas/internal/middleware"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// poolCollector reports pgxpool statistics, read when scraped
+type poolCollector struct {
+	pool *pgxpool.Pool
+
+	acquired     *prometheus.Desc
+	idle         *prometheus.Desc
+	total        *prometheus.Desc
+	max          *prometheus.Desc
+	acquires     *prometheus.Desc
+	emptyAcquire *prometheus.Desc
+	waitSeconds  *prometheus.Desc
+	canceled     *prometheus.Desc
+}
+
+func newPoolCollector(pool *pgxpool.Pool) *poolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+	}
+	return &poolCollector{
+		pool:         pool,
+		acquired:     desc("acquired_connections", "Connections currently in use."),
+		idle:         desc("idle_connections", "Connections currently idle."),
+		total:        desc("connections", "Connections currently open, including ones being established."),
+		max:          desc("max_connections", "Most connections the pool will open."),
+		acquires:     desc("acquires_total", "Connections acquired from the pool."),
+		emptyAcquire: desc("empty_acquires_total", "Acquires that had to wait because no connection was idle."),
+		waitSeconds:  desc("acquire_wait_seconds_total", "Time spent waiting for a connection when none was idle."),
+		canceled:     desc("canceled_acquires_total", "Acquires canceled by their context before a connection was free."),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.acquired
+	ch <- c.idle
+	ch <- c.total
+	ch <- c.max
+	ch <- c.acquires
+	ch <- c.emptyAcquire
+	ch <- c.waitSeconds
+	ch <- c.canceled
+}
+
+// Collect implements prometheus.Collector
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(c.acquired, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.total, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.max, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquires, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquire, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.waitSeconds, prometheus.CounterValue, stat.EmptyAcquireWaitTime().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.canceled, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+}
+
+// RateLimitStats is the view of a rate limiter needed for its metrics
+type RateLimitStats interface {
+	Stats() map[string]middleware.LimiterStats
+	Rejections() []middleware.Rejection
+}
+
+// rateLimitCollector reports rate limiter rejections and in-memory store
+// sizes, read when scraped
+type rateLimitCollector struct {
+	limiter RateLimitStats
+
+	rejections  *prometheus.Desc
+	entries     *prometheus.Desc
+	evictions   *prometheus.Desc
+	expirations *prometheus.Desc
+}
+
+func newRateLimitCollector(limiter RateLimitStats) *rateLimitCollector {
+	return &rateLimitCollector{
+		limiter: limiter,
+		rejections: prometheus.NewDesc(prometheus.BuildFQName(namespace, "ratelimit", "rejections_total"),
+			"Requests rejected by the rate limiter, by reason and the tier or route policy whose limit was hit.",
+			[]string{"reason", "limit"}, nil),
+		entries: prometheus.NewDesc(prometheus.BuildFQName(namespace, "ratelimit", "store_entries"),
+			"Callers tracked by an in-memory rate limit store.",
+			[]string{"limit"}, nil),
+		evictions: prometheus.NewDesc(prometheus.BuildFQName(namespace, "ratelimit", "store_evictions_total"),
+			"Callers dropped from a full in-memory rate limit store.",
+			[]string{"limit"}, nil),
+		expirations: prometheus.NewDesc(prometheus.BuildFQName(namespace, "ratelimit", "store_expirations_total"),
+			"Idle callers dropped from an in-memory rate limit store.",
+			[]string{"limit"}, nil),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *rateLimitCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.rejections
+	ch <- c.entries
+	ch <- c.evictions
+	ch <- c.expirations
+}
+
+// Collect implements prometheus.Collector
+func (c *rateLimitCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, r := range c.limiter.Rejections() {
+		ch <- prometheus.MustNewConstMetric(c.rejections, prometheus.CounterValue, float64(r.Count), r.Reason, r.Limit)
+	}
+	for limit, stats := range c.limiter.Stats() {
+		ch <- prometheus.MustNewConstMetric(c.entries, prometheus.GaugeValue, float64(stats.Entries), limit)
+		ch <- prometheus.MustNewConstMetric(c.evictions, prometheus.CounterValue, float64(stats.Evictions), limit)
+		ch <- prometheus.MustNewConstMetric(c.expirations, prometheus.CounterValue, float64(stats.Expirations), limit)
+	}
+}
+
+// LiveStats is the view of the WebSocket server needed for its metrics
+type LiveStats interface {
+	Stats() live.Stats
+}
+
+// liveCollector reports WebSocket connections, read when scraped
+type liveCollector struct {
+	server LiveStats
+
+	connections *prometheus.Desc
+	rejected    *prometheus.Desc
+	dropped     *prometheus.Desc
+}
+
+func newLiveCollector(server LiveStats) *liveCollector {
+	return &liveCollector{
+		server: server,
+		connections: prometheus.NewDesc(prometheus.BuildFQName(namespace, "websocket", "connections"),
+			"Open WebSocket connections.", nil, nil),
+		rejected: prometheus.NewDesc(prometheus.BuildFQName(namespace, "websocket", "rejected_connections_total"),
+			"WebSocket connections refused at a connection limit, by reason.",
+			[]string{"reason"}, nil),
+		dropped: prometheus.NewDesc(prometheus.BuildFQName(namespace, "websocket", "dropped_notifications_total"),
+			"New joke notifications not sent to clients that were too slow to receive them.", nil, nil),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *liveCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.connections
+	ch <- c.rejected
+	ch <- c.dropped
+}
+
+// Collect implements prometheus.Collector
+func (c *liveCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.server.Stats()
+	ch <- prometheus.MustNewConstMetric(c.connections, prometheus.GaugeValue, float64(stats.Connections))
+	for reason, count := range stats.Rejected {
+		ch <- prometheus.MustNewConstMetric(c.rejected, prometheus.CounterValue, float64(count), reason)
+	}
+	ch <- prometheus.MustNewConstMetric(c.dropped, prometheus.CounterValue, float64(stats.DroppedNotifications))
+}
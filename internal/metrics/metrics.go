@@ -0,0 +1,120 @@
+// Package metrics collects Prometheus metrics for the API: requests by
+// route, database pool usage, rate limiting, WebSocket connections and the
+// jokes served.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "djaas"
+
+// Metrics holds the application's collectors in its own registry
+type Metrics struct {
+	registry     *prometheus.Registry
+	requests     *prometheus.CounterVec
+	duration     *prometheus.HistogramVec
+	jokesServed  *prometheus.CounterVec
+	noJokesFound prometheus.Counter
+}
+
+// New creates a Metrics with the request and joke collectors, plus the Go
+// runtime and process collectors
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests handled, by method, route pattern and status code.",
+		}, []string{"method", "route", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency, by method, route pattern and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		jokesServed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "jokes_served_total",
+			Help:      "Jokes returned to callers, by category.",
+		}, []string{"category"}),
+		noJokesFound: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "jokes_not_found_total",
+			Help:      "Requests for which no joke matched the filters.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		m.requests,
+		m.duration,
+		m.jokesServed,
+		m.noJokesFound,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// RegisterPool adds the database connection pool's statistics
+func (m *Metrics) RegisterPool(pool *pgxpool.Pool) {
+	m.registry.MustRegister(newPoolCollector(pool))
+}
+
+// RegisterRateLimiter adds the rate limiter's rejections and store sizes
+func (m *Metrics) RegisterRateLimiter(limiter RateLimitStats) {
+	m.registry.MustRegister(newRateLimitCollector(limiter))
+}
+
+// RegisterLive adds the WebSocket server's connections
+func (m *Metrics) RegisterLive(server LiveStats) {
+	m.registry.MustRegister(newLiveCollector(server))
+}
+
+// ObserveRequest implements middleware.RequestRecorder
+func (m *Metrics) ObserveRequest(method, route string, status int, duration time.Duration) {
+	method = methodLabel(method)
+	code := strconv.Itoa(status)
+	m.requests.WithLabelValues(method, route, code).Inc()
+	m.duration.WithLabelValues(method, route, code).Observe(duration.Seconds())
+}
+
+// methodLabel returns the method label for a request. Clients may send
+// any token as the method, so methods other than the standard ones are
+// counted as OTHER to keep the number of series bounded.
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	default:
+		return "OTHER"
+	}
+}
+
+// JokeServed implements handler.JokeMetrics
+func (m *Metrics) JokeServed(category *string) {
+	label := "uncategorized"
+	if category != nil && *category != "" {
+		label = *category
+	}
+	m.jokesServed.WithLabelValues(label).Inc()
+}
+
+// NoJokesFound implements handler.JokeMetrics
+func (m *Metrics) NoJokesFound() {
+	m.noJokesFound.Inc()
+}
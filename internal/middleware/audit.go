@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/cdunlap/djaas/internal/audit"
+)
+
+// AuditActor creates a middleware that identifies the caller to the audit
+// log: its principal, client IP and request ID. It must run after
+// RequestID, ClientIP and Authenticate.
+func AuditActor() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			subject := "anonymous"
+			if principal := PrincipalFromContext(ctx); principal != nil {
+				subject = principal.Subject
+			}
+
+			ctx = audit.WithActor(ctx, audit.Actor{
+				Subject:   subject,
+				ClientIP:  ClientIPFromContext(ctx),
+				RequestID: RequestIDFromContext(ctx),
+			})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
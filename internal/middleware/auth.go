@@ -1,29 +1,58 @@
 package middleware
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
-	"os"
+
+	"github.com/cdunlap/djaas/internal/auth"
+	"github.com/cdunlap/djaas/internal/logging"
+	"github.com/cdunlap/djaas/internal/model"
 )
 
-// SimpleAuth checks for a valid API token in the X-API-Token header
-func SimpleAuth() func(http.Handler) http.Handler {
-	// Get the API token from environment variable
-	apiToken := os.Getenv("API_TOKEN")
-	if apiToken == "" {
-		apiToken = "default-secret-token" // Fallback for development
-	}
+type principalKey struct{}
 
+// Authenticate identifies the caller with the first authenticator that finds
+// credentials in the request, and stores the resulting principal in the
+// request context. Requests without credentials continue anonymously;
+// requests with invalid credentials are rejected. Failures are charged to
+// the client address by throttle, which may be nil, and addresses with too
+// many are refused with 429 before their credentials are checked.
+func Authenticate(logger *slog.Logger, throttle *AuthThrottle, authenticators ...auth.Authenticator) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			token := r.Header.Get("X-API-Token")
-
-			if token == "" {
-				http.Error(w, `{"error":"Missing X-API-Token header"}`, http.StatusUnauthorized)
-				return
+			clientIP := clientIPOf(r)
+			if HasCredentials(r) {
+				if wait := throttle.Blocked(clientIP); wait > 0 {
+					w.Header().Set("Retry-After", fmt.Sprintf("%d", max(ceilSeconds(wait), 1)))
+					writeError(w, r, http.StatusTooManyRequests, "rate_limit_exceeded", "Too many failed authentication attempts, please try again later")
+					return
+				}
 			}
 
-			if token != apiToken {
-				http.Error(w, `{"error":"Invalid API token"}`, http.StatusUnauthorized)
+			for _, authenticator := range authenticators {
+				principal, err := authenticator.Authenticate(r)
+				if errors.Is(err, auth.ErrNoCredentials) {
+					continue
+				}
+				if err != nil {
+					if errors.Is(err, auth.ErrInvalidCredentials) {
+						logging.FromContext(r.Context(), logger).DebugContext(r.Context(), "rejected credentials", "error", err)
+						throttle.Failed(r.Context(), clientIP)
+						w.Header().Set("WWW-Authenticate", `Bearer realm="djaas", error="invalid_token"`)
+						writeError(w, r, http.StatusUnauthorized, "invalid_credentials", "The API key or bearer token is invalid, expired or revoked")
+						return
+					}
+					logging.FromContext(r.Context(), logger).ErrorContext(r.Context(), "failed to authenticate request", "error", err)
+					writeError(w, r, http.StatusServiceUnavailable, "auth_unavailable", "Credentials could not be checked, please try again later")
+					return
+				}
+
+				setLogPrincipal(r.Context(), principal.Subject)
+				ctx := context.WithValue(r.Context(), principalKey{}, principal)
+				next.ServeHTTP(w, r.WithContext(ctx))
 				return
 			}
 
@@ -31,3 +60,16 @@ func SimpleAuth() func(http.Handler) http.Handler {
 		})
 	}
 }
+
+// HasCredentials reports whether a request carries an API key or an
+// Authorization header for the authenticators to check
+func HasCredentials(r *http.Request) bool {
+	return r.Header.Get("X-API-Token") != "" || r.Header.Get("Authorization") != ""
+}
+
+// PrincipalFromContext returns the authenticated caller, or nil for
+// anonymous requests
+func PrincipalFromContext(ctx context.Context) *model.Principal {
+	principal, _ := ctx.Value(principalKey{}).(*model.Principal)
+	return principal
+}
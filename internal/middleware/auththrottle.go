@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// AuthPolicy is the rate limit policy for failed authentication attempts,
+// counted per client address
+const AuthPolicy = "auth"
+
+// maxBlockedClients bounds the addresses an AuthThrottle remembers
+const maxBlockedClients = 100000
+
+// AuthThrottle limits failed authentication attempts per client address,
+// or per /64 network for IPv6, under AuthPolicy, so that invalid API keys
+// and tokens cannot drive key lookups and signature checks faster than the
+// policy allows. Failures are charged through the RateLimiter, so replicas
+// sharing its backend share the count. Once an address is over the limit,
+// its requests with credentials are refused before the authenticators run,
+// until it may retry.
+type AuthThrottle struct {
+	limiter *RateLimiter
+	logger  *slog.Logger
+
+	mu      sync.Mutex
+	blocked map[string]time.Time
+}
+
+// NewAuthThrottle creates an AuthThrottle charging failures to limiter
+func NewAuthThrottle(logger *slog.Logger, limiter *RateLimiter) *AuthThrottle {
+	return &AuthThrottle{
+		limiter: limiter,
+		logger:  logger,
+		blocked: make(map[string]time.Time),
+	}
+}
+
+// Blocked returns how long clientIP must wait before its credentials are
+// checked again, or 0 if they may be checked now. A nil AuthThrottle
+// blocks nothing.
+func (t *AuthThrottle) Blocked(clientIP string) time.Duration {
+	if t == nil {
+		return 0
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := clientKey(clientIP)
+	until, ok := t.blocked[key]
+	if !ok {
+		return 0
+	}
+	wait := time.Until(until)
+	if wait <= 0 {
+		delete(t.blocked, key)
+		return 0
+	}
+	t.limiter.reject("rate_limit_exceeded", "route-"+AuthPolicy)
+	return wait
+}
+
+// Failed charges a failed attempt from clientIP. Once the address is over
+// the limit, it is blocked until it may retry.
+func (t *AuthThrottle) Failed(ctx context.Context, clientIP string) {
+	if t == nil {
+		return
+	}
+
+	v := t.limiter.Check(ctx, t.logger, nil, clientIP, AuthPolicy)
+	if v.Allowed || v.RetryAfter == 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if len(t.blocked) >= maxBlockedClients {
+		for ip, until := range t.blocked {
+			if !until.After(now) {
+				delete(t.blocked, ip)
+			}
+		}
+	}
+	if len(t.blocked) < maxBlockedClients {
+		t.blocked[clientKey(clientIP)] = now.Add(v.RetryAfter)
+	}
+}
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cdunlap/djaas/internal/auth"
+	"github.com/cdunlap/djaas/internal/model"
+)
+
+// rejectAll rejects every credential it is shown
+type rejectAll struct{}
+
+func (rejectAll) Authenticate(r *http.Request) (*model.Principal, error) {
+	if !HasCredentials(r) {
+		return nil, auth.ErrNoCredentials
+	}
+	return nil, fmt.Errorf("%w: unknown key", auth.ErrInvalidCredentials)
+}
+
+func TestAuthenticateThrottlesFailures(t *testing.T) {
+	_, backend := newTestRedis(t, time.Now())
+	limiter := NewRateLimiter(
+		Tier{Name: "anonymous", Requests: 100, Window: time.Minute},
+		nil, "", []Policy{{Name: AuthPolicy, Requests: 2, Window: time.Minute}}, backend, false,
+	)
+	logger := slog.New(slog.DiscardHandler)
+	handler := Authenticate(logger, NewAuthThrottle(logger, limiter), rejectAll{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(remoteAddr, token string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/joke", nil)
+		r.RemoteAddr = remoteAddr
+		if token != "" {
+			r.Header.Set("X-API-Token", token)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	// The failure over the limit is still answered, then the address is blocked
+	for i := range 3 {
+		if w := serve("192.0.2.1:1234", "guess"); w.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt #%d: status %d, want 401", i+1, w.Code)
+		}
+	}
+	w := serve("192.0.2.1:1234", "guess")
+	if w.Code != http.StatusTooManyRequests || errorCode(t, w) != "rate_limit_exceeded" {
+		t.Fatalf("blocked attempt: status %d, want 429 rate_limit_exceeded", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("blocked attempt has no Retry-After header")
+	}
+
+	// Requests without credentials, and other addresses, are unaffected
+	if w := serve("192.0.2.1:1234", ""); w.Code != http.StatusOK {
+		t.Errorf("anonymous request from blocked address: status %d, want 200", w.Code)
+	}
+	if w := serve("192.0.2.2:1234", "guess"); w.Code != http.StatusUnauthorized {
+		t.Errorf("attempt from another address: status %d, want 401", w.Code)
+	}
+}
+
+// A nil throttle leaves failures unlimited
+func TestAuthenticateWithoutThrottle(t *testing.T) {
+	handler := Authenticate(slog.New(slog.DiscardHandler), nil, rejectAll{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	for i := range 5 {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/joke", nil)
+		r.Header.Set("X-API-Token", "guess")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt #%d: status %d, want 401", i+1, w.Code)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/cdunlap/djaas/internal/model"
+)
+
+// RequireScope rejects requests from anonymous callers with 401 and from
+// callers missing any of the given scopes with 403. It must run after
+// Authenticate.
+func RequireScope(scopes ...model.Scope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal := PrincipalFromContext(r.Context())
+			if principal == nil {
+				writeUnauthorized(w, r)
+				return
+			}
+
+			for _, scope := range scopes {
+				if !principal.HasScope(scope) {
+					writeError(w, r, http.StatusForbidden, "forbidden", "This operation requires the "+string(scope)+" scope")
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireRole rejects requests from anonymous callers with 401 and from
+// callers that do not hold every scope of the given role with 403. It must
+// run after Authenticate.
+func RequireRole(role model.Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal := PrincipalFromContext(r.Context())
+			if principal == nil {
+				writeUnauthorized(w, r)
+				return
+			}
+
+			if !principal.HasRole(role) {
+				scopes := make([]string, 0, len(role.Scopes()))
+				for _, scope := range role.Scopes() {
+					scopes = append(scopes, string(scope))
+				}
+				writeError(w, r, http.StatusForbidden, "forbidden", "This operation requires the "+string(role)+" role ("+strings.Join(scopes, ", ")+")")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// writeUnauthorized tells an anonymous caller how to authenticate
+func writeUnauthorized(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="djaas"`)
+	writeError(w, r, http.StatusUnauthorized, "unauthorized", "Authentication required: send an X-API-Token or Authorization: Bearer header")
+}
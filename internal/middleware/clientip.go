@@ -0,0 +1,198 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// Headers a trusted proxy may use to pass on the client address
+const (
+	HeaderXForwardedFor = "X-Forwarded-For"
+	HeaderForwarded     = "Forwarded"
+	HeaderXRealIP       = "X-Real-IP"
+)
+
+type clientIPKey struct{}
+
+// ClientIP resolves the address of the client behind any trusted proxies and
+// stores it in the request context for the logger, rate limiter and
+// handlers.
+//
+// Forwarding headers are only read when the connection comes from a trusted
+// proxy, and only the one named by header, since a proxy that sets
+// X-Forwarded-For passes a client's own Forwarded header through untouched.
+// The hops in the header are walked from the right, skipping trusted
+// proxies, so entries a client prepends are never used.
+func ClientIP(trusted []netip.Prefix, header string) func(http.Handler) http.Handler {
+	resolver := &clientIPResolver{trusted: trusted, header: header}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), clientIPKey{}, resolver.resolve(r))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// ClientIPFromContext returns the resolved client address, or an empty
+// string when the ClientIP middleware has not run
+func ClientIPFromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey{}).(string)
+	return ip
+}
+
+// clientIPOf returns the resolved client address for r, falling back to the
+// connection's peer address
+func clientIPOf(r *http.Request) string {
+	if ip := ClientIPFromContext(r.Context()); ip != "" {
+		return ip
+	}
+	return remoteHost(r.RemoteAddr)
+}
+
+// clientKey returns the key an anonymous client is limited by: its
+// address, or for IPv6 the /64 network containing it, since a single host
+// is usually given a whole /64 and could otherwise rotate through it
+func clientKey(clientIP string) string {
+	addr, err := netip.ParseAddr(clientIP)
+	if err != nil {
+		return clientIP
+	}
+	addr = addr.Unmap()
+	if !addr.Is6() {
+		return addr.String()
+	}
+	prefix, err := addr.WithZone("").Prefix(64)
+	if err != nil {
+		return clientIP
+	}
+	return prefix.String()
+}
+
+type clientIPResolver struct {
+	trusted []netip.Prefix
+	header  string
+}
+
+func (c *clientIPResolver) resolve(r *http.Request) string {
+	peer, err := netip.ParseAddr(remoteHost(r.RemoteAddr))
+	if err != nil {
+		return remoteHost(r.RemoteAddr)
+	}
+	peer = peer.Unmap()
+	if !c.isTrusted(peer) {
+		return peer.String()
+	}
+
+	var hops []string
+	switch c.header {
+	case HeaderForwarded:
+		hops = parseForwarded(r.Header.Values(HeaderForwarded))
+	case HeaderXRealIP:
+		hops = r.Header.Values(HeaderXRealIP)
+	default:
+		hops = parseForwardedFor(r.Header.Values(HeaderXForwardedFor))
+	}
+
+	// Each proxy appends the address it received the request from, so the
+	// rightmost untrusted hop is the furthest one that can be believed
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := parseHop(hops[i])
+		if err != nil {
+			// Unknown or obfuscated hops end the chain we can verify
+			break
+		}
+		client = addr
+		if !c.isTrusted(addr) {
+			break
+		}
+	}
+	return client.String()
+}
+
+func (c *clientIPResolver) isTrusted(addr netip.Addr) bool {
+	for _, prefix := range c.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseForwardedFor splits X-Forwarded-For header values into hops
+func parseForwardedFor(values []string) []string {
+	var hops []string
+	for _, value := range values {
+		for _, hop := range strings.Split(value, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	return hops
+}
+
+// parseForwarded extracts the for= node of each element of RFC 7239
+// Forwarded header values. Elements without one are kept as "unknown" so
+// they still break the chain.
+func parseForwarded(values []string) []string {
+	var hops []string
+	for _, value := range values {
+		for _, element := range splitQuoted(value, ',') {
+			node := "unknown"
+			for _, pair := range splitQuoted(element, ';') {
+				name, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if ok && strings.EqualFold(name, "for") {
+					node = strings.Trim(strings.TrimSpace(val), `"`)
+				}
+			}
+			hops = append(hops, node)
+		}
+	}
+	return hops
+}
+
+// splitQuoted splits s on sep, ignoring separators inside quoted strings
+func splitQuoted(s string, sep byte) []string {
+	var parts []string
+	quoted := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quoted:
+			i++
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == sep && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// parseHop parses a forwarded address, which may carry a port and, for
+// IPv6, brackets: "192.0.2.1", "192.0.2.1:4711", "[2001:db8::1]:4711"
+func parseHop(hop string) (netip.Addr, error) {
+	hop = strings.TrimSpace(hop)
+	if addrPort, err := netip.ParseAddrPort(hop); err == nil {
+		return addrPort.Addr().Unmap(), nil
+	}
+	addr, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(hop, "["), "]"))
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	return addr.Unmap(), nil
+}
+
+// remoteHost strips the port from a RemoteAddr
+func remoteHost(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
@@ -0,0 +1,231 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"net/netip"
+	"slices"
+	"testing"
+)
+
+func TestClientIPResolve(t *testing.T) {
+	trusted := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("fd00::/8"),
+	}
+
+	tests := []struct {
+		name    string
+		header  string
+		peer    string
+		headers map[string][]string
+		want    string
+	}{
+		{
+			name:    "no proxy",
+			peer:    "198.51.100.7:52000",
+			headers: nil,
+			want:    "198.51.100.7",
+		},
+		{
+			name:    "untrusted peer sending headers",
+			peer:    "198.51.100.7:52000",
+			headers: map[string][]string{HeaderXForwardedFor: {"203.0.113.9"}, HeaderXRealIP: {"203.0.113.9"}},
+			want:    "198.51.100.7",
+		},
+		{
+			name:    "trusted proxy",
+			peer:    "10.0.0.2:443",
+			headers: map[string][]string{HeaderXForwardedFor: {"203.0.113.9"}},
+			want:    "203.0.113.9",
+		},
+		{
+			name:    "spoofed left-most entry",
+			peer:    "10.0.0.2:443",
+			headers: map[string][]string{HeaderXForwardedFor: {"1.2.3.4, 203.0.113.9"}},
+			want:    "203.0.113.9",
+		},
+		{
+			name:    "chain of trusted proxies",
+			peer:    "10.0.0.2:443",
+			headers: map[string][]string{HeaderXForwardedFor: {"1.2.3.4, 203.0.113.9, 10.0.0.3"}},
+			want:    "203.0.113.9",
+		},
+		{
+			name:    "multiple header lines",
+			peer:    "10.0.0.2:443",
+			headers: map[string][]string{HeaderXForwardedFor: {"1.2.3.4", "203.0.113.9, 10.0.0.3"}},
+			want:    "203.0.113.9",
+		},
+		{
+			name:    "garbage hop ends the chain",
+			peer:    "10.0.0.2:443",
+			headers: map[string][]string{HeaderXForwardedFor: {"203.0.113.9, not-an-ip"}},
+			want:    "10.0.0.2",
+		},
+		{
+			name:    "only trusted hops",
+			peer:    "10.0.0.2:443",
+			headers: map[string][]string{HeaderXForwardedFor: {"10.0.0.4, 10.0.0.3"}},
+			want:    "10.0.0.4",
+		},
+		{
+			name:    "other headers are ignored",
+			peer:    "10.0.0.2:443",
+			headers: map[string][]string{HeaderForwarded: {"for=203.0.113.9"}, HeaderXRealIP: {"203.0.113.9"}},
+			want:    "10.0.0.2",
+		},
+		{
+			name:    "IPv4-mapped peer",
+			peer:    "[::ffff:198.51.100.7]:52000",
+			headers: nil,
+			want:    "198.51.100.7",
+		},
+		{
+			name:    "IPv4-mapped peer is trusted as IPv4",
+			peer:    "[::ffff:10.0.0.2]:443",
+			headers: map[string][]string{HeaderXForwardedFor: {"::ffff:203.0.113.9"}},
+			want:    "203.0.113.9",
+		},
+		{
+			name:    "IPv6 proxy",
+			peer:    "[fd00::2]:443",
+			headers: map[string][]string{HeaderXForwardedFor: {"2001:db8::1"}},
+			want:    "2001:db8::1",
+		},
+		{
+			name:    "Forwarded with a quoted IPv6 node",
+			header:  HeaderForwarded,
+			peer:    "10.0.0.2:443",
+			headers: map[string][]string{HeaderForwarded: {`for=1.2.3.4, for="[2001:db8::1]:4711";proto=https`}},
+			want:    "2001:db8::1",
+		},
+		{
+			name:    "Forwarded unknown node ends the chain",
+			header:  HeaderForwarded,
+			peer:    "10.0.0.2:443",
+			headers: map[string][]string{HeaderForwarded: {"for=203.0.113.9, for=unknown"}},
+			want:    "10.0.0.2",
+		},
+		{
+			name:    "Forwarded obfuscated node ends the chain",
+			header:  HeaderForwarded,
+			peer:    "10.0.0.2:443",
+			headers: map[string][]string{HeaderForwarded: {"for=203.0.113.9, for=_hidden"}},
+			want:    "10.0.0.2",
+		},
+		{
+			name:    "Forwarded ignores X-Forwarded-For",
+			header:  HeaderForwarded,
+			peer:    "10.0.0.2:443",
+			headers: map[string][]string{HeaderForwarded: {"for=203.0.113.9"}, HeaderXForwardedFor: {"1.2.3.4"}},
+			want:    "203.0.113.9",
+		},
+		{
+			name:    "X-Real-IP",
+			header:  HeaderXRealIP,
+			peer:    "10.0.0.2:443",
+			headers: map[string][]string{HeaderXRealIP: {"203.0.113.9"}},
+			want:    "203.0.113.9",
+		},
+		{
+			name:    "unparseable peer",
+			peer:    "@",
+			headers: map[string][]string{HeaderXForwardedFor: {"203.0.113.9"}},
+			want:    "@",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver := &clientIPResolver{trusted: trusted, header: tt.header}
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.peer
+			for name, values := range tt.headers {
+				for _, value := range values {
+					r.Header.Add(name, value)
+				}
+			}
+			if got := resolver.resolve(r); got != tt.want {
+				t.Errorf("resolve = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseForwarded(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   []string
+	}{
+		{"single", []string{"for=192.0.2.60"}, []string{"192.0.2.60"}},
+		{"case insensitive", []string{"For=192.0.2.60;proto=http"}, []string{"192.0.2.60"}},
+		{"quoted IPv6 with port", []string{`for="[2001:db8::1]:4711"`}, []string{"[2001:db8::1]:4711"}},
+		{"several elements", []string{"for=192.0.2.43, for=198.51.100.17;by=203.0.113.60"}, []string{"192.0.2.43", "198.51.100.17"}},
+		{"several lines", []string{"for=192.0.2.43", "for=198.51.100.17"}, []string{"192.0.2.43", "198.51.100.17"}},
+		{"unknown", []string{"for=unknown"}, []string{"unknown"}},
+		{"obfuscated", []string{"for=_hidden, for=_SEVKISEK"}, []string{"_hidden", "_SEVKISEK"}},
+		{"element without for", []string{"proto=https;by=203.0.113.60"}, []string{"unknown"}},
+		{"separators inside quotes", []string{`for="192.0.2.1";host="a,b;c", for=192.0.2.2`}, []string{"192.0.2.1", "192.0.2.2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseForwarded(tt.values); !slices.Equal(got, tt.want) {
+				t.Errorf("parseForwarded(%q) = %q, want %q", tt.values, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseHop(t *testing.T) {
+	tests := []struct {
+		hop     string
+		want    string
+		wantErr bool
+	}{
+		{hop: "192.0.2.1", want: "192.0.2.1"},
+		{hop: " 192.0.2.1 ", want: "192.0.2.1"},
+		{hop: "192.0.2.1:4711", want: "192.0.2.1"},
+		{hop: "2001:db8::1", want: "2001:db8::1"},
+		{hop: "[2001:db8::1]", want: "2001:db8::1"},
+		{hop: "[2001:db8::1]:4711", want: "2001:db8::1"},
+		{hop: "::ffff:192.0.2.1", want: "192.0.2.1"},
+		{hop: "[::ffff:192.0.2.1]:4711", want: "192.0.2.1"},
+		{hop: "unknown", wantErr: true},
+		{hop: "_hidden", wantErr: true},
+		{hop: "", wantErr: true},
+		{hop: "192.0.2.1:port", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.hop, func(t *testing.T) {
+			got, err := parseHop(tt.hop)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseHop(%q) = %v, want an error", tt.hop, got)
+				}
+				return
+			}
+			if err != nil || got.String() != tt.want {
+				t.Errorf("parseHop(%q) = %v, %v; want %s", tt.hop, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientKey(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{"192.0.2.1", "192.0.2.1"},
+		{"::ffff:192.0.2.1", "192.0.2.1"},
+		{"2001:db8:1:2:3:4:5:6", "2001:db8:1:2::/64"},
+		{"2001:db8:1:2::ffff", "2001:db8:1:2::/64"},
+		{"fe80::1%eth0", "fe80::/64"},
+		{"not-an-ip", "not-an-ip"},
+	}
+	for _, tt := range tests {
+		if got := clientKey(tt.ip); got != tt.want {
+			t.Errorf("clientKey(%q) = %q, want %q", tt.ip, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/cdunlap/djaas/internal/model"
+)
+
+type ratingCeilingKey struct{}
+
+// RatingCeiling stores the maximum content rating the caller may receive in
+// the request context. Authenticated callers whose API key sets a ceiling get
+// that ceiling; everyone else gets the default. It must run after
+// Authenticate.
+func RatingCeiling(defaultMax model.ContentRating) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ceiling := defaultMax
+			if principal := PrincipalFromContext(r.Context()); principal != nil && principal.MaxRating != nil {
+				ceiling = *principal.MaxRating
+			}
+
+			ctx := context.WithValue(r.Context(), ratingCeilingKey{}, ceiling)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RatingCeilingFromContext returns the caller's rating ceiling, defaulting to
+// the most family-friendly rating when none was set
+func RatingCeilingFromContext(ctx context.Context) model.ContentRating {
+	if rating, ok := ctx.Value(ratingCeilingKey{}).(model.ContentRating); ok {
+		return rating
+	}
+	return model.RatingG
+}
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/cdunlap/djaas/internal/model"
+)
+
+// writeError writes an error response in the same shape as the handlers
+func writeError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(model.ErrorResponse{
+		Error:     code,
+		Message:   message,
+		RequestID: RequestIDFromContext(r.Context()),
+	})
+}
@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"container/list"
+	"context"
+	"hash/maphash"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// limiterShards is the number of independently locked partitions of a
+// LimiterStore. It must be a power of two.
+const limiterShards = 64
+
+// LimiterStore holds a token bucket per key (an IP address or principal)
+// with bounded memory. Keys are spread across shards, each with its own
+// lock and LRU list. A shard evicts its least recently used key when full,
+// and drops keys idle for longer than the TTL as it goes.
+type LimiterStore struct {
+	rate        rate.Limit
+	burst       int
+	ttl         time.Duration
+	maxPerShard int
+	seed        maphash.Seed
+	shards      [limiterShards]limiterShard
+	now         func() time.Time
+
+	evictions   atomic.Uint64
+	expirations atomic.Uint64
+}
+
+type limiterShard struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // front is most recently used
+}
+
+type limiterEntry struct {
+	key      string
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// LimiterStats reports the size and turnover of a LimiterStore
+type LimiterStats struct {
+	// Entries is the number of keys currently tracked
+	Entries int
+	// Evictions counts keys dropped because their shard was full
+	Evictions uint64
+	// Expirations counts keys dropped after being idle for the TTL
+	Expirations uint64
+}
+
+// NewLimiterStore creates a LimiterStore allowing requests per window for
+// each key and tracking at most maxEntries keys. Keys idle for a full window
+// have refilled their bucket, so dropping them loses nothing.
+func NewLimiterStore(requests int, window time.Duration, maxEntries int) *LimiterStore {
+	s := &LimiterStore{
+		rate:        rate.Every(window / time.Duration(requests)),
+		burst:       requests,
+		ttl:         window,
+		maxPerShard: max(maxEntries/limiterShards, 1),
+		seed:        maphash.MakeSeed(),
+		now:         time.Now,
+	}
+	for i := range s.shards {
+		s.shards[i].entries = make(map[string]*list.Element)
+		s.shards[i].lru = list.New()
+	}
+	return s
+}
+
+// Allow implements Store. It never fails.
+func (s *LimiterStore) Allow(_ context.Context, key string) (Decision, error) {
+	limiter := s.GetLimiter(key)
+	now := s.now()
+
+	// A reservation that would have to wait is handed back, and its delay
+	// is how long the caller should wait before retrying
+	reservation := limiter.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return Decision{
+			RetryAfter: delay,
+			Reset:      s.untilFull(limiter.TokensAt(now)),
+		}, nil
+	}
+
+	tokens := limiter.TokensAt(now)
+	return Decision{
+		Allowed:   true,
+		Remaining: max(int(tokens), 0),
+		Reset:     s.untilFull(tokens),
+	}, nil
+}
+
+// untilFull returns how long a bucket holding tokens takes to refill
+func (s *LimiterStore) untilFull(tokens float64) time.Duration {
+	missing := float64(s.burst) - tokens
+	if missing <= 0 {
+		return 0
+	}
+	return time.Duration(missing / float64(s.rate) * float64(time.Second))
+}
+
+// GetLimiter returns the rate limiter for key, creating it if needed
+func (s *LimiterStore) GetLimiter(key string) *rate.Limiter {
+	shard := &s.shards[maphash.String(s.seed, key)&(limiterShards-1)]
+	now := s.now()
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if elem, ok := shard.entries[key]; ok {
+		entry := elem.Value.(*limiterEntry)
+		entry.lastSeen = now
+		shard.lru.MoveToFront(elem)
+		return entry.limiter
+	}
+
+	// Idle entries collect at the back of the list; drop them before adding
+	for back := shard.lru.Back(); back != nil; back = shard.lru.Back() {
+		if now.Sub(back.Value.(*limiterEntry).lastSeen) < s.ttl {
+			break
+		}
+		shard.remove(back)
+		s.expirations.Add(1)
+	}
+	if shard.lru.Len() >= s.maxPerShard {
+		shard.remove(shard.lru.Back())
+		s.evictions.Add(1)
+	}
+
+	entry := &limiterEntry{
+		key:      key,
+		limiter:  rate.NewLimiter(s.rate, s.burst),
+		lastSeen: now,
+	}
+	shard.entries[key] = shard.lru.PushFront(entry)
+	return entry.limiter
+}
+
+// Stats returns the current number of keys and eviction counters
+func (s *LimiterStore) Stats() LimiterStats {
+	stats := LimiterStats{
+		Evictions:   s.evictions.Load(),
+		Expirations: s.expirations.Load(),
+	}
+	for i := range s.shards {
+		s.shards[i].mu.Lock()
+		stats.Entries += s.shards[i].lru.Len()
+		s.shards[i].mu.Unlock()
+	}
+	return stats
+}
+
+func (sh *limiterShard) remove(elem *list.Element) {
+	delete(sh.entries, elem.Value.(*limiterEntry).key)
+	sh.lru.Remove(elem)
+}
@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// newTestLimiterStore creates a LimiterStore whose clock is *now
+func newTestLimiterStore(requests int, window time.Duration, maxEntries int, now *time.Time) *LimiterStore {
+	store := NewLimiterStore(requests, window, maxEntries)
+	store.now = func() time.Time { return *now }
+	return store
+}
+
+func TestLimiterStoreAllow(t *testing.T) {
+	now := time.Date(2026, 3, 14, 15, 9, 26, 0, time.UTC)
+	// One request per second, in bursts of up to three
+	store := newTestLimiterStore(3, 3*time.Second, 100, &now)
+	ctx := context.Background()
+
+	for i, want := range []int{2, 1, 0} {
+		decision, err := store.Allow(ctx, "alice")
+		if err != nil {
+			t.Fatalf("Allow #%d: %v", i+1, err)
+		}
+		if !decision.Allowed || decision.Remaining != want {
+			t.Errorf("Allow #%d = %+v, want allowed with %d remaining", i+1, decision, want)
+		}
+	}
+
+	decision, _ := store.Allow(ctx, "alice")
+	if decision.Allowed || decision.RetryAfter != time.Second || decision.Reset != 3*time.Second {
+		t.Errorf("Allow after burst = %+v, want rejected, retry after 1s, reset in 3s", decision)
+	}
+
+	// A rejected request does not use up the next token
+	now = now.Add(time.Second)
+	if decision, _ := store.Allow(ctx, "alice"); !decision.Allowed {
+		t.Errorf("Allow after 1s = %+v, want allowed", decision)
+	}
+}
+
+func TestLimiterStoreBounded(t *testing.T) {
+	now := time.Date(2026, 3, 14, 15, 9, 26, 0, time.UTC)
+	// Two keys per shard
+	store := newTestLimiterStore(10, time.Minute, 2*limiterShards, &now)
+
+	const keys = 10000
+	var last *rate.Limiter
+	for i := range keys {
+		last = store.GetLimiter("key-" + strconv.Itoa(i))
+	}
+
+	stats := store.Stats()
+	if stats.Entries > 2*limiterShards {
+		t.Errorf("Entries = %d, want at most %d", stats.Entries, 2*limiterShards)
+	}
+	if stats.Evictions != uint64(keys-stats.Entries) {
+		t.Errorf("Evictions = %d, want %d (every key not tracked)", stats.Evictions, keys-stats.Entries)
+	}
+	if stats.Expirations != 0 {
+		t.Errorf("Expirations = %d, want 0", stats.Expirations)
+	}
+
+	// The most recently used key is still tracked
+	if store.GetLimiter("key-"+strconv.Itoa(keys-1)) != last {
+		t.Error("the most recently used key was evicted")
+	}
+}
+
+func TestLimiterStoreExpiresIdle(t *testing.T) {
+	start := time.Date(2026, 3, 14, 15, 9, 26, 0, time.UTC)
+	now := start
+	store := newTestLimiterStore(10, time.Minute, 100000, &now)
+
+	const idle = 100
+	for i := range idle {
+		store.GetLimiter("idle-" + strconv.Itoa(i))
+	}
+
+	// One key is used again before the others expire
+	now = start.Add(30 * time.Second)
+	kept := store.GetLimiter("idle-0")
+
+	// New keys in every shard sweep out those idle for a full window
+	now = start.Add(time.Minute)
+	const fresh = 1000
+	for i := range fresh {
+		store.GetLimiter("fresh-" + strconv.Itoa(i))
+	}
+
+	stats := store.Stats()
+	if stats.Expirations != idle-1 {
+		t.Errorf("Expirations = %d, want %d", stats.Expirations, idle-1)
+	}
+	if stats.Entries != fresh+1 {
+		t.Errorf("Entries = %d, want %d", stats.Entries, fresh+1)
+	}
+	if stats.Evictions != 0 {
+		t.Errorf("Evictions = %d, want 0", stats.Evictions)
+	}
+	if store.GetLimiter("idle-0") != kept {
+		t.Error("a key used within the window was dropped")
+	}
+}
+
+func TestLimiterStoreConcurrent(t *testing.T) {
+	// Tokens refill every 36 seconds, far longer than the test runs
+	const burst = 100
+	store := NewLimiterStore(burst, time.Hour, 16*limiterShards)
+	ctx := context.Background()
+
+	var allowed atomic.Int64
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Go(func() {
+			for i := range 2000 {
+				// Every goroutine shares one key, and churns through others
+				if decision, _ := store.Allow(ctx, "shared"); decision.Allowed {
+					allowed.Add(1)
+				}
+				store.Allow(ctx, "key-"+strconv.Itoa(g*2000+i))
+			}
+		})
+	}
+	wg.Wait()
+
+	if got := allowed.Load(); got != burst {
+		t.Errorf("shared key allowed %d requests, want %d", got, burst)
+	}
+	if stats := store.Stats(); stats.Entries > 16*limiterShards {
+		t.Errorf("Entries = %d, want at most %d", stats.Entries, 16*limiterShards)
+	}
+}
+
+func BenchmarkLimiterStoreAllow(b *testing.B) {
+	store := NewLimiterStore(1000, time.Minute, 10000)
+	ctx := context.Background()
+
+	keys := make([]string, 50000)
+	for i := range keys {
+		keys[i] = "key-" + strconv.Itoa(i)
+	}
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			store.Allow(ctx, keys[i%len(keys)])
+			i++
+		}
+	})
+}
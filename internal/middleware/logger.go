@@ -1,9 +1,12 @@
 package middleware
 
 import (
+	"context"
 	"log/slog"
 	"net/http"
 	"time"
+
+	"github.com/cdunlap/djaas/internal/logging"
 )
 
 // responseWriter wraps http.ResponseWriter to capture status code
@@ -36,6 +39,26 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 	return rw.ResponseWriter.Write(b)
 }
 
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to
+// flush event streams
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+type logFieldsKey struct{}
+
+// logFields collects values set by later middleware for the request log line
+type logFields struct {
+	principal string
+}
+
+// setLogPrincipal records the authenticated caller for the request log line
+func setLogPrincipal(ctx context.Context, subject string) {
+	if fields, ok := ctx.Value(logFieldsKey{}).(*logFields); ok {
+		fields.principal = subject
+	}
+}
+
 // Logger creates a logging middleware
 func Logger(logger *slog.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -46,19 +69,22 @@ func Logger(logger *slog.Logger) func(http.Handler) http.Handler {
 			wrapped := newResponseWriter(w)
 
 			// Call the next handler
-			next.ServeHTTP(wrapped, r)
+			fields := &logFields{}
+			next.ServeHTTP(wrapped, r.WithContext(context.WithValue(r.Context(), logFieldsKey{}, fields)))
 
 			// Log the request
 			duration := time.Since(start)
 
-			logger.Info("request completed",
+			logging.FromContext(r.Context(), logger).InfoContext(r.Context(), "request completed",
 				"method", r.Method,
 				"path", r.URL.Path,
 				"query", r.URL.RawQuery,
 				"status", wrapped.statusCode,
 				"duration_ms", duration.Milliseconds(),
 				"remote_addr", r.RemoteAddr,
+				"client_ip", ClientIPFromContext(r.Context()),
 				"user_agent", r.UserAgent(),
+				"principal", fields.principal,
 			)
 		})
 	}
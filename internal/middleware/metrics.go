@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RequestRecorder records completed requests, for metrics
+type RequestRecorder interface {
+	ObserveRequest(method, route string, status int, duration time.Duration)
+}
+
+// Metrics creates a middleware that reports each request to recorder,
+// labelled by the matched chi route pattern rather than the raw path so
+// that IDs in URLs do not create a series per joke. Requests that match no
+// route are reported as "unmatched".
+func Metrics(recorder RequestRecorder) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			wrapped := newResponseWriter(w)
+
+			next.ServeHTTP(wrapped, r)
+
+			// The pattern is only complete once routing has finished
+			route := "unmatched"
+			if rctx := chi.RouteContext(r.Context()); rctx != nil {
+				if pattern := rctx.RoutePattern(); pattern != "" {
+					route = pattern
+				}
+			}
+			recorder.ObserveRequest(r.Method, route, wrapped.statusCode, time.Since(start))
+		})
+	}
+}
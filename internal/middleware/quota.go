@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// QuotaTracker counts requests per caller per UTC day in memory
+type QuotaTracker struct {
+	mu   sync.Mutex
+	day  time.Time
+	used map[string]int
+	now  func() time.Time
+}
+
+// NewQuotaTracker creates an empty QuotaTracker
+func NewQuotaTracker() *QuotaTracker {
+	return &QuotaTracker{
+		used: make(map[string]int),
+		now:  time.Now,
+	}
+}
+
+// Take implements QuotaStore
+func (q *QuotaTracker) Take(_ context.Context, subject string, limit int) (int, bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.rollover()
+	if limit > 0 && q.used[subject] >= limit {
+		return q.used[subject], false, nil
+	}
+	q.used[subject]++
+	return q.used[subject], true, nil
+}
+
+// Used implements QuotaStore
+func (q *QuotaTracker) Used(_ context.Context, subject string) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.rollover()
+	return q.used[subject], nil
+}
+
+// ResetsAt implements QuotaStore
+func (q *QuotaTracker) ResetsAt() time.Time {
+	return startOfDay(q.now()).AddDate(0, 0, 1)
+}
+
+// rollover clears all counts when a new UTC day starts, which also keeps
+// the map from accumulating callers that have gone away
+func (q *QuotaTracker) rollover() {
+	today := startOfDay(q.now())
+	if !today.Equal(q.day) {
+		q.day = today
+		q.used = make(map[string]int)
+	}
+}
+
+func startOfDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
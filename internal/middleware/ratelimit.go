@@ -1,121 +1,359 @@
 package middleware
 
 import (
+	"context"
 	"fmt"
-	"net"
+	"log/slog"
 	"net/http"
 	"sync"
 	"time"
 
-	"golang.org/x/time/rate"
+	"github.com/cdunlap/djaas/internal/logging"
+	"github.com/cdunlap/djaas/internal/model"
 )
 
-// IPRateLimiter manages rate limiters for different IPs
-type IPRateLimiter struct {
-	limiters map[string]*rate.Limiter
-	mu       sync.RWMutex
-	rate     rate.Limit
-	burst    int
+// Tier is the rate limit applied to a class of callers
+type Tier struct {
+	Name     string
+	Requests int
+	Window   time.Duration
+	// DailyQuota caps requests per UTC day; 0 means unlimited
+	DailyQuota int
 }
 
-// NewIPRateLimiter creates a new IP rate limiter
-func NewIPRateLimiter(requests int, window time.Duration) *IPRateLimiter {
-	r := rate.Every(window / time.Duration(requests))
-	return &IPRateLimiter{
-		limiters: make(map[string]*rate.Limiter),
-		rate:     r,
-		burst:    requests,
-	}
+// Policy is the rate limit for a group of routes, such as writes or
+// search. On those routes it replaces the burst limit of the caller's tier,
+// with its own allowance per caller; daily quotas still apply.
+type Policy struct {
+	Name     string
+	Requests int
+	Window   time.Duration
+	// Unlimited exempts the routes from rate limits and quotas altogether
+	Unlimited bool
 }
 
-// GetLimiter returns the rate limiter for the given IP
-func (i *IPRateLimiter) GetLimiter(ip string) *rate.Limiter {
-	i.mu.Lock()
-	defer i.mu.Unlock()
-
-	limiter, exists := i.limiters[ip]
-	if !exists {
-		limiter = rate.NewLimiter(i.rate, i.burst)
-		i.limiters[ip] = limiter
-	}
+// RateLimiter limits anonymous callers per IP and authenticated callers per
+// API key or token subject, according to their tier and the policy of the
+// route they call
+type RateLimiter struct {
+	anonymous    Tier
+	anonStore    Store
+	tiers        map[string]Tier
+	tierStores   map[string]Store
+	defaultTier  string
+	policies     map[string]Policy
+	policyStores map[string]Store
+	quotas       QuotaStore
+	failOpen     bool
+	enforced     bool
 
-	return limiter
+	mu         sync.Mutex
+	rejections map[rejectionKey]uint64
 }
 
-// RateLimit creates a rate limiting middleware
-func RateLimit(requests int, window time.Duration) func(http.Handler) http.Handler {
-	limiter := NewIPRateLimiter(requests, window)
+type rejectionKey struct {
+	reason string
+	limit  string
+}
 
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			ip := getIP(r)
-			rateLimiter := limiter.GetLimiter(ip)
-
-			if !rateLimiter.Allow() {
-				w.Header().Set("Content-Type", "application/json")
-				w.Header().Set("X-RateLimit-Limit", fmt.Sprintf("%d", requests))
-				w.Header().Set("X-RateLimit-Window", window.String())
-				w.Header().Set("Retry-After", "60")
-				w.WriteHeader(http.StatusTooManyRequests)
-				w.Write([]byte(`{"error":"rate_limit_exceeded","message":"Too many requests, please try again later"}`))
-				return
-			}
+// Rejection counts requests rejected for one reason by one limit
+type Rejection struct {
+	// Reason is the error code sent: rate_limit_exceeded, quota_exceeded or
+	// rate_limit_unavailable
+	Reason string
+	// Limit is the tier or "route-" and the policy name whose limit was hit
+	Limit string
+	Count uint64
+}
 
-			// Add rate limit headers to successful requests
-			w.Header().Set("X-RateLimit-Limit", fmt.Sprintf("%d", requests))
-			w.Header().Set("X-RateLimit-Window", window.String())
+// NewRateLimiter creates a RateLimiter keeping its state in backend.
+// Authenticated callers whose tier is not in tiers are limited by
+// defaultTier, which must be present. When the backend fails, requests are
+// let through if failOpen is set and rejected otherwise.
+func NewRateLimiter(anonymous Tier, tiers []Tier, defaultTier string, policies []Policy, backend Backend, failOpen bool) *RateLimiter {
+	l := &RateLimiter{
+		anonymous:    anonymous,
+		anonStore:    backend.Store(anonymous),
+		tiers:        make(map[string]Tier, len(tiers)),
+		tierStores:   make(map[string]Store, len(tiers)),
+		defaultTier:  defaultTier,
+		policies:     make(map[string]Policy, len(policies)),
+		policyStores: make(map[string]Store, len(policies)),
+		quotas:       backend.Quotas(),
+		failOpen:     failOpen,
+		rejections:   make(map[rejectionKey]uint64),
+	}
+	for _, tier := range tiers {
+		l.tiers[tier.Name] = tier
+		l.tierStores[tier.Name] = backend.Store(tier)
+	}
+	for _, policy := range policies {
+		l.policies[policy.Name] = policy
+		if !policy.Unlimited {
+			// Prefixed so a policy cannot share state with a tier of the same name
+			l.policyStores[policy.Name] = backend.Store(Tier{
+				Name:     "route-" + policy.Name,
+				Requests: policy.Requests,
+				Window:   policy.Window,
+			})
+		}
+	}
+	return l
+}
 
-			next.ServeHTTP(w, r)
-		})
+// tierFor returns the tier that applies to a caller, with its store
+func (l *RateLimiter) tierFor(principal *model.Principal) (Tier, Store) {
+	if principal == nil {
+		return l.anonymous, l.anonStore
+	}
+	if tier, ok := l.tiers[principal.Tier]; ok {
+		return tier, l.tierStores[tier.Name]
 	}
+	return l.tiers[l.defaultTier], l.tierStores[l.defaultTier]
 }
 
-// getIP extracts the real IP address from the request
-func getIP(r *http.Request) string {
-	// Check X-Forwarded-For header (for requests behind a proxy)
-	forwarded := r.Header.Get("X-Forwarded-For")
-	if forwarded != "" {
-		// X-Forwarded-For can contain multiple IPs, take the first one
-		ips := parseForwardedFor(forwarded)
-		if len(ips) > 0 {
-			return ips[0]
+// Stats reports the size of each in-memory store, by tier name or
+// "route-" and the policy name. Stores kept elsewhere are not included.
+func (l *RateLimiter) Stats() map[string]LimiterStats {
+	stats := make(map[string]LimiterStats)
+	if store, ok := l.anonStore.(*LimiterStore); ok {
+		stats[l.anonymous.Name] = store.Stats()
+	}
+	for name, store := range l.tierStores {
+		if store, ok := store.(*LimiterStore); ok {
+			stats[name] = store.Stats()
+		}
+	}
+	for name, store := range l.policyStores {
+		if store, ok := store.(*LimiterStore); ok {
+			stats["route-"+name] = store.Stats()
 		}
 	}
+	return stats
+}
+
+// Rejections reports how many requests have been rejected, by reason and
+// limit
+func (l *RateLimiter) Rejections() []Rejection {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 
-	// Check X-Real-IP header
-	realIP := r.Header.Get("X-Real-IP")
-	if realIP != "" {
-		return realIP
+	rejections := make([]Rejection, 0, len(l.rejections))
+	for key, count := range l.rejections {
+		rejections = append(rejections, Rejection{Reason: key.reason, Limit: key.limit, Count: count})
 	}
+	return rejections
+}
+
+// reject counts a rejected request
+func (l *RateLimiter) reject(code, limit string) {
+	l.mu.Lock()
+	l.rejections[rejectionKey{reason: code, limit: limit}]++
+	l.mu.Unlock()
+}
 
-	// Fall back to RemoteAddr
-	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+// Usage reports the caller's limits and today's usage
+func (l *RateLimiter) Usage(ctx context.Context, principal *model.Principal) (model.Usage, error) {
+	tier, _ := l.tierFor(principal)
+	used, err := l.quotas.Used(ctx, principal.Subject)
 	if err != nil {
-		return r.RemoteAddr
+		return model.Usage{}, err
+	}
+
+	usage := model.Usage{
+		Subject:    principal.Subject,
+		Tier:       tier.Name,
+		Requests:   tier.Requests,
+		Window:     tier.Window.String(),
+		DailyQuota: tier.DailyQuota,
+		UsedToday:  used,
+		ResetsAt:   l.quotas.ResetsAt(),
+		Enforced:   l.enforced,
 	}
-	return ip
+	if tier.DailyQuota > 0 {
+		remaining := max(tier.DailyQuota-used, 0)
+		usage.RemainingToday = &remaining
+	}
+	return usage, nil
 }
 
-// parseForwardedFor parses the X-Forwarded-For header
-func parseForwardedFor(header string) []string {
-	var ips []string
-	for i := 0; i < len(header); {
-		// Skip spaces
-		for i < len(header) && header[i] == ' ' {
-			i++
+// Verdict is the outcome of checking a request against the caller's rate
+// limit and daily quota
+type Verdict struct {
+	// Allowed reports whether the request may proceed
+	Allowed bool
+	// Exempt is set when the request's policy is unlimited; no other
+	// fields are set
+	Exempt bool
+
+	// Requests and Window are the burst limit applied, and Policy its
+	// RateLimit-Policy header value, including any daily quota
+	Requests int
+	Window   time.Duration
+	Policy   string
+
+	// Limit, Remaining and Reset describe whichever of the burst limit and
+	// daily quota is closer to running out. Limit is 0 when neither could
+	// be checked.
+	Limit     int
+	Remaining int
+	Reset     time.Duration
+
+	// Reason is the error code of a rejected request: rate_limit_exceeded,
+	// quota_exceeded or rate_limit_unavailable
+	Reason  string
+	Message string
+	// RetryAfter is how long a caller rejected with 429 must wait
+	RetryAfter time.Duration
+}
+
+// Check decides whether a request governed by the named policy may
+// proceed, consuming from the caller's allowance if so. Anonymous callers,
+// with a nil principal, are limited by clientIP, or by its /64 network for
+// IPv6. Rejections are counted,
+// and backend failures are logged and handled according to the fail open
+// setting.
+func (l *RateLimiter) Check(ctx context.Context, logger *slog.Logger, principal *model.Principal, clientIP, policyName string) Verdict {
+	tier, store := l.tierFor(principal)
+	v := Verdict{Requests: tier.Requests, Window: tier.Window}
+	limitName := tier.Name
+
+	if policy, ok := l.policies[policyName]; ok {
+		if policy.Unlimited {
+			return Verdict{Allowed: true, Exempt: true}
+		}
+		store = l.policyStores[policy.Name]
+		v.Requests, v.Window, limitName = policy.Requests, policy.Window, "route-"+policy.Name
+	}
+
+	key := clientKey(clientIP)
+	if principal != nil {
+		key = principal.Subject
+	}
+
+	v.Policy = fmt.Sprintf("%d;w=%d", v.Requests, ceilSeconds(v.Window))
+	if principal != nil && tier.DailyQuota > 0 {
+		v.Policy += fmt.Sprintf(", %d;w=%d", tier.DailyQuota, ceilSeconds(24*time.Hour))
+	}
+
+	decision, err := store.Allow(ctx, key)
+	if err != nil {
+		if !l.unavailable(ctx, logger, limitName, err, &v) {
+			return v
+		}
+	} else {
+		v.Limit, v.Remaining, v.Reset = v.Requests, decision.Remaining, decision.Reset
+		if !decision.Allowed {
+			return l.rejected(v, "rate_limit_exceeded", limitName, "Too many requests, please try again later", decision.RetryAfter)
 		}
-		// Find the end of the IP
-		start := i
-		for i < len(header) && header[i] != ',' {
-			i++
+	}
+
+	if principal == nil {
+		v.Allowed = true
+		return v
+	}
+
+	used, taken, err := l.quotas.Take(ctx, principal.Subject, tier.DailyQuota)
+	if err != nil {
+		if !l.unavailable(ctx, logger, tier.Name, err, &v) {
+			return v
 		}
-		if start < i {
-			ips = append(ips, header[start:i])
+	} else if tier.DailyQuota > 0 {
+		remaining := max(tier.DailyQuota-used, 0)
+		untilReset := time.Until(l.quotas.ResetsAt())
+		if v.Limit == 0 || !taken || remaining < v.Remaining {
+			v.Limit, v.Remaining, v.Reset = tier.DailyQuota, remaining, untilReset
 		}
-		// Skip the comma
-		if i < len(header) && header[i] == ',' {
-			i++
+		if !taken {
+			return l.rejected(v, "quota_exceeded", tier.Name, "Daily quota exceeded, see /api/v1/usage", untilReset)
 		}
 	}
-	return ips
+
+	v.Allowed = true
+	return v
+}
+
+// rejected counts a request rejected with 429 and completes its verdict
+func (l *RateLimiter) rejected(v Verdict, code, limit, message string, retryAfter time.Duration) Verdict {
+	l.reject(code, limit)
+	v.Reason, v.Message = code, message
+	v.RetryAfter = max(retryAfter, time.Second)
+	return v
+}
+
+// unavailable handles a backend failure according to the fail open setting,
+// and reports whether the request may continue. If not, v is marked
+// rejected.
+func (l *RateLimiter) unavailable(ctx context.Context, logger *slog.Logger, limit string, err error, v *Verdict) bool {
+	logger = logging.FromContext(ctx, logger)
+	if l.failOpen {
+		logger.WarnContext(ctx, "rate limit backend unavailable, allowing request", "error", err)
+		return true
+	}
+	logger.ErrorContext(ctx, "rate limit backend unavailable, rejecting request", "error", err)
+	l.reject("rate_limit_unavailable", limit)
+	v.Reason, v.Message = "rate_limit_unavailable", "Rate limits could not be checked, please try again later"
+	return false
+}
+
+// RateLimit creates a rate limiting middleware for routes governed by the
+// named policy. Anonymous callers are limited per IP; authenticated callers
+// are limited per principal by their tier's daily quota and by the policy,
+// or their tier's burst limit when no policy of that name is configured. It
+// must run after Authenticate.
+//
+// Responses carry the RateLimit-Policy, RateLimit-Limit,
+// RateLimit-Remaining and RateLimit-Reset headers of the IETF draft
+// (draft-ietf-httpapi-ratelimit-headers), describing whichever of the burst
+// limit and daily quota is closer to running out. The older
+// X-RateLimit-Limit and X-RateLimit-Window headers are still sent.
+func RateLimit(logger *slog.Logger, limiter *RateLimiter, policy string) func(http.Handler) http.Handler {
+	return RateLimitFunc(logger, limiter, func(*http.Request) string { return policy })
+}
+
+// RateLimitFunc is like RateLimit, but chooses the policy for each request,
+// for routes whose cost depends on their parameters
+func RateLimitFunc(logger *slog.Logger, limiter *RateLimiter, policyFor func(*http.Request) string) func(http.Handler) http.Handler {
+	limiter.enforced = true
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			v := limiter.Check(r.Context(), logger, PrincipalFromContext(r.Context()), clientIPOf(r), policyFor(r))
+			if v.Exempt {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("X-RateLimit-Limit", fmt.Sprintf("%d", v.Requests))
+			w.Header().Set("X-RateLimit-Window", v.Window.String())
+			w.Header().Set("RateLimit-Policy", v.Policy)
+			if v.Limit > 0 {
+				setRateLimitHeaders(w, v.Limit, v.Remaining, v.Reset)
+			}
+
+			if !v.Allowed {
+				status := http.StatusServiceUnavailable
+				if v.RetryAfter > 0 {
+					status = http.StatusTooManyRequests
+					w.Header().Set("Retry-After", fmt.Sprintf("%d", ceilSeconds(v.RetryAfter)))
+				}
+				writeError(w, r, status, v.Reason, v.Message)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// setRateLimitHeaders describes the limit closest to running out
+func setRateLimitHeaders(w http.ResponseWriter, limit, remaining int, reset time.Duration) {
+	w.Header().Set("RateLimit-Limit", fmt.Sprintf("%d", limit))
+	w.Header().Set("RateLimit-Remaining", fmt.Sprintf("%d", remaining))
+	w.Header().Set("RateLimit-Reset", fmt.Sprintf("%d", ceilSeconds(reset)))
+}
+
+// ceilSeconds rounds d up to whole seconds, as the headers require
+func ceilSeconds(d time.Duration) int64 {
+	return int64((d + time.Second - 1) / time.Second)
 }
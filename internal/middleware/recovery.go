@@ -4,6 +4,8 @@ import (
 	"log/slog"
 	"net/http"
 	"runtime/debug"
+
+	"github.com/cdunlap/djaas/internal/logging"
 )
 
 // Recovery creates a panic recovery middleware
@@ -13,7 +15,7 @@ func Recovery(logger *slog.Logger) func(http.Handler) http.Handler {
 			defer func() {
 				if err := recover(); err != nil {
 					// Log the panic with stack trace
-					logger.Error("panic recovered",
+					logging.FromContext(r.Context(), logger).ErrorContext(r.Context(), "panic recovered",
 						"error", err,
 						"path", r.URL.Path,
 						"method", r.Method,
@@ -21,9 +23,7 @@ func Recovery(logger *slog.Logger) func(http.Handler) http.Handler {
 					)
 
 					// Return 500 Internal Server Error
-					w.Header().Set("Content-Type", "application/json")
-					w.WriteHeader(http.StatusInternalServerError)
-					w.Write([]byte(`{"error":"internal_error","message":"An internal error occurred"}`))
+					writeError(w, r, http.StatusInternalServerError, "internal_error", "An internal error occurred")
 				}
 			}()
 
@@ -0,0 +1,178 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// gcraScript applies the generic cell rate algorithm to one key. The key
+// holds the theoretical arrival time (TAT) of the next request in
+// microseconds; a request is allowed when that time is no more than the
+// burst tolerance ahead of now. The server clock is used so replicas with
+// skewed clocks agree.
+//
+// KEYS[1]: the caller's key
+// ARGV[1]: emission interval in microseconds (window / requests)
+// ARGV[2]: burst size (requests)
+//
+// Returns {allowed (1 or 0), remaining, retry after, reset}, with times in
+// microseconds.
+var gcraScript = redis.NewScript(`
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local interval = tonumber(ARGV[1])
+local tolerance = interval * tonumber(ARGV[2])
+
+local tat = tonumber(redis.call("GET", KEYS[1])) or now
+if tat < now then
+  tat = now
+end
+
+local new_tat = tat + interval
+if new_tat - now > tolerance then
+  return {0, 0, new_tat - tolerance - now, tat - now}
+end
+
+redis.call("SET", KEYS[1], string.format("%.0f", new_tat), "PX", math.ceil((new_tat - now) / 1000))
+return {1, math.floor((tolerance - (new_tat - now)) / interval), 0, new_tat - now}
+`)
+
+// quotaScript counts a request against a daily quota if any is left.
+//
+// KEYS[1]: the caller's counter for the day
+// ARGV[1]: the quota, or 0 for unlimited
+// ARGV[2]: unix time at which the counter expires
+//
+// Returns {counted (1 or 0), requests made today}.
+var quotaScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local used = tonumber(redis.call("GET", KEYS[1]) or "0")
+if limit > 0 and used >= limit then
+  return {0, used}
+end
+
+redis.call("INCR", KEYS[1])
+if used == 0 then
+  redis.call("EXPIREAT", KEYS[1], ARGV[2])
+end
+return {1, used + 1}
+`)
+
+// RedisBackend keeps rate limit state in Redis, or any server speaking its
+// protocol with Lua scripting, so that replicas share one set of limits
+type RedisBackend struct {
+	client  redis.UniversalClient
+	prefix  string
+	timeout time.Duration
+}
+
+// NewRedisBackend creates a RedisBackend. Keys are namespaced with prefix
+// so one server can be shared with other applications. Each check,
+// including any retries, gives up after timeout.
+func NewRedisBackend(client redis.UniversalClient, prefix string, timeout time.Duration) *RedisBackend {
+	return &RedisBackend{client: client, prefix: prefix, timeout: timeout}
+}
+
+// Store implements Backend
+func (b *RedisBackend) Store(tier Tier) Store {
+	return &RedisStore{
+		client:   b.client,
+		prefix:   b.prefix + "ratelimit:" + tier.Name + ":",
+		timeout:  b.timeout,
+		interval: tier.Window / time.Duration(tier.Requests),
+		burst:    tier.Requests,
+	}
+}
+
+// Quotas implements Backend
+func (b *RedisBackend) Quotas() QuotaStore {
+	return &RedisQuotas{
+		client:  b.client,
+		prefix:  b.prefix + "quota:",
+		timeout: b.timeout,
+		now:     time.Now,
+	}
+}
+
+// RedisStore is a Store for one tier, limiting callers with GCRA
+type RedisStore struct {
+	client   redis.UniversalClient
+	prefix   string
+	timeout  time.Duration
+	interval time.Duration
+	burst    int
+}
+
+// Allow implements Store
+func (s *RedisStore) Allow(ctx context.Context, key string) (Decision, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	result, err := gcraScript.Run(ctx, s.client, []string{s.prefix + key}, s.interval.Microseconds(), s.burst).Int64Slice()
+	if err != nil {
+		return Decision{}, fmt.Errorf("failed to check rate limit: %w", err)
+	}
+	if len(result) != 4 {
+		return Decision{}, fmt.Errorf("failed to check rate limit: unexpected script result %v", result)
+	}
+
+	return Decision{
+		Allowed:    result[0] == 1,
+		Remaining:  int(result[1]),
+		RetryAfter: time.Duration(result[2]) * time.Microsecond,
+		Reset:      time.Duration(result[3]) * time.Microsecond,
+	}, nil
+}
+
+// RedisQuotas is a QuotaStore keeping one counter per caller per UTC day
+type RedisQuotas struct {
+	client  redis.UniversalClient
+	prefix  string
+	timeout time.Duration
+	now     func() time.Time
+}
+
+// Take implements QuotaStore
+func (q *RedisQuotas) Take(ctx context.Context, subject string, limit int) (int, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, q.timeout)
+	defer cancel()
+
+	// Keep counters a little past midnight so late replicas still see them
+	expiresAt := q.ResetsAt().Add(time.Hour).Unix()
+	result, err := quotaScript.Run(ctx, q.client, []string{q.key(subject)}, limit, expiresAt).Int64Slice()
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to check quota: %w", err)
+	}
+	if len(result) != 2 {
+		return 0, false, fmt.Errorf("failed to check quota: unexpected script result %v", result)
+	}
+	return int(result[1]), result[0] == 1, nil
+}
+
+// Used implements QuotaStore
+func (q *RedisQuotas) Used(ctx context.Context, subject string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, q.timeout)
+	defer cancel()
+
+	used, err := q.client.Get(ctx, q.key(subject)).Int()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read quota usage: %w", err)
+	}
+	return used, nil
+}
+
+// ResetsAt implements QuotaStore
+func (q *RedisQuotas) ResetsAt() time.Time {
+	return startOfDay(q.now()).AddDate(0, 0, 1)
+}
+
+func (q *RedisQuotas) key(subject string) string {
+	return q.prefix + startOfDay(q.now()).Format("2006-01-02") + ":" + subject
+}
@@ -0,0 +1,235 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/cdunlap/djaas/internal/model"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestRedis starts an in-process Redis server with a fixed clock and
+// returns a backend using it
+func newTestRedis(t *testing.T, now time.Time) (*miniredis.Miniredis, *RedisBackend) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	server.SetTime(now)
+
+	client := redis.NewClient(&redis.Options{Addr: server.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { client.Close() })
+
+	return server, NewRedisBackend(client, "djaas:", time.Second)
+}
+
+func TestRedisStoreGCRA(t *testing.T) {
+	now := time.Date(2026, 3, 14, 15, 9, 26, 0, time.UTC)
+	server, backend := newTestRedis(t, now)
+	// One request per second, in bursts of up to three
+	store := backend.Store(Tier{Name: "basic", Requests: 3, Window: 3 * time.Second})
+	ctx := context.Background()
+
+	for i, want := range []int{2, 1, 0} {
+		decision, err := store.Allow(ctx, "alice")
+		if err != nil {
+			t.Fatalf("Allow #%d: %v", i+1, err)
+		}
+		if !decision.Allowed || decision.Remaining != want {
+			t.Errorf("Allow #%d = %+v, want allowed with %d remaining", i+1, decision, want)
+		}
+		if wantReset := time.Duration(i+1) * time.Second; decision.Reset != wantReset {
+			t.Errorf("Allow #%d: Reset = %v, want %v", i+1, decision.Reset, wantReset)
+		}
+	}
+
+	decision, err := store.Allow(ctx, "alice")
+	if err != nil {
+		t.Fatalf("Allow: %v", err)
+	}
+	if decision.Allowed || decision.RetryAfter != time.Second || decision.Reset != 3*time.Second {
+		t.Errorf("Allow after burst = %+v, want rejected, retry after 1s, reset in 3s", decision)
+	}
+
+	// Other callers have their own allowance
+	if decision, err := store.Allow(ctx, "bob"); err != nil || !decision.Allowed {
+		t.Errorf("Allow(bob) = %+v, %v; want allowed", decision, err)
+	}
+
+	// One interval later, one request is allowed again
+	server.SetTime(now.Add(time.Second))
+	decision, err = store.Allow(ctx, "alice")
+	if err != nil {
+		t.Fatalf("Allow: %v", err)
+	}
+	if !decision.Allowed || decision.Remaining != 0 {
+		t.Errorf("Allow after 1s = %+v, want allowed with 0 remaining", decision)
+	}
+
+	key := "djaas:ratelimit:basic:alice"
+	if !server.Exists(key) {
+		t.Fatalf("key %q not set", key)
+	}
+	if ttl := server.TTL(key); ttl != 3*time.Second {
+		t.Errorf("TTL = %v, want 3s (until the burst is replenished)", ttl)
+	}
+}
+
+func TestRedisQuotas(t *testing.T) {
+	now := time.Date(2026, 3, 14, 15, 9, 26, 0, time.UTC)
+	server, backend := newTestRedis(t, now)
+	quotas := backend.Quotas().(*RedisQuotas)
+	quotas.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i, want := range []struct {
+		used int
+		ok   bool
+	}{{1, true}, {2, true}, {2, false}} {
+		used, ok, err := quotas.Take(ctx, "key:1", 2)
+		if err != nil {
+			t.Fatalf("Take #%d: %v", i+1, err)
+		}
+		if used != want.used || ok != want.ok {
+			t.Errorf("Take #%d = %d, %t; want %d, %t", i+1, used, ok, want.used, want.ok)
+		}
+	}
+
+	if used, err := quotas.Used(ctx, "key:1"); err != nil || used != 2 {
+		t.Errorf("Used = %d, %v; want 2", used, err)
+	}
+	if used, err := quotas.Used(ctx, "key:2"); err != nil || used != 0 {
+		t.Errorf("Used by another caller = %d, %v; want 0", used, err)
+	}
+
+	// A limit of 0 counts requests without capping them
+	for range 3 {
+		if _, ok, err := quotas.Take(ctx, "key:2", 0); err != nil || !ok {
+			t.Fatalf("Take unlimited = %t, %v; want ok", ok, err)
+		}
+	}
+	if used, err := quotas.Used(ctx, "key:2"); err != nil || used != 3 {
+		t.Errorf("Used unlimited = %d, %v; want 3", used, err)
+	}
+
+	// Counters outlive midnight by an hour
+	key := "djaas:quota:2026-03-14:key:1"
+	wantTTL := time.Date(2026, 3, 15, 1, 0, 0, 0, time.UTC).Sub(now)
+	if ttl := server.TTL(key); ttl != wantTTL {
+		t.Errorf("TTL = %v, want %v", ttl, wantTTL)
+	}
+
+	// A new day starts a new counter
+	quotas.now = func() time.Time { return now.AddDate(0, 0, 1) }
+	if used, ok, err := quotas.Take(ctx, "key:1", 2); err != nil || used != 1 || !ok {
+		t.Errorf("Take next day = %d, %t, %v; want 1, true", used, ok, err)
+	}
+}
+
+// serveLimited sends a request through RateLimit, as principal if not nil
+func serveLimited(limiter *RateLimiter, principal *model.Principal) *httptest.ResponseRecorder {
+	handler := RateLimit(slog.New(slog.DiscardHandler), limiter, "read")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/tags", nil)
+	if principal != nil {
+		r = r.WithContext(context.WithValue(r.Context(), principalKey{}, principal))
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func errorCode(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var body model.ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("decode error response: %v", err)
+	}
+	return body.Error
+}
+
+func TestRateLimitRedisBackend(t *testing.T) {
+	_, backend := newTestRedis(t, time.Now())
+	limiter := NewRateLimiter(
+		Tier{Name: "anonymous", Requests: 1, Window: time.Minute},
+		[]Tier{{Name: "basic", Requests: 10, Window: time.Minute, DailyQuota: 1}},
+		"basic", nil, backend, false,
+	)
+
+	if w := serveLimited(limiter, nil); w.Code != http.StatusOK {
+		t.Fatalf("first anonymous request: status %d, want 200", w.Code)
+	}
+	w := serveLimited(limiter, nil)
+	if w.Code != http.StatusTooManyRequests || errorCode(t, w) != "rate_limit_exceeded" {
+		t.Errorf("second anonymous request: status %d, want 429 rate_limit_exceeded", w.Code)
+	}
+
+	principal := &model.Principal{Subject: "key:1", Tier: "basic"}
+	if w := serveLimited(limiter, principal); w.Code != http.StatusOK {
+		t.Fatalf("first authenticated request: status %d, want 200", w.Code)
+	}
+	w = serveLimited(limiter, principal)
+	if w.Code != http.StatusTooManyRequests || errorCode(t, w) != "quota_exceeded" {
+		t.Errorf("request over quota: status %d, want 429 quota_exceeded", w.Code)
+	}
+}
+
+func TestRateLimitRedisUnavailable(t *testing.T) {
+	principal := &model.Principal{Subject: "key:1", Tier: "basic"}
+
+	tests := []struct {
+		name      string
+		failOpen  bool
+		principal *model.Principal
+		want      int
+	}{
+		{"fail closed, anonymous", false, nil, http.StatusServiceUnavailable},
+		{"fail closed, authenticated", false, principal, http.StatusServiceUnavailable},
+		{"fail open, anonymous", true, nil, http.StatusOK},
+		{"fail open, authenticated", true, principal, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, backend := newTestRedis(t, time.Now())
+			limiter := NewRateLimiter(
+				Tier{Name: "anonymous", Requests: 10, Window: time.Minute},
+				[]Tier{{Name: "basic", Requests: 10, Window: time.Minute, DailyQuota: 100}},
+				"basic", nil, backend, tt.failOpen,
+			)
+			server.SetError("LOADING Redis is loading the dataset in memory")
+
+			w := serveLimited(limiter, tt.principal)
+			if w.Code != tt.want {
+				t.Fatalf("status %d, want %d", w.Code, tt.want)
+			}
+			if tt.want == http.StatusServiceUnavailable {
+				if code := errorCode(t, w); code != "rate_limit_unavailable" {
+					t.Errorf("code = %q, want rate_limit_unavailable", code)
+				}
+				if rejections := limiter.Rejections(); len(rejections) != 1 || rejections[0].Reason != "rate_limit_unavailable" {
+					t.Errorf("Rejections = %+v, want one rate_limit_unavailable", rejections)
+				}
+			}
+		})
+	}
+}
+
+// An unreachable server is reported as an error rather than a decision
+func TestRedisStoreUnreachable(t *testing.T) {
+	server, backend := newTestRedis(t, time.Now())
+	server.Close()
+
+	if _, err := backend.Store(Tier{Name: "basic", Requests: 1, Window: time.Second}).Allow(context.Background(), "alice"); err == nil {
+		t.Error("Allow succeeded, want an error")
+	}
+	if _, _, err := backend.Quotas().Take(context.Background(), "key:1", 1); err == nil {
+		t.Error("Take succeeded, want an error")
+	}
+}
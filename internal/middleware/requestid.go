@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"log/slog"
+	"net/http"
+
+	"github.com/cdunlap/djaas/internal/logging"
+)
+
+// HeaderRequestID carries the request ID in both directions
+const HeaderRequestID = "X-Request-ID"
+
+// maxRequestIDLength bounds the IDs accepted from callers
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// RequestID creates a middleware that identifies each request. A caller's
+// X-Request-ID is kept if it is a plausible ID, so requests can be followed
+// across services; otherwise a new one is generated. The ID is returned in
+// the response, included in error bodies, and added to a request logger
+// stored in the context (see logging.FromContext).
+func RequestID(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(HeaderRequestID)
+			if !ValidRequestID(id) {
+				id = rand.Text()
+			}
+			w.Header().Set(HeaderRequestID, id)
+
+			ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+			ctx = logging.NewContext(ctx, logger.With("request_id", id))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequestIDFromContext returns the ID assigned by RequestID, or an empty
+// string if the middleware has not run
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// ValidRequestID reports whether id is safe to log and echo back: short,
+// and made only of letters, digits and common separators
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case c == '-' || c == '_' || c == '.' || c == ':' || c == '/' || c == '+' || c == '=':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"context"
+	"time"
+)
+
+// Store holds the rate limit state for the callers of one tier
+type Store interface {
+	// Allow decides whether a request for key may proceed, consuming from
+	// its allowance if so
+	Allow(ctx context.Context, key string) (Decision, error)
+}
+
+// Decision is a Store's verdict on a single request
+type Decision struct {
+	Allowed bool
+	// Remaining is how many more requests would be allowed right now
+	Remaining int
+	// RetryAfter is how long a rejected caller must wait for a request to
+	// be allowed
+	RetryAfter time.Duration
+	// Reset is how long until the caller's full burst is available again
+	Reset time.Duration
+}
+
+// QuotaStore counts requests against daily quotas
+type QuotaStore interface {
+	// Take records a request for subject if it has quota left, and reports
+	// whether it did along with the number of requests made today. A limit
+	// of 0 means unlimited.
+	Take(ctx context.Context, subject string, limit int) (used int, ok bool, err error)
+	// Used returns the number of requests subject has made today
+	Used(ctx context.Context, subject string) (int, error)
+	// ResetsAt returns when the current day's quotas reset
+	ResetsAt() time.Time
+}
+
+// Backend creates the stores a RateLimiter keeps its state in
+type Backend interface {
+	Store(tier Tier) Store
+	Quotas() QuotaStore
+}
+
+// MemoryBackend keeps rate limit state in process. Each replica enforces
+// the configured limits on its own.
+type MemoryBackend struct {
+	// MaxEntries bounds the number of callers tracked per tier
+	MaxEntries int
+}
+
+// Store implements Backend
+func (b MemoryBackend) Store(tier Tier) Store {
+	return NewLimiterStore(tier.Requests, tier.Window, b.MaxEntries)
+}
+
+// Quotas implements Backend
+func (b MemoryBackend) Quotas() QuotaStore {
+	return NewQuotaTracker()
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing creates a middleware that starts a server span for each request,
+// continuing the trace in the caller's traceparent header if there is one.
+// The span is named after the matched chi route pattern once routing has
+// finished, like the request metrics.
+func Tracing() func(http.Handler) http.Handler {
+	tracer := otel.Tracer("github.com/cdunlap/djaas/internal/middleware")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := tracer.Start(ctx, r.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("http.request.method", r.Method),
+					attribute.String("url.path", r.URL.Path),
+					attribute.String("client.address", ClientIPFromContext(r.Context())),
+					attribute.String("user_agent.original", r.UserAgent()),
+				),
+			)
+			defer span.End()
+
+			wrapped := newResponseWriter(w)
+			next.ServeHTTP(wrapped, r.WithContext(ctx))
+
+			route := "unmatched"
+			if rctx := chi.RouteContext(r.Context()); rctx != nil {
+				if pattern := rctx.RoutePattern(); pattern != "" {
+					route = pattern
+					span.SetAttributes(attribute.String("http.route", pattern))
+				}
+			}
+			span.SetName(r.Method + " " + route)
+			span.SetAttributes(attribute.Int("http.response.status_code", wrapped.statusCode))
+			// Client errors are the caller's problem, not the server's
+			if wrapped.statusCode >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(wrapped.statusCode))
+			}
+		})
+	}
+}
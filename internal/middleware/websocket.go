@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Browsers cannot set headers on WebSocket requests, so they offer their
+// credentials as subprotocols instead, alongside the real one:
+//
+//	new WebSocket(url, ["djaas.v1", "djaas.apikey." + key])
+//	new WebSocket(url, ["djaas.v1", "djaas.bearer." + jwt])
+const (
+	WebSocketAPIKeyProtocol = "djaas.apikey."
+	WebSocketBearerProtocol = "djaas.bearer."
+)
+
+// WebSocketCredentials moves credentials offered as subprotocols on a
+// WebSocket upgrade into the X-API-Token or Authorization header, so
+// Authenticate treats them like any other request. The credential
+// subprotocols are removed so they are never echoed back. It must run
+// before Authenticate.
+func WebSocketCredentials() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			offered := r.Header.Values("Sec-WebSocket-Protocol")
+			if len(offered) == 0 || !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var protocols []string
+			for _, value := range offered {
+				for _, protocol := range strings.Split(value, ",") {
+					protocol = strings.TrimSpace(protocol)
+					if key, ok := strings.CutPrefix(protocol, WebSocketAPIKeyProtocol); ok {
+						if r.Header.Get("X-API-Token") == "" {
+							r.Header.Set("X-API-Token", key)
+						}
+						continue
+					}
+					if token, ok := strings.CutPrefix(protocol, WebSocketBearerProtocol); ok {
+						if r.Header.Get("Authorization") == "" {
+							r.Header.Set("Authorization", "Bearer "+token)
+						}
+						continue
+					}
+					if protocol != "" {
+						protocols = append(protocols, protocol)
+					}
+				}
+			}
+
+			r.Header.Del("Sec-WebSocket-Protocol")
+			if len(protocols) > 0 {
+				r.Header.Set("Sec-WebSocket-Protocol", strings.Join(protocols, ", "))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
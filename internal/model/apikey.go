@@ -0,0 +1,91 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Scope grants an API key permission to perform a class of operations
+type Scope string
+
+const (
+	ScopeJokesWrite Scope = "jokes:write"
+	ScopeTagsAdmin  Scope = "tags:admin"
+	ScopeModerate   Scope = "moderate"
+)
+
+// Scopes lists every valid scope
+var Scopes = []Scope{ScopeJokesWrite, ScopeTagsAdmin, ScopeModerate}
+
+// ParseScope parses a scope name such as "jokes:write"
+func ParseScope(s string) (Scope, error) {
+	normalized := Scope(strings.ToLower(strings.TrimSpace(s)))
+	if slices.Contains(Scopes, normalized) {
+		return normalized, nil
+	}
+	return "", fmt.Errorf("unknown scope %q", s)
+}
+
+// APIKey describes a stored API key. The key itself is only shown once, when
+// it is created; afterwards it is identified by its prefix.
+type APIKey struct {
+	ID         int32          `json:"id"`
+	Name       string         `json:"name"`
+	Owner      string         `json:"owner"`
+	Prefix     string         `json:"prefix"`
+	Scopes     []Scope        `json:"scopes"`
+	Tier       string         `json:"tier"`
+	MaxRating  *ContentRating `json:"max_rating,omitempty"`
+	ExpiresAt  *time.Time     `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time     `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time     `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	// Subject identifies the caller in logs, e.g. "key:12"
+	Subject string
+	// KeyID is the API key used to authenticate, if any
+	KeyID int32
+	Name  string
+	Owner string
+	// Scopes lists the operations the caller may perform
+	Scopes []Scope
+	// Tier names the rate limit tier the caller is subject to
+	Tier string
+	// MaxRating overrides the default content rating ceiling when set
+	MaxRating *ContentRating
+}
+
+// HasScope reports whether the principal was granted scope
+func (p *Principal) HasScope(scope Scope) bool {
+	return p != nil && slices.Contains(p.Scopes, scope)
+}
+
+// HasRole reports whether the principal holds every scope granted by role.
+// Any authenticated principal is a reader.
+func (p *Principal) HasRole(role Role) bool {
+	if p == nil {
+		return false
+	}
+	for _, scope := range role.Scopes() {
+		if !p.HasScope(scope) {
+			return false
+		}
+	}
+	return true
+}
+
+// Role returns the most privileged role the principal holds
+func (p *Principal) Role() Role {
+	role := RoleReader
+	for _, candidate := range Roles {
+		if p.HasRole(candidate) {
+			role = candidate
+		}
+	}
+	return role
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditEvent records a write or admin operation
+type AuditEvent struct {
+	ID         int64     `json:"id"`
+	OccurredAt time.Time `json:"occurred_at"`
+	// Actor is the caller's subject, e.g. "key:12" or "jwt:alice"
+	Actor      string `json:"actor"`
+	Action     string `json:"action" example:"joke.create"`
+	TargetType string `json:"target_type" example:"joke"`
+	TargetID   string `json:"target_id" example:"42"`
+	// Before and After are the target as it was before and after the
+	// operation; either is omitted when the target did not exist
+	Before    json.RawMessage `json:"before,omitempty" swaggertype:"object"`
+	After     json.RawMessage `json:"after,omitempty" swaggertype:"object"`
+	ClientIP  string          `json:"client_ip,omitempty"`
+	RequestID string          `json:"request_id,omitempty"`
+}
+
+// AuditEventList is a page of audit events, newest first
+type AuditEventList struct {
+	Events []AuditEvent `json:"events"`
+	// NextCursor fetches the next page when passed as cursor; it is omitted
+	// on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
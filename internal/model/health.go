@@ -0,0 +1,57 @@
+package model
+
+// StatusResponse is a bare status, e.g. from the liveness probe
+type StatusResponse struct {
+	Status string `json:"status"`
+}
+
+// CheckResult is the outcome of checking one dependency
+type CheckResult struct {
+	Name string `json:"name"`
+	// Status is "ok" or "fail"
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	// Optional checks do not affect readiness
+	Optional bool `json:"optional,omitempty"`
+	// Error explains a failed check; only shown to authenticated callers
+	Error string `json:"error,omitempty"`
+}
+
+// ReadinessResponse reports whether the service can take traffic
+type ReadinessResponse struct {
+	// Status is "ready", "not_ready" or "draining"
+	Status string        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// DBPoolStats describes the database connection pool
+type DBPoolStats struct {
+	AcquiredConns     int32   `json:"acquired_conns"`
+	IdleConns         int32   `json:"idle_conns"`
+	TotalConns        int32   `json:"total_conns"`
+	MaxConns          int32   `json:"max_conns"`
+	AcquireCount      int64   `json:"acquire_count"`
+	EmptyAcquireCount int64   `json:"empty_acquire_count"`
+	AcquireWaitMS     float64 `json:"acquire_wait_ms"`
+}
+
+// HealthDetailsResponse is the detailed health report
+type HealthDetailsResponse struct {
+	// Status is "ready", "not_ready" or "draining"
+	Status        string        `json:"status"`
+	UptimeSeconds int64         `json:"uptime_seconds"`
+	Version       string        `json:"version"`
+	Commit        string        `json:"commit"`
+	Checks        []CheckResult `json:"checks"`
+	DBPool        DBPoolStats   `json:"db_pool"`
+}
+
+// VersionResponse identifies the running build
+type VersionResponse struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	// Modified is set when built from a checkout with uncommitted changes
+	Modified  bool   `json:"modified,omitempty"`
+	GoVersion string `json:"go_version"`
+}
@@ -4,19 +4,85 @@ import "time"
 
 // Joke represents a dad joke
 type Joke struct {
-	ID        int32     `json:"id"`
-	Setup     string    `json:"setup"`
-	Punchline string    `json:"punchline"`
-	Category  *string   `json:"category,omitempty"`
-	Tags      []string  `json:"tags"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID              int32         `json:"id"`
+	Setup           string        `json:"setup"`
+	Punchline       string        `json:"punchline"`
+	Type            JokeType      `json:"type"`
+	Parts           []JokePart    `json:"parts"`
+	Category        *string       `json:"category,omitempty"`
+	Tags            []string      `json:"tags"`
+	Rating          ContentRating `json:"rating"`
+	ContentWarnings []string      `json:"content_warnings"`
+	Flagged         bool          `json:"flagged"`
+	Language        string        `json:"language"`
+	TranslationOf   *int32        `json:"translation_of,omitempty"`
+	Author          *string       `json:"author,omitempty"`
+	SourceName      *string       `json:"source_name,omitempty"`
+	SourceURL       *string       `json:"source_url,omitempty"`
+	License         *string       `json:"license,omitempty"`
+	CreatedAt       time.Time     `json:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at"`
+}
+
+// JokeList is a page of jokes, oldest first
+type JokeList struct {
+	Jokes []Joke `json:"jokes"`
+	// NextCursor fetches the next page when passed as cursor; it is omitted
+	// on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Category is a joke category and the number of jokes in it
+type Category struct {
+	Name      string `json:"name"`
+	JokeCount int64  `json:"joke_count"`
+}
+
+// SimilarJoke is a joke ranked by its similarity to another joke
+type SimilarJoke struct {
+	Joke
+	Score float64 `json:"score"`
+}
+
+// SimilarJokesResponse represents the response for similar joke lookups
+type SimilarJokesResponse struct {
+	JokeID int32         `json:"joke_id"`
+	Jokes  []SimilarJoke `json:"jokes"`
+}
+
+// TranslationsResponse represents the response for joke translation lookups
+type TranslationsResponse struct {
+	JokeID       int32  `json:"joke_id"`
+	Translations []Joke `json:"translations"`
+}
+
+// ImportJokesResponse represents the result of a bulk joke import
+type ImportJokesResponse struct {
+	Created []int32       `json:"created"`
+	Errors  []ImportError `json:"errors"`
+}
+
+// ImportError describes why one joke in an import was rejected
+type ImportError struct {
+	Index   int    `json:"index"`
+	Error   string `json:"error"`
+	Message string `json:"message,omitempty"`
+	// RequestID matches the X-Request-ID response header and the server logs
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// DeleteSourceResponse represents the result of deleting a source's jokes
+type DeleteSourceResponse struct {
+	Source  string `json:"source"`
+	Deleted int64  `json:"deleted"`
 }
 
 // ErrorResponse represents an error response
 type ErrorResponse struct {
 	Error   string `json:"error"`
 	Message string `json:"message,omitempty"`
+	// RequestID matches the X-Request-ID response header and the server logs
+	RequestID string `json:"request_id,omitempty"`
 }
 
 // HealthResponse represents a health check response
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// JokeType describes the structure of a joke
+type JokeType string
+
+const (
+	// TypeSetupPunchline is a classic two-part joke
+	TypeSetupPunchline JokeType = "setup_punchline"
+	// TypeOneLiner is a single line with no separate punchline
+	TypeOneLiner JokeType = "one_liner"
+	// TypeKnockKnock is a knock-knock dialogue
+	TypeKnockKnock JokeType = "knock_knock"
+	// TypeMultiPart is any other joke told in more than two ordered parts
+	TypeMultiPart JokeType = "multi_part"
+)
+
+// JokeTypes lists every valid joke type
+var JokeTypes = []JokeType{TypeSetupPunchline, TypeOneLiner, TypeKnockKnock, TypeMultiPart}
+
+// ParseJokeType parses a joke type such as "knock_knock" or "knock-knock"
+func ParseJokeType(s string) (JokeType, error) {
+	normalized := strings.ToLower(strings.TrimSpace(s))
+	normalized = strings.ReplaceAll(normalized, "-", "_")
+
+	for _, jokeType := range JokeTypes {
+		if string(jokeType) == normalized {
+			return jokeType, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown joke type %q", s)
+}
+
+// JokePart is one ordered line of a joke. Speaker is set for dialogue
+// formats such as knock-knock jokes.
+type JokePart struct {
+	Speaker string `json:"speaker,omitempty"`
+	Text    string `json:"text"`
+}
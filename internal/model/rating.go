@@ -0,0 +1,49 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ContentRating classifies how suitable a joke is for younger audiences
+type ContentRating string
+
+// Ratings are listed from most to least family-friendly
+const (
+	RatingG    ContentRating = "g"
+	RatingPG   ContentRating = "pg"
+	RatingPG13 ContentRating = "pg13"
+	RatingR    ContentRating = "r"
+)
+
+// ContentRatings lists every valid rating in ascending order
+var ContentRatings = []ContentRating{RatingG, RatingPG, RatingPG13, RatingR}
+
+// ParseContentRating parses a rating such as "g", "PG" or "pg-13"
+func ParseContentRating(s string) (ContentRating, error) {
+	normalized := strings.ToLower(strings.TrimSpace(s))
+	normalized = strings.ReplaceAll(normalized, "-", "")
+
+	for _, rating := range ContentRatings {
+		if string(rating) == normalized {
+			return rating, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown content rating %q", s)
+}
+
+// Rank returns the position of the rating in ContentRatings, or -1 if unknown
+func (r ContentRating) Rank() int {
+	for i, rating := range ContentRatings {
+		if rating == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// Exceeds reports whether r is less family-friendly than other
+func (r ContentRating) Exceeds(other ContentRating) bool {
+	return r.Rank() > other.Rank()
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Role is a named bundle of scopes. Each role includes every scope of the
+// roles before it.
+type Role string
+
+// Roles are listed from least to most privileged
+const (
+	RoleReader      Role = "reader"
+	RoleContributor Role = "contributor"
+	RoleModerator   Role = "moderator"
+	RoleAdmin       Role = "admin"
+)
+
+// Roles lists every valid role in ascending order of privilege
+var Roles = []Role{RoleReader, RoleContributor, RoleModerator, RoleAdmin}
+
+// roleScopes lists the scopes granted by each role
+var roleScopes = map[Role][]Scope{
+	RoleReader:      {},
+	RoleContributor: {ScopeJokesWrite},
+	RoleModerator:   {ScopeJokesWrite, ScopeModerate},
+	RoleAdmin:       {ScopeJokesWrite, ScopeModerate, ScopeTagsAdmin},
+}
+
+// ParseRole parses a role name such as "moderator"
+func ParseRole(s string) (Role, error) {
+	normalized := Role(strings.ToLower(strings.TrimSpace(s)))
+	if slices.Contains(Roles, normalized) {
+		return normalized, nil
+	}
+	return "", fmt.Errorf("unknown role %q", s)
+}
+
+// Scopes returns the scopes granted by the role
+func (r Role) Scopes() []Scope {
+	return slices.Clone(roleScopes[r])
+}
+
+// ParseScopeOrRole parses a scope name, or a role name which is expanded to
+// the scopes it grants
+func ParseScopeOrRole(s string) ([]Scope, error) {
+	if scope, err := ParseScope(s); err == nil {
+		return []Scope{scope}, nil
+	}
+	if role, err := ParseRole(s); err == nil {
+		return role.Scopes(), nil
+	}
+	return nil, fmt.Errorf("unknown scope or role %q", s)
+}
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// Usage describes a caller's rate limit and how much of its daily quota it
+// has used
+type Usage struct {
+	Subject string `json:"subject"`
+	Tier    string `json:"tier"`
+	// Requests and Window describe the burst limit
+	Requests int    `json:"requests"`
+	Window   string `json:"window"`
+	// DailyQuota is 0 for tiers without a daily quota
+	DailyQuota int `json:"daily_quota"`
+	UsedToday  int `json:"used_today"`
+	// RemainingToday is omitted for tiers without a daily quota
+	RemainingToday *int      `json:"remaining_today,omitempty"`
+	ResetsAt       time.Time `json:"resets_at"`
+	// Enforced is false when rate limiting is disabled, e.g. in development
+	Enforced bool `json:"enforced"`
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Vote is a caller's opinion of a joke
+type Vote string
+
+const (
+	VoteUp   Vote = "up"
+	VoteDown Vote = "down"
+)
+
+// ParseVote parses "up" or "down"
+func ParseVote(s string) (Vote, error) {
+	switch vote := Vote(strings.ToLower(strings.TrimSpace(s))); vote {
+	case VoteUp, VoteDown:
+		return vote, nil
+	default:
+		return "", fmt.Errorf("unknown vote %q", s)
+	}
+}
+
+// VoteTotals counts the votes on a joke
+type VoteTotals struct {
+	JokeID int32 `json:"joke_id"`
+	Up     int64 `json:"up"`
+	Down   int64 `json:"down"`
+}
@@ -0,0 +1,152 @@
+package moderation
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/cdunlap/djaas/internal/model"
+)
+
+// Result describes the outcome of checking a joke submission
+type Result struct {
+	// Flagged is true when the submission contains terms above its declared rating
+	Flagged bool
+	// Matches lists the terms that caused the submission to be flagged
+	Matches []string
+	// Rating is the declared rating, raised to cover any matched terms
+	Rating model.ContentRating
+}
+
+// Checker inspects submitted text for content above a declared rating
+type Checker interface {
+	Check(text string, declared model.ContentRating) Result
+}
+
+// WordListChecker flags submissions containing listed words or phrases.
+// Each entry carries the minimum rating a joke must have to use it.
+type WordListChecker struct {
+	words   map[string]model.ContentRating
+	phrases map[string]model.ContentRating
+}
+
+// defaultWordList is used when no word list file is configured
+var defaultWordList = map[string]model.ContentRating{
+	"beer":     model.RatingPG,
+	"booze":    model.RatingPG,
+	"drunk":    model.RatingPG,
+	"hangover": model.RatingPG,
+	"damn":     model.RatingPG13,
+	"hell":     model.RatingPG13,
+	"crap":     model.RatingPG13,
+	"sexy":     model.RatingPG13,
+	"ass":      model.RatingR,
+	"bastard":  model.RatingR,
+	"sex":      model.RatingR,
+}
+
+// NewWordListChecker creates a WordListChecker from a map of terms to ratings
+func NewWordListChecker(entries map[string]model.ContentRating) *WordListChecker {
+	c := &WordListChecker{
+		words:   make(map[string]model.ContentRating),
+		phrases: make(map[string]model.ContentRating),
+	}
+
+	for term, rating := range entries {
+		term = strings.ToLower(strings.TrimSpace(term))
+		if term == "" {
+			continue
+		}
+		if strings.ContainsRune(term, ' ') {
+			c.phrases[term] = rating
+		} else {
+			c.words[term] = rating
+		}
+	}
+
+	return c
+}
+
+// NewDefaultChecker creates a WordListChecker with the built-in word list
+func NewDefaultChecker() *WordListChecker {
+	return NewWordListChecker(defaultWordList)
+}
+
+// LoadWordList reads a word list file with one "term[,rating]" entry per line.
+// Blank lines and lines starting with # are ignored; the rating defaults to pg13.
+func LoadWordList(path string) (*WordListChecker, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open word list: %w", err)
+	}
+	defer file.Close()
+
+	entries := make(map[string]model.ContentRating)
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		term, ratingStr, hasRating := strings.Cut(line, ",")
+		rating := model.RatingPG13
+		if hasRating {
+			rating, err = model.ParseContentRating(ratingStr)
+			if err != nil {
+				return nil, fmt.Errorf("word list line %d: %w", lineNum, err)
+			}
+		}
+		entries[term] = rating
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read word list: %w", err)
+	}
+
+	return NewWordListChecker(entries), nil
+}
+
+// Check flags text containing terms that require a higher rating than declared
+func (c *WordListChecker) Check(text string, declared model.ContentRating) Result {
+	result := Result{Rating: declared}
+	seen := make(map[string]bool)
+
+	match := func(term string, rating model.ContentRating) {
+		if !rating.Exceeds(declared) || seen[term] {
+			return
+		}
+		seen[term] = true
+		result.Flagged = true
+		result.Matches = append(result.Matches, term)
+		if rating.Exceeds(result.Rating) {
+			result.Rating = rating
+		}
+	}
+
+	lower := strings.ToLower(text)
+	words := strings.FieldsFunc(lower, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+	for _, word := range words {
+		word = strings.Trim(word, "'")
+		if rating, ok := c.words[word]; ok {
+			match(word, rating)
+		}
+	}
+
+	// Phrases are matched against the text with punctuation collapsed to spaces
+	normalized := " " + strings.Join(words, " ") + " "
+	for phrase, rating := range c.phrases {
+		if strings.Contains(normalized, " "+phrase+" ") {
+			match(phrase, rating)
+		}
+	}
+
+	sort.Strings(result.Matches)
+	return result
+}
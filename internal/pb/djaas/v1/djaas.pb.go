@@ -0,0 +1,1155 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: djaas/v1/djaas.proto
+
+package djaasv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// JokeType describes the structure of a joke
+type JokeType int32
+
+const (
+	JokeType_JOKE_TYPE_UNSPECIFIED JokeType = 0
+	// A classic two-part joke
+	JokeType_JOKE_TYPE_SETUP_PUNCHLINE JokeType = 1
+	// A single line with no separate punchline
+	JokeType_JOKE_TYPE_ONE_LINER JokeType = 2
+	// A knock-knock dialogue
+	JokeType_JOKE_TYPE_KNOCK_KNOCK JokeType = 3
+	// Any other joke told in more than two ordered parts
+	JokeType_JOKE_TYPE_MULTI_PART JokeType = 4
+)
+
+// Enum value maps for JokeType.
+var (
+	JokeType_name = map[int32]string{
+		0: "JOKE_TYPE_UNSPECIFIED",
+		1: "JOKE_TYPE_SETUP_PUNCHLINE",
+		2: "JOKE_TYPE_ONE_LINER",
+		3: "JOKE_TYPE_KNOCK_KNOCK",
+		4: "JOKE_TYPE_MULTI_PART",
+	}
+	JokeType_value = map[string]int32{
+		"JOKE_TYPE_UNSPECIFIED":     0,
+		"JOKE_TYPE_SETUP_PUNCHLINE": 1,
+		"JOKE_TYPE_ONE_LINER":       2,
+		"JOKE_TYPE_KNOCK_KNOCK":     3,
+		"JOKE_TYPE_MULTI_PART":      4,
+	}
+)
+
+func (x JokeType) Enum() *JokeType {
+	p := new(JokeType)
+	*p = x
+	return p
+}
+
+func (x JokeType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (JokeType) Descriptor() protoreflect.EnumDescriptor {
+	return file_djaas_v1_djaas_proto_enumTypes[0].Descriptor()
+}
+
+func (JokeType) Type() protoreflect.EnumType {
+	return &file_djaas_v1_djaas_proto_enumTypes[0]
+}
+
+func (x JokeType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use JokeType.Descriptor instead.
+func (JokeType) EnumDescriptor() ([]byte, []int) {
+	return file_djaas_v1_djaas_proto_rawDescGZIP(), []int{0}
+}
+
+// ContentRating classifies how suitable a joke is for younger audiences,
+// from most to least family-friendly
+type ContentRating int32
+
+const (
+	ContentRating_CONTENT_RATING_UNSPECIFIED ContentRating = 0
+	ContentRating_CONTENT_RATING_G           ContentRating = 1
+	ContentRating_CONTENT_RATING_PG          ContentRating = 2
+	ContentRating_CONTENT_RATING_PG13        ContentRating = 3
+	ContentRating_CONTENT_RATING_R           ContentRating = 4
+)
+
+// Enum value maps for ContentRating.
+var (
+	ContentRating_name = map[int32]string{
+		0: "CONTENT_RATING_UNSPECIFIED",
+		1: "CONTENT_RATING_G",
+		2: "CONTENT_RATING_PG",
+		3: "CONTENT_RATING_PG13",
+		4: "CONTENT_RATING_R",
+	}
+	ContentRating_value = map[string]int32{
+		"CONTENT_RATING_UNSPECIFIED": 0,
+		"CONTENT_RATING_G":           1,
+		"CONTENT_RATING_PG":          2,
+		"CONTENT_RATING_PG13":        3,
+		"CONTENT_RATING_R":           4,
+	}
+)
+
+func (x ContentRating) Enum() *ContentRating {
+	p := new(ContentRating)
+	*p = x
+	return p
+}
+
+func (x ContentRating) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ContentRating) Descriptor() protoreflect.EnumDescriptor {
+	return file_djaas_v1_djaas_proto_enumTypes[1].Descriptor()
+}
+
+func (ContentRating) Type() protoreflect.EnumType {
+	return &file_djaas_v1_djaas_proto_enumTypes[1]
+}
+
+func (x ContentRating) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ContentRating.Descriptor instead.
+func (ContentRating) EnumDescriptor() ([]byte, []int) {
+	return file_djaas_v1_djaas_proto_rawDescGZIP(), []int{1}
+}
+
+// JokePart is one ordered line of a joke
+type JokePart struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Set for dialogue formats such as knock-knock jokes
+	Speaker       string `protobuf:"bytes,1,opt,name=speaker,proto3" json:"speaker,omitempty"`
+	Text          string `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JokePart) Reset() {
+	*x = JokePart{}
+	mi := &file_djaas_v1_djaas_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JokePart) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JokePart) ProtoMessage() {}
+
+func (x *JokePart) ProtoReflect() protoreflect.Message {
+	mi := &file_djaas_v1_djaas_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JokePart.ProtoReflect.Descriptor instead.
+func (*JokePart) Descriptor() ([]byte, []int) {
+	return file_djaas_v1_djaas_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *JokePart) GetSpeaker() string {
+	if x != nil {
+		return x.Speaker
+	}
+	return ""
+}
+
+func (x *JokePart) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+// Joke is a dad joke. Empty strings and a zero translation_of mean the
+// field is not set.
+type Joke struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Setup           string                 `protobuf:"bytes,2,opt,name=setup,proto3" json:"setup,omitempty"`
+	Punchline       string                 `protobuf:"bytes,3,opt,name=punchline,proto3" json:"punchline,omitempty"`
+	Type            JokeType               `protobuf:"varint,4,opt,name=type,proto3,enum=djaas.v1.JokeType" json:"type,omitempty"`
+	Parts           []*JokePart            `protobuf:"bytes,5,rep,name=parts,proto3" json:"parts,omitempty"`
+	Category        string                 `protobuf:"bytes,6,opt,name=category,proto3" json:"category,omitempty"`
+	Tags            []string               `protobuf:"bytes,7,rep,name=tags,proto3" json:"tags,omitempty"`
+	Rating          ContentRating          `protobuf:"varint,8,opt,name=rating,proto3,enum=djaas.v1.ContentRating" json:"rating,omitempty"`
+	ContentWarnings []string               `protobuf:"bytes,9,rep,name=content_warnings,json=contentWarnings,proto3" json:"content_warnings,omitempty"`
+	Flagged         bool                   `protobuf:"varint,10,opt,name=flagged,proto3" json:"flagged,omitempty"`
+	Language        string                 `protobuf:"bytes,11,opt,name=language,proto3" json:"language,omitempty"`
+	TranslationOf   int32                  `protobuf:"varint,12,opt,name=translation_of,json=translationOf,proto3" json:"translation_of,omitempty"`
+	Author          string                 `protobuf:"bytes,13,opt,name=author,proto3" json:"author,omitempty"`
+	SourceName      string                 `protobuf:"bytes,14,opt,name=source_name,json=sourceName,proto3" json:"source_name,omitempty"`
+	SourceUrl       string                 `protobuf:"bytes,15,opt,name=source_url,json=sourceUrl,proto3" json:"source_url,omitempty"`
+	License         string                 `protobuf:"bytes,16,opt,name=license,proto3" json:"license,omitempty"`
+	CreatedAt       *timestamppb.Timestamp `protobuf:"bytes,17,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt       *timestamppb.Timestamp `protobuf:"bytes,18,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Joke) Reset() {
+	*x = Joke{}
+	mi := &file_djaas_v1_djaas_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Joke) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Joke) ProtoMessage() {}
+
+func (x *Joke) ProtoReflect() protoreflect.Message {
+	mi := &file_djaas_v1_djaas_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Joke.ProtoReflect.Descriptor instead.
+func (*Joke) Descriptor() ([]byte, []int) {
+	return file_djaas_v1_djaas_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Joke) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Joke) GetSetup() string {
+	if x != nil {
+		return x.Setup
+	}
+	return ""
+}
+
+func (x *Joke) GetPunchline() string {
+	if x != nil {
+		return x.Punchline
+	}
+	return ""
+}
+
+func (x *Joke) GetType() JokeType {
+	if x != nil {
+		return x.Type
+	}
+	return JokeType_JOKE_TYPE_UNSPECIFIED
+}
+
+func (x *Joke) GetParts() []*JokePart {
+	if x != nil {
+		return x.Parts
+	}
+	return nil
+}
+
+func (x *Joke) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *Joke) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Joke) GetRating() ContentRating {
+	if x != nil {
+		return x.Rating
+	}
+	return ContentRating_CONTENT_RATING_UNSPECIFIED
+}
+
+func (x *Joke) GetContentWarnings() []string {
+	if x != nil {
+		return x.ContentWarnings
+	}
+	return nil
+}
+
+func (x *Joke) GetFlagged() bool {
+	if x != nil {
+		return x.Flagged
+	}
+	return false
+}
+
+func (x *Joke) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *Joke) GetTranslationOf() int32 {
+	if x != nil {
+		return x.TranslationOf
+	}
+	return 0
+}
+
+func (x *Joke) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *Joke) GetSourceName() string {
+	if x != nil {
+		return x.SourceName
+	}
+	return ""
+}
+
+func (x *Joke) GetSourceUrl() string {
+	if x != nil {
+		return x.SourceUrl
+	}
+	return ""
+}
+
+func (x *Joke) GetLicense() string {
+	if x != nil {
+		return x.License
+	}
+	return ""
+}
+
+func (x *Joke) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Joke) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+// JokeFilter selects jokes, like the query parameters of GET /api/v1/joke.
+// Empty fields match every joke.
+type JokeFilter struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Search   string                 `protobuf:"bytes,1,opt,name=search,proto3" json:"search,omitempty"`
+	Category string                 `protobuf:"bytes,2,opt,name=category,proto3" json:"category,omitempty"`
+	// Jokes with any of these tags match
+	Tags []string `protobuf:"bytes,3,rep,name=tags,proto3" json:"tags,omitempty"`
+	// Capped by the caller's rating ceiling
+	MaxRating ContentRating `protobuf:"varint,4,opt,name=max_rating,json=maxRating,proto3,enum=djaas.v1.ContentRating" json:"max_rating,omitempty"`
+	// Preferred language code, e.g. "en"; random jokes fall back to the
+	// server's fallback languages, lists do not
+	Lang          string   `protobuf:"bytes,5,opt,name=lang,proto3" json:"lang,omitempty"`
+	Type          JokeType `protobuf:"varint,6,opt,name=type,proto3,enum=djaas.v1.JokeType" json:"type,omitempty"`
+	Source        string   `protobuf:"bytes,7,opt,name=source,proto3" json:"source,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JokeFilter) Reset() {
+	*x = JokeFilter{}
+	mi := &file_djaas_v1_djaas_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JokeFilter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JokeFilter) ProtoMessage() {}
+
+func (x *JokeFilter) ProtoReflect() protoreflect.Message {
+	mi := &file_djaas_v1_djaas_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JokeFilter.ProtoReflect.Descriptor instead.
+func (*JokeFilter) Descriptor() ([]byte, []int) {
+	return file_djaas_v1_djaas_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *JokeFilter) GetSearch() string {
+	if x != nil {
+		return x.Search
+	}
+	return ""
+}
+
+func (x *JokeFilter) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *JokeFilter) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *JokeFilter) GetMaxRating() ContentRating {
+	if x != nil {
+		return x.MaxRating
+	}
+	return ContentRating_CONTENT_RATING_UNSPECIFIED
+}
+
+func (x *JokeFilter) GetLang() string {
+	if x != nil {
+		return x.Lang
+	}
+	return ""
+}
+
+func (x *JokeFilter) GetType() JokeType {
+	if x != nil {
+		return x.Type
+	}
+	return JokeType_JOKE_TYPE_UNSPECIFIED
+}
+
+func (x *JokeFilter) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+type GetRandomJokeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Filter        *JokeFilter            `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRandomJokeRequest) Reset() {
+	*x = GetRandomJokeRequest{}
+	mi := &file_djaas_v1_djaas_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRandomJokeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRandomJokeRequest) ProtoMessage() {}
+
+func (x *GetRandomJokeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_djaas_v1_djaas_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRandomJokeRequest.ProtoReflect.Descriptor instead.
+func (*GetRandomJokeRequest) Descriptor() ([]byte, []int) {
+	return file_djaas_v1_djaas_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetRandomJokeRequest) GetFilter() *JokeFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+type GetJokeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetJokeRequest) Reset() {
+	*x = GetJokeRequest{}
+	mi := &file_djaas_v1_djaas_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetJokeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetJokeRequest) ProtoMessage() {}
+
+func (x *GetJokeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_djaas_v1_djaas_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetJokeRequest.ProtoReflect.Descriptor instead.
+func (*GetJokeRequest) Descriptor() ([]byte, []int) {
+	return file_djaas_v1_djaas_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetJokeRequest) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type ListJokesRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Filter *JokeFilter            `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	// 1 to 100; 0 means 20
+	PageSize int32 `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// next_page_token from the previous page
+	PageToken     string `protobuf:"bytes,3,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListJokesRequest) Reset() {
+	*x = ListJokesRequest{}
+	mi := &file_djaas_v1_djaas_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListJokesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListJokesRequest) ProtoMessage() {}
+
+func (x *ListJokesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_djaas_v1_djaas_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListJokesRequest.ProtoReflect.Descriptor instead.
+func (*ListJokesRequest) Descriptor() ([]byte, []int) {
+	return file_djaas_v1_djaas_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListJokesRequest) GetFilter() *JokeFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *ListJokesRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListJokesRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type ListJokesResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Jokes []*Joke                `protobuf:"bytes,1,rep,name=jokes,proto3" json:"jokes,omitempty"`
+	// Fetches the next page when passed as page_token; empty on the last page
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListJokesResponse) Reset() {
+	*x = ListJokesResponse{}
+	mi := &file_djaas_v1_djaas_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListJokesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListJokesResponse) ProtoMessage() {}
+
+func (x *ListJokesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_djaas_v1_djaas_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListJokesResponse.ProtoReflect.Descriptor instead.
+func (*ListJokesResponse) Descriptor() ([]byte, []int) {
+	return file_djaas_v1_djaas_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListJokesResponse) GetJokes() []*Joke {
+	if x != nil {
+		return x.Jokes
+	}
+	return nil
+}
+
+func (x *ListJokesResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+// CreateJokeRequest holds a new joke. Setup and punchline are derived from
+// parts for one-liners, knock-knock and multi-part jokes, and vice versa.
+type CreateJokeRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Setup     string                 `protobuf:"bytes,1,opt,name=setup,proto3" json:"setup,omitempty"`
+	Punchline string                 `protobuf:"bytes,2,opt,name=punchline,proto3" json:"punchline,omitempty"`
+	// Defaults to JOKE_TYPE_SETUP_PUNCHLINE
+	Type     JokeType    `protobuf:"varint,3,opt,name=type,proto3,enum=djaas.v1.JokeType" json:"type,omitempty"`
+	Parts    []*JokePart `protobuf:"bytes,4,rep,name=parts,proto3" json:"parts,omitempty"`
+	Category string      `protobuf:"bytes,5,opt,name=category,proto3" json:"category,omitempty"`
+	Tags     []string    `protobuf:"bytes,6,rep,name=tags,proto3" json:"tags,omitempty"`
+	// Defaults to CONTENT_RATING_G
+	Rating          ContentRating `protobuf:"varint,7,opt,name=rating,proto3,enum=djaas.v1.ContentRating" json:"rating,omitempty"`
+	ContentWarnings []string      `protobuf:"bytes,8,rep,name=content_warnings,json=contentWarnings,proto3" json:"content_warnings,omitempty"`
+	// Defaults to the server's first fallback language
+	Language string `protobuf:"bytes,9,opt,name=language,proto3" json:"language,omitempty"`
+	// Links the joke to an equivalent joke in another language
+	TranslationOf int32  `protobuf:"varint,10,opt,name=translation_of,json=translationOf,proto3" json:"translation_of,omitempty"`
+	Author        string `protobuf:"bytes,11,opt,name=author,proto3" json:"author,omitempty"`
+	SourceName    string `protobuf:"bytes,12,opt,name=source_name,json=sourceName,proto3" json:"source_name,omitempty"`
+	SourceUrl     string `protobuf:"bytes,13,opt,name=source_url,json=sourceUrl,proto3" json:"source_url,omitempty"`
+	License       string `protobuf:"bytes,14,opt,name=license,proto3" json:"license,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateJokeRequest) Reset() {
+	*x = CreateJokeRequest{}
+	mi := &file_djaas_v1_djaas_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateJokeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateJokeRequest) ProtoMessage() {}
+
+func (x *CreateJokeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_djaas_v1_djaas_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateJokeRequest.ProtoReflect.Descriptor instead.
+func (*CreateJokeRequest) Descriptor() ([]byte, []int) {
+	return file_djaas_v1_djaas_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *CreateJokeRequest) GetSetup() string {
+	if x != nil {
+		return x.Setup
+	}
+	return ""
+}
+
+func (x *CreateJokeRequest) GetPunchline() string {
+	if x != nil {
+		return x.Punchline
+	}
+	return ""
+}
+
+func (x *CreateJokeRequest) GetType() JokeType {
+	if x != nil {
+		return x.Type
+	}
+	return JokeType_JOKE_TYPE_UNSPECIFIED
+}
+
+func (x *CreateJokeRequest) GetParts() []*JokePart {
+	if x != nil {
+		return x.Parts
+	}
+	return nil
+}
+
+func (x *CreateJokeRequest) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *CreateJokeRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *CreateJokeRequest) GetRating() ContentRating {
+	if x != nil {
+		return x.Rating
+	}
+	return ContentRating_CONTENT_RATING_UNSPECIFIED
+}
+
+func (x *CreateJokeRequest) GetContentWarnings() []string {
+	if x != nil {
+		return x.ContentWarnings
+	}
+	return nil
+}
+
+func (x *CreateJokeRequest) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *CreateJokeRequest) GetTranslationOf() int32 {
+	if x != nil {
+		return x.TranslationOf
+	}
+	return 0
+}
+
+func (x *CreateJokeRequest) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *CreateJokeRequest) GetSourceName() string {
+	if x != nil {
+		return x.SourceName
+	}
+	return ""
+}
+
+func (x *CreateJokeRequest) GetSourceUrl() string {
+	if x != nil {
+		return x.SourceUrl
+	}
+	return ""
+}
+
+func (x *CreateJokeRequest) GetLicense() string {
+	if x != nil {
+		return x.License
+	}
+	return ""
+}
+
+type ListTagsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTagsRequest) Reset() {
+	*x = ListTagsRequest{}
+	mi := &file_djaas_v1_djaas_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTagsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTagsRequest) ProtoMessage() {}
+
+func (x *ListTagsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_djaas_v1_djaas_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTagsRequest.ProtoReflect.Descriptor instead.
+func (*ListTagsRequest) Descriptor() ([]byte, []int) {
+	return file_djaas_v1_djaas_proto_rawDescGZIP(), []int{8}
+}
+
+type ListTagsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tags          []string               `protobuf:"bytes,1,rep,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTagsResponse) Reset() {
+	*x = ListTagsResponse{}
+	mi := &file_djaas_v1_djaas_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTagsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTagsResponse) ProtoMessage() {}
+
+func (x *ListTagsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_djaas_v1_djaas_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTagsResponse.ProtoReflect.Descriptor instead.
+func (*ListTagsResponse) Descriptor() ([]byte, []int) {
+	return file_djaas_v1_djaas_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ListTagsResponse) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+type StreamJokesRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Filter *JokeFilter            `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	// Time between jokes, 5s to 1h; unset means 30s
+	Interval      *durationpb.Duration `protobuf:"bytes,2,opt,name=interval,proto3" json:"interval,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamJokesRequest) Reset() {
+	*x = StreamJokesRequest{}
+	mi := &file_djaas_v1_djaas_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamJokesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamJokesRequest) ProtoMessage() {}
+
+func (x *StreamJokesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_djaas_v1_djaas_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamJokesRequest.ProtoReflect.Descriptor instead.
+func (*StreamJokesRequest) Descriptor() ([]byte, []int) {
+	return file_djaas_v1_djaas_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *StreamJokesRequest) GetFilter() *JokeFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *StreamJokesRequest) GetInterval() *durationpb.Duration {
+	if x != nil {
+		return x.Interval
+	}
+	return nil
+}
+
+var File_djaas_v1_djaas_proto protoreflect.FileDescriptor
+
+const file_djaas_v1_djaas_proto_rawDesc = "" +
+	"\n" +
+	"\x14djaas/v1/djaas.proto\x12\bdjaas.v1\x1a\x1egoogle/protobuf/duration.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"8\n" +
+	"\bJokePart\x12\x18\n" +
+	"\aspeaker\x18\x01 \x01(\tR\aspeaker\x12\x12\n" +
+	"\x04text\x18\x02 \x01(\tR\x04text\"\xed\x04\n" +
+	"\x04Joke\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x14\n" +
+	"\x05setup\x18\x02 \x01(\tR\x05setup\x12\x1c\n" +
+	"\tpunchline\x18\x03 \x01(\tR\tpunchline\x12&\n" +
+	"\x04type\x18\x04 \x01(\x0e2\x12.djaas.v1.JokeTypeR\x04type\x12(\n" +
+	"\x05parts\x18\x05 \x03(\v2\x12.djaas.v1.JokePartR\x05parts\x12\x1a\n" +
+	"\bcategory\x18\x06 \x01(\tR\bcategory\x12\x12\n" +
+	"\x04tags\x18\a \x03(\tR\x04tags\x12/\n" +
+	"\x06rating\x18\b \x01(\x0e2\x17.djaas.v1.ContentRatingR\x06rating\x12)\n" +
+	"\x10content_warnings\x18\t \x03(\tR\x0fcontentWarnings\x12\x18\n" +
+	"\aflagged\x18\n" +
+	" \x01(\bR\aflagged\x12\x1a\n" +
+	"\blanguage\x18\v \x01(\tR\blanguage\x12%\n" +
+	"\x0etranslation_of\x18\f \x01(\x05R\rtranslationOf\x12\x16\n" +
+	"\x06author\x18\r \x01(\tR\x06author\x12\x1f\n" +
+	"\vsource_name\x18\x0e \x01(\tR\n" +
+	"sourceName\x12\x1d\n" +
+	"\n" +
+	"source_url\x18\x0f \x01(\tR\tsourceUrl\x12\x18\n" +
+	"\alicense\x18\x10 \x01(\tR\alicense\x129\n" +
+	"\n" +
+	"created_at\x18\x11 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\x12 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"\xe0\x01\n" +
+	"\n" +
+	"JokeFilter\x12\x16\n" +
+	"\x06search\x18\x01 \x01(\tR\x06search\x12\x1a\n" +
+	"\bcategory\x18\x02 \x01(\tR\bcategory\x12\x12\n" +
+	"\x04tags\x18\x03 \x03(\tR\x04tags\x126\n" +
+	"\n" +
+	"max_rating\x18\x04 \x01(\x0e2\x17.djaas.v1.ContentRatingR\tmaxRating\x12\x12\n" +
+	"\x04lang\x18\x05 \x01(\tR\x04lang\x12&\n" +
+	"\x04type\x18\x06 \x01(\x0e2\x12.djaas.v1.JokeTypeR\x04type\x12\x16\n" +
+	"\x06source\x18\a \x01(\tR\x06source\"D\n" +
+	"\x14GetRandomJokeRequest\x12,\n" +
+	"\x06filter\x18\x01 \x01(\v2\x14.djaas.v1.JokeFilterR\x06filter\" \n" +
+	"\x0eGetJokeRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\"|\n" +
+	"\x10ListJokesRequest\x12,\n" +
+	"\x06filter\x18\x01 \x01(\v2\x14.djaas.v1.JokeFilterR\x06filter\x12\x1b\n" +
+	"\tpage_size\x18\x02 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x03 \x01(\tR\tpageToken\"a\n" +
+	"\x11ListJokesResponse\x12$\n" +
+	"\x05jokes\x18\x01 \x03(\v2\x0e.djaas.v1.JokeR\x05jokes\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"\xda\x03\n" +
+	"\x11CreateJokeRequest\x12\x14\n" +
+	"\x05setup\x18\x01 \x01(\tR\x05setup\x12\x1c\n" +
+	"\tpunchline\x18\x02 \x01(\tR\tpunchline\x12&\n" +
+	"\x04type\x18\x03 \x01(\x0e2\x12.djaas.v1.JokeTypeR\x04type\x12(\n" +
+	"\x05parts\x18\x04 \x03(\v2\x12.djaas.v1.JokePartR\x05parts\x12\x1a\n" +
+	"\bcategory\x18\x05 \x01(\tR\bcategory\x12\x12\n" +
+	"\x04tags\x18\x06 \x03(\tR\x04tags\x12/\n" +
+	"\x06rating\x18\a \x01(\x0e2\x17.djaas.v1.ContentRatingR\x06rating\x12)\n" +
+	"\x10content_warnings\x18\b \x03(\tR\x0fcontentWarnings\x12\x1a\n" +
+	"\blanguage\x18\t \x01(\tR\blanguage\x12%\n" +
+	"\x0etranslation_of\x18\n" +
+	" \x01(\x05R\rtranslationOf\x12\x16\n" +
+	"\x06author\x18\v \x01(\tR\x06author\x12\x1f\n" +
+	"\vsource_name\x18\f \x01(\tR\n" +
+	"sourceName\x12\x1d\n" +
+	"\n" +
+	"source_url\x18\r \x01(\tR\tsourceUrl\x12\x18\n" +
+	"\alicense\x18\x0e \x01(\tR\alicense\"\x11\n" +
+	"\x0fListTagsRequest\"&\n" +
+	"\x10ListTagsResponse\x12\x12\n" +
+	"\x04tags\x18\x01 \x03(\tR\x04tags\"y\n" +
+	"\x12StreamJokesRequest\x12,\n" +
+	"\x06filter\x18\x01 \x01(\v2\x14.djaas.v1.JokeFilterR\x06filter\x125\n" +
+	"\binterval\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\binterval*\x92\x01\n" +
+	"\bJokeType\x12\x19\n" +
+	"\x15JOKE_TYPE_UNSPECIFIED\x10\x00\x12\x1d\n" +
+	"\x19JOKE_TYPE_SETUP_PUNCHLINE\x10\x01\x12\x17\n" +
+	"\x13JOKE_TYPE_ONE_LINER\x10\x02\x12\x19\n" +
+	"\x15JOKE_TYPE_KNOCK_KNOCK\x10\x03\x12\x18\n" +
+	"\x14JOKE_TYPE_MULTI_PART\x10\x04*\x8b\x01\n" +
+	"\rContentRating\x12\x1e\n" +
+	"\x1aCONTENT_RATING_UNSPECIFIED\x10\x00\x12\x14\n" +
+	"\x10CONTENT_RATING_G\x10\x01\x12\x15\n" +
+	"\x11CONTENT_RATING_PG\x10\x02\x12\x17\n" +
+	"\x13CONTENT_RATING_PG13\x10\x03\x12\x14\n" +
+	"\x10CONTENT_RATING_R\x10\x042\x86\x03\n" +
+	"\vJokeService\x12?\n" +
+	"\rGetRandomJoke\x12\x1e.djaas.v1.GetRandomJokeRequest\x1a\x0e.djaas.v1.Joke\x123\n" +
+	"\aGetJoke\x12\x18.djaas.v1.GetJokeRequest\x1a\x0e.djaas.v1.Joke\x12D\n" +
+	"\tListJokes\x12\x1a.djaas.v1.ListJokesRequest\x1a\x1b.djaas.v1.ListJokesResponse\x129\n" +
+	"\n" +
+	"CreateJoke\x12\x1b.djaas.v1.CreateJokeRequest\x1a\x0e.djaas.v1.Joke\x12A\n" +
+	"\bListTags\x12\x19.djaas.v1.ListTagsRequest\x1a\x1a.djaas.v1.ListTagsResponse\x12=\n" +
+	"\vStreamJokes\x12\x1c.djaas.v1.StreamJokesRequest\x1a\x0e.djaas.v1.Joke0\x01B7Z5github.com/cdunlap/djaas/internal/pb/djaas/v1;djaasv1b\x06proto3"
+
+var (
+	file_djaas_v1_djaas_proto_rawDescOnce sync.Once
+	file_djaas_v1_djaas_proto_rawDescData []byte
+)
+
+func file_djaas_v1_djaas_proto_rawDescGZIP() []byte {
+	file_djaas_v1_djaas_proto_rawDescOnce.Do(func() {
+		file_djaas_v1_djaas_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_djaas_v1_djaas_proto_rawDesc), len(file_djaas_v1_djaas_proto_rawDesc)))
+	})
+	return file_djaas_v1_djaas_proto_rawDescData
+}
+
+var file_djaas_v1_djaas_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_djaas_v1_djaas_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_djaas_v1_djaas_proto_goTypes = []any{
+	(JokeType)(0),                 // 0: djaas.v1.JokeType
+	(ContentRating)(0),            // 1: djaas.v1.ContentRating
+	(*JokePart)(nil),              // 2: djaas.v1.JokePart
+	(*Joke)(nil),                  // 3: djaas.v1.Joke
+	(*JokeFilter)(nil),            // 4: djaas.v1.JokeFilter
+	(*GetRandomJokeRequest)(nil),  // 5: djaas.v1.GetRandomJokeRequest
+	(*GetJokeRequest)(nil),        // 6: djaas.v1.GetJokeRequest
+	(*ListJokesRequest)(nil),      // 7: djaas.v1.ListJokesRequest
+	(*ListJokesResponse)(nil),     // 8: djaas.v1.ListJokesResponse
+	(*CreateJokeRequest)(nil),     // 9: djaas.v1.CreateJokeRequest
+	(*ListTagsRequest)(nil),       // 10: djaas.v1.ListTagsRequest
+	(*ListTagsResponse)(nil),      // 11: djaas.v1.ListTagsResponse
+	(*StreamJokesRequest)(nil),    // 12: djaas.v1.StreamJokesRequest
+	(*timestamppb.Timestamp)(nil), // 13: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),   // 14: google.protobuf.Duration
+}
+var file_djaas_v1_djaas_proto_depIdxs = []int32{
+	0,  // 0: djaas.v1.Joke.type:type_name -> djaas.v1.JokeType
+	2,  // 1: djaas.v1.Joke.parts:type_name -> djaas.v1.JokePart
+	1,  // 2: djaas.v1.Joke.rating:type_name -> djaas.v1.ContentRating
+	13, // 3: djaas.v1.Joke.created_at:type_name -> google.protobuf.Timestamp
+	13, // 4: djaas.v1.Joke.updated_at:type_name -> google.protobuf.Timestamp
+	1,  // 5: djaas.v1.JokeFilter.max_rating:type_name -> djaas.v1.ContentRating
+	0,  // 6: djaas.v1.JokeFilter.type:type_name -> djaas.v1.JokeType
+	4,  // 7: djaas.v1.GetRandomJokeRequest.filter:type_name -> djaas.v1.JokeFilter
+	4,  // 8: djaas.v1.ListJokesRequest.filter:type_name -> djaas.v1.JokeFilter
+	3,  // 9: djaas.v1.ListJokesResponse.jokes:type_name -> djaas.v1.Joke
+	0,  // 10: djaas.v1.CreateJokeRequest.type:type_name -> djaas.v1.JokeType
+	2,  // 11: djaas.v1.CreateJokeRequest.parts:type_name -> djaas.v1.JokePart
+	1,  // 12: djaas.v1.CreateJokeRequest.rating:type_name -> djaas.v1.ContentRating
+	4,  // 13: djaas.v1.StreamJokesRequest.filter:type_name -> djaas.v1.JokeFilter
+	14, // 14: djaas.v1.StreamJokesRequest.interval:type_name -> google.protobuf.Duration
+	5,  // 15: djaas.v1.JokeService.GetRandomJoke:input_type -> djaas.v1.GetRandomJokeRequest
+	6,  // 16: djaas.v1.JokeService.GetJoke:input_type -> djaas.v1.GetJokeRequest
+	7,  // 17: djaas.v1.JokeService.ListJokes:input_type -> djaas.v1.ListJokesRequest
+	9,  // 18: djaas.v1.JokeService.CreateJoke:input_type -> djaas.v1.CreateJokeRequest
+	10, // 19: djaas.v1.JokeService.ListTags:input_type -> djaas.v1.ListTagsRequest
+	12, // 20: djaas.v1.JokeService.StreamJokes:input_type -> djaas.v1.StreamJokesRequest
+	3,  // 21: djaas.v1.JokeService.GetRandomJoke:output_type -> djaas.v1.Joke
+	3,  // 22: djaas.v1.JokeService.GetJoke:output_type -> djaas.v1.Joke
+	8,  // 23: djaas.v1.JokeService.ListJokes:output_type -> djaas.v1.ListJokesResponse
+	3,  // 24: djaas.v1.JokeService.CreateJoke:output_type -> djaas.v1.Joke
+	11, // 25: djaas.v1.JokeService.ListTags:output_type -> djaas.v1.ListTagsResponse
+	3,  // 26: djaas.v1.JokeService.StreamJokes:output_type -> djaas.v1.Joke
+	21, // [21:27] is the sub-list for method output_type
+	15, // [15:21] is the sub-list for method input_type
+	15, // [15:15] is the sub-list for extension type_name
+	15, // [15:15] is the sub-list for extension extendee
+	0,  // [0:15] is the sub-list for field type_name
+}
+
+func init() { file_djaas_v1_djaas_proto_init() }
+func file_djaas_v1_djaas_proto_init() {
+	if File_djaas_v1_djaas_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_djaas_v1_djaas_proto_rawDesc), len(file_djaas_v1_djaas_proto_rawDesc)),
+			NumEnums:      2,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_djaas_v1_djaas_proto_goTypes,
+		DependencyIndexes: file_djaas_v1_djaas_proto_depIdxs,
+		EnumInfos:         file_djaas_v1_djaas_proto_enumTypes,
+		MessageInfos:      file_djaas_v1_djaas_proto_msgTypes,
+	}.Build()
+	File_djaas_v1_djaas_proto = out.File
+	file_djaas_v1_djaas_proto_goTypes = nil
+	file_djaas_v1_djaas_proto_depIdxs = nil
+}
@@ -0,0 +1,337 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: djaas/v1/djaas.proto
+
+package djaasv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	JokeService_GetRandomJoke_FullMethodName = "/djaas.v1.JokeService/GetRandomJoke"
+	JokeService_GetJoke_FullMethodName       = "/djaas.v1.JokeService/GetJoke"
+	JokeService_ListJokes_FullMethodName     = "/djaas.v1.JokeService/ListJokes"
+	JokeService_CreateJoke_FullMethodName    = "/djaas.v1.JokeService/CreateJoke"
+	JokeService_ListTags_FullMethodName      = "/djaas.v1.JokeService/ListTags"
+	JokeService_StreamJokes_FullMethodName   = "/djaas.v1.JokeService/StreamJokes"
+)
+
+// JokeServiceClient is the client API for JokeService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// JokeService serves dad jokes to backend services. Calls are authenticated
+// with the same API keys and bearer tokens as the REST API, sent as
+// "x-api-token" or "authorization: Bearer <token>" metadata.
+type JokeServiceClient interface {
+	// GetRandomJoke returns a random joke matching the filter
+	GetRandomJoke(ctx context.Context, in *GetRandomJokeRequest, opts ...grpc.CallOption) (*Joke, error)
+	// GetJoke returns a joke by ID
+	GetJoke(ctx context.Context, in *GetJokeRequest, opts ...grpc.CallOption) (*Joke, error)
+	// ListJokes pages through the jokes matching the filter, oldest first
+	ListJokes(ctx context.Context, in *ListJokesRequest, opts ...grpc.CallOption) (*ListJokesResponse, error)
+	// CreateJoke adds a joke; it requires the jokes:write scope
+	CreateJoke(ctx context.Context, in *CreateJokeRequest, opts ...grpc.CallOption) (*Joke, error)
+	// ListTags returns every tag, sorted by name
+	ListTags(ctx context.Context, in *ListTagsRequest, opts ...grpc.CallOption) (*ListTagsResponse, error)
+	// StreamJokes sends a random joke matching the filter straight away and
+	// then every interval, until the caller cancels
+	StreamJokes(ctx context.Context, in *StreamJokesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Joke], error)
+}
+
+type jokeServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewJokeServiceClient(cc grpc.ClientConnInterface) JokeServiceClient {
+	return &jokeServiceClient{cc}
+}
+
+func (c *jokeServiceClient) GetRandomJoke(ctx context.Context, in *GetRandomJokeRequest, opts ...grpc.CallOption) (*Joke, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Joke)
+	err := c.cc.Invoke(ctx, JokeService_GetRandomJoke_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jokeServiceClient) GetJoke(ctx context.Context, in *GetJokeRequest, opts ...grpc.CallOption) (*Joke, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Joke)
+	err := c.cc.Invoke(ctx, JokeService_GetJoke_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jokeServiceClient) ListJokes(ctx context.Context, in *ListJokesRequest, opts ...grpc.CallOption) (*ListJokesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListJokesResponse)
+	err := c.cc.Invoke(ctx, JokeService_ListJokes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jokeServiceClient) CreateJoke(ctx context.Context, in *CreateJokeRequest, opts ...grpc.CallOption) (*Joke, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Joke)
+	err := c.cc.Invoke(ctx, JokeService_CreateJoke_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jokeServiceClient) ListTags(ctx context.Context, in *ListTagsRequest, opts ...grpc.CallOption) (*ListTagsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListTagsResponse)
+	err := c.cc.Invoke(ctx, JokeService_ListTags_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jokeServiceClient) StreamJokes(ctx context.Context, in *StreamJokesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Joke], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &JokeService_ServiceDesc.Streams[0], JokeService_StreamJokes_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamJokesRequest, Joke]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type JokeService_StreamJokesClient = grpc.ServerStreamingClient[Joke]
+
+// JokeServiceServer is the server API for JokeService service.
+// All implementations must embed UnimplementedJokeServiceServer
+// for forward compatibility.
+//
+// JokeService serves dad jokes to backend services. Calls are authenticated
+// with the same API keys and bearer tokens as the REST API, sent as
+// "x-api-token" or "authorization: Bearer <token>" metadata.
+type JokeServiceServer interface {
+	// GetRandomJoke returns a random joke matching the filter
+	GetRandomJoke(context.Context, *GetRandomJokeRequest) (*Joke, error)
+	// GetJoke returns a joke by ID
+	GetJoke(context.Context, *GetJokeRequest) (*Joke, error)
+	// ListJokes pages through the jokes matching the filter, oldest first
+	ListJokes(context.Context, *ListJokesRequest) (*ListJokesResponse, error)
+	// CreateJoke adds a joke; it requires the jokes:write scope
+	CreateJoke(context.Context, *CreateJokeRequest) (*Joke, error)
+	// ListTags returns every tag, sorted by name
+	ListTags(context.Context, *ListTagsRequest) (*ListTagsResponse, error)
+	// StreamJokes sends a random joke matching the filter straight away and
+	// then every interval, until the caller cancels
+	StreamJokes(*StreamJokesRequest, grpc.ServerStreamingServer[Joke]) error
+	mustEmbedUnimplementedJokeServiceServer()
+}
+
+// UnimplementedJokeServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedJokeServiceServer struct{}
+
+func (UnimplementedJokeServiceServer) GetRandomJoke(context.Context, *GetRandomJokeRequest) (*Joke, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRandomJoke not implemented")
+}
+func (UnimplementedJokeServiceServer) GetJoke(context.Context, *GetJokeRequest) (*Joke, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetJoke not implemented")
+}
+func (UnimplementedJokeServiceServer) ListJokes(context.Context, *ListJokesRequest) (*ListJokesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListJokes not implemented")
+}
+func (UnimplementedJokeServiceServer) CreateJoke(context.Context, *CreateJokeRequest) (*Joke, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateJoke not implemented")
+}
+func (UnimplementedJokeServiceServer) ListTags(context.Context, *ListTagsRequest) (*ListTagsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTags not implemented")
+}
+func (UnimplementedJokeServiceServer) StreamJokes(*StreamJokesRequest, grpc.ServerStreamingServer[Joke]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamJokes not implemented")
+}
+func (UnimplementedJokeServiceServer) mustEmbedUnimplementedJokeServiceServer() {}
+func (UnimplementedJokeServiceServer) testEmbeddedByValue()                     {}
+
+// UnsafeJokeServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to JokeServiceServer will
+// result in compilation errors.
+type UnsafeJokeServiceServer interface {
+	mustEmbedUnimplementedJokeServiceServer()
+}
+
+func RegisterJokeServiceServer(s grpc.ServiceRegistrar, srv JokeServiceServer) {
+	// If the following call pancis, it indicates UnimplementedJokeServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&JokeService_ServiceDesc, srv)
+}
+
+func _JokeService_GetRandomJoke_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRandomJokeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JokeServiceServer).GetRandomJoke(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JokeService_GetRandomJoke_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JokeServiceServer).GetRandomJoke(ctx, req.(*GetRandomJokeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JokeService_GetJoke_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetJokeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JokeServiceServer).GetJoke(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JokeService_GetJoke_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JokeServiceServer).GetJoke(ctx, req.(*GetJokeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JokeService_ListJokes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListJokesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JokeServiceServer).ListJokes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JokeService_ListJokes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JokeServiceServer).ListJokes(ctx, req.(*ListJokesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JokeService_CreateJoke_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateJokeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JokeServiceServer).CreateJoke(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JokeService_CreateJoke_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JokeServiceServer).CreateJoke(ctx, req.(*CreateJokeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JokeService_ListTags_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTagsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JokeServiceServer).ListTags(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JokeService_ListTags_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JokeServiceServer).ListTags(ctx, req.(*ListTagsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JokeService_StreamJokes_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamJokesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(JokeServiceServer).StreamJokes(m, &grpc.GenericServerStream[StreamJokesRequest, Joke]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type JokeService_StreamJokesServer = grpc.ServerStreamingServer[Joke]
+
+// JokeService_ServiceDesc is the grpc.ServiceDesc for JokeService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var JokeService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "djaas.v1.JokeService",
+	HandlerType: (*JokeServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetRandomJoke",
+			Handler:    _JokeService_GetRandomJoke_Handler,
+		},
+		{
+			MethodName: "GetJoke",
+			Handler:    _JokeService_GetJoke_Handler,
+		},
+		{
+			MethodName: "ListJokes",
+			Handler:    _JokeService_ListJokes_Handler,
+		},
+		{
+			MethodName: "CreateJoke",
+			Handler:    _JokeService_CreateJoke_Handler,
+		},
+		{
+			MethodName: "ListTags",
+			Handler:    _JokeService_ListTags_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamJokes",
+			Handler:       _JokeService_StreamJokes_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "djaas/v1/djaas.proto",
+}
@@ -0,0 +1,75 @@
+package render
+
+import (
+	"strconv"
+	"strings"
+)
+
+// mediaRange is one entry of an Accept header, e.g. "text/*;q=0.5"
+type mediaRange struct {
+	typ     string
+	subtype string
+	quality float64
+}
+
+// specificity ranks a range: exact types beat type/* beats */*
+func (m mediaRange) specificity() int {
+	switch {
+	case m.typ == "*":
+		return 0
+	case m.subtype == "*":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// matches reports whether the range covers a media type such as "text/plain"
+func (m mediaRange) matches(typ, subtype string) bool {
+	return (m.typ == "*" || m.typ == typ) && (m.subtype == "*" || m.subtype == subtype)
+}
+
+// parseAccept returns the media ranges in an Accept header. Malformed
+// entries are skipped.
+func parseAccept(header string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		typ, subtype, ok := strings.Cut(strings.ToLower(strings.TrimSpace(params[0])), "/")
+		if !ok || typ == "" || subtype == "" || (typ == "*" && subtype != "*") {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range params[1:] {
+			q, ok := strings.CutPrefix(strings.TrimSpace(param), "q=")
+			if !ok {
+				continue
+			}
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil || parsed < 0 || parsed > 1 {
+				parsed = 0
+			}
+			quality = parsed
+		}
+		ranges = append(ranges, mediaRange{typ: typ, subtype: subtype, quality: quality})
+	}
+	return ranges
+}
+
+// qualityOf returns the quality the Accept ranges give a media type: that of
+// the most specific matching range, or 0 when none matches
+func qualityOf(mediaType string, ranges []mediaRange) float64 {
+	typ, subtype, _ := strings.Cut(mediaType, "/")
+
+	quality, specificity := 0.0, -1
+	for _, r := range ranges {
+		if !r.matches(typ, subtype) {
+			continue
+		}
+		if s := r.specificity(); s > specificity {
+			quality, specificity = r.quality, s
+		}
+	}
+	return quality
+}
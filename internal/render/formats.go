@@ -0,0 +1,195 @@
+package render
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/cdunlap/djaas/internal/model"
+)
+
+// DefaultSSMLPause is how long speech pauses before a punchline
+const DefaultSSMLPause = time.Second
+
+// telling splits a joke into the lines leading up to the punchline and the
+// punchline itself. One-liners have no separate punchline.
+func telling(joke model.Joke) (lead []string, punchline string) {
+	parts := joke.Parts
+	if len(parts) == 0 {
+		parts = []model.JokePart{{Text: joke.Setup}, {Text: joke.Punchline}}
+	}
+
+	for _, part := range parts {
+		lead = append(lead, part.Text)
+	}
+	if joke.Type == model.TypeOneLiner || len(lead) < 2 {
+		return lead, ""
+	}
+	return lead[:len(lead)-1], lead[len(lead)-1]
+}
+
+// JSON renders the full response body as JSON
+type JSON struct{}
+
+// ContentType implements Renderer
+func (JSON) ContentType() string { return "application/json" }
+
+// Render implements Renderer
+func (JSON) Render(w io.Writer, resp Response) error {
+	return json.NewEncoder(w).Encode(resp.Body)
+}
+
+// Text renders jokes as plain lines, for terminals and shell prompts. Jokes
+// are separated by a blank line.
+type Text struct{}
+
+// ContentType implements Renderer
+func (Text) ContentType() string { return "text/plain; charset=utf-8" }
+
+// Render implements Renderer
+func (Text) Render(w io.Writer, resp Response) error {
+	bw := bufio.NewWriter(w)
+	for i, joke := range resp.Jokes {
+		if i > 0 {
+			bw.WriteString("\n")
+		}
+		lead, punchline := telling(joke)
+		for _, line := range lead {
+			bw.WriteString(line + "\n")
+		}
+		if punchline != "" {
+			bw.WriteString(punchline + "\n")
+		}
+	}
+	return bw.Flush()
+}
+
+// markdownEscaper escapes the characters Markdown would treat as formatting
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`,
+	`<`, `\<`, `>`, `\>`, `#`, `\#`, `|`, `\|`, `~`, `\~`,
+)
+
+// Markdown renders each line of a joke as a paragraph with the punchline in
+// bold. Jokes are separated by a horizontal rule.
+type Markdown struct{}
+
+// ContentType implements Renderer
+func (Markdown) ContentType() string { return "text/markdown; charset=utf-8" }
+
+// Render implements Renderer
+func (Markdown) Render(w io.Writer, resp Response) error {
+	bw := bufio.NewWriter(w)
+	for i, joke := range resp.Jokes {
+		if i > 0 {
+			bw.WriteString("---\n\n")
+		}
+		lead, punchline := telling(joke)
+		for _, line := range lead {
+			bw.WriteString(markdownEscaper.Replace(line) + "\n\n")
+		}
+		if punchline != "" {
+			bw.WriteString("**" + markdownEscaper.Replace(punchline) + "**\n\n")
+		}
+	}
+	return bw.Flush()
+}
+
+var htmlTemplate = template.Must(template.New("jokes").Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}">
+<head>
+<meta charset="utf-8">
+<title>{{if eq (len .Jokes) 1}}Dad Joke{{else}}Dad Jokes{{end}}</title>
+</head>
+<body>
+{{- range .Jokes}}
+<article class="joke" lang="{{.Lang}}">
+{{- range .Lead}}
+<p>{{.}}</p>
+{{- end}}
+{{- if .Punchline}}
+<p class="punchline"><strong>{{.Punchline}}</strong></p>
+{{- end}}
+</article>
+{{- end}}
+</body>
+</html>
+`))
+
+// HTML renders jokes as a standalone HTML page
+type HTML struct{}
+
+// ContentType implements Renderer
+func (HTML) ContentType() string { return "text/html; charset=utf-8" }
+
+// Render implements Renderer
+func (HTML) Render(w io.Writer, resp Response) error {
+	type htmlJoke struct {
+		Lang      string
+		Lead      []string
+		Punchline string
+	}
+
+	page := struct {
+		Lang  string
+		Jokes []htmlJoke
+	}{Lang: documentLanguage(resp.Jokes)}
+	for _, joke := range resp.Jokes {
+		lead, punchline := telling(joke)
+		page.Jokes = append(page.Jokes, htmlJoke{Lang: joke.Language, Lead: lead, Punchline: punchline})
+	}
+
+	return htmlTemplate.Execute(w, page)
+}
+
+// SSML renders jokes as speech markup for voice assistants, with a pause
+// before each punchline
+type SSML struct {
+	Pause time.Duration
+}
+
+// ContentType implements Renderer
+func (SSML) ContentType() string { return "application/ssml+xml; charset=utf-8" }
+
+// Render implements Renderer
+func (s SSML) Render(w io.Writer, resp Response) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<speak version="1.1" xmlns="http://www.w3.org/2001/10/synthesis" xml:lang="%s">`+"\n", escapeXML(documentLanguage(resp.Jokes)))
+	for _, joke := range resp.Jokes {
+		fmt.Fprintf(bw, `<p xml:lang="%s">`, escapeXML(joke.Language))
+		lead, punchline := telling(joke)
+		for _, line := range lead {
+			bw.WriteString("<s>" + escapeXML(line) + "</s>")
+		}
+		if punchline != "" {
+			if s.Pause > 0 {
+				fmt.Fprintf(bw, `<break time="%dms"/>`, s.Pause.Milliseconds())
+			}
+			bw.WriteString("<s>" + escapeXML(punchline) + "</s>")
+		}
+		bw.WriteString("</p>\n")
+	}
+	bw.WriteString("</speak>\n")
+	return bw.Flush()
+}
+
+// escapeXML escapes text for use in XML content or attribute values
+func escapeXML(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// documentLanguage is the language of the first joke, or English when there
+// are none
+func documentLanguage(jokes []model.Joke) string {
+	if len(jokes) > 0 && jokes[0].Language != "" {
+		return jokes[0].Language
+	}
+	return "en"
+}
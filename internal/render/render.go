@@ -0,0 +1,124 @@
+// Package render writes joke responses in the formats callers ask for,
+// chosen from the format query parameter or the Accept header
+package render
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+
+	"github.com/cdunlap/djaas/internal/model"
+)
+
+var (
+	// ErrUnknownFormat is returned for a format name no renderer is registered under
+	ErrUnknownFormat = errors.New("unknown format")
+	// ErrNotAcceptable is returned when no renderer produces a media type the
+	// Accept header allows
+	ErrNotAcceptable = errors.New("no acceptable format")
+)
+
+// Response is a joke endpoint's response. Body is the full JSON document;
+// formats meant for reading or listening show only the jokes.
+type Response struct {
+	Body  any
+	Jokes []model.Joke
+}
+
+// Renderer writes responses in one media type
+type Renderer interface {
+	// ContentType is the Content-Type header value, e.g. "text/plain; charset=utf-8"
+	ContentType() string
+	Render(w io.Writer, resp Response) error
+}
+
+// Registry holds the renderers and chooses between them
+type Registry struct {
+	renderers  []registered
+	formats    map[string]Renderer
+	formatList []string
+}
+
+type registered struct {
+	mediaType string
+	renderer  Renderer
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{formats: make(map[string]Renderer)}
+}
+
+// Default returns a registry with the built-in renderers. JSON is first, so
+// it is served when the caller expresses no preference.
+func Default() *Registry {
+	registry := NewRegistry()
+	registry.Register(JSON{}, "json")
+	registry.Register(Text{}, "text", "txt")
+	registry.Register(HTML{}, "html")
+	registry.Register(Markdown{}, "markdown", "md")
+	registry.Register(SSML{Pause: DefaultSSMLPause}, "ssml")
+	return registry
+}
+
+// Register adds a renderer, selectable with any of the given format names.
+// The first renderer registered is the default. Register panics if the
+// renderer's content type is invalid, as that is a programming error.
+func (reg *Registry) Register(renderer Renderer, formats ...string) {
+	mediaType, _, err := mime.ParseMediaType(renderer.ContentType())
+	if err != nil {
+		panic(fmt.Sprintf("render: invalid content type %q: %v", renderer.ContentType(), err))
+	}
+
+	reg.renderers = append(reg.renderers, registered{mediaType: mediaType, renderer: renderer})
+	for _, format := range formats {
+		format = strings.ToLower(format)
+		if _, ok := reg.formats[format]; !ok {
+			reg.formatList = append(reg.formatList, format)
+		}
+		reg.formats[format] = renderer
+	}
+}
+
+// Formats lists the registered format names in registration order
+func (reg *Registry) Formats() []string {
+	return reg.formatList
+}
+
+// Negotiate picks the renderer for a request. A format name takes
+// precedence over the Accept header; with neither, the default is used.
+func (reg *Registry) Negotiate(format, accept string) (Renderer, error) {
+	if len(reg.renderers) == 0 {
+		return nil, ErrNotAcceptable
+	}
+
+	if format != "" {
+		renderer, ok := reg.formats[strings.ToLower(format)]
+		if !ok {
+			return nil, fmt.Errorf("%w %q", ErrUnknownFormat, format)
+		}
+		return renderer, nil
+	}
+
+	ranges := parseAccept(accept)
+	if len(ranges) == 0 {
+		return reg.renderers[0].renderer, nil
+	}
+
+	// Each renderer takes the quality of the most specific range matching
+	// it; ties go to the earlier registration
+	var best Renderer
+	bestQuality := 0.0
+	for _, candidate := range reg.renderers {
+		quality := qualityOf(candidate.mediaType, ranges)
+		if quality > bestQuality {
+			best, bestQuality = candidate.renderer, quality
+		}
+	}
+	if best == nil {
+		return nil, ErrNotAcceptable
+	}
+	return best, nil
+}
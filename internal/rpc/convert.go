@@ -0,0 +1,117 @@
+package rpc
+
+import (
+	"github.com/cdunlap/djaas/internal/model"
+	djaasv1 "github.com/cdunlap/djaas/internal/pb/djaas/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// jokeTypes maps protobuf joke types to model joke types
+var jokeTypes = map[djaasv1.JokeType]model.JokeType{
+	djaasv1.JokeType_JOKE_TYPE_SETUP_PUNCHLINE: model.TypeSetupPunchline,
+	djaasv1.JokeType_JOKE_TYPE_ONE_LINER:       model.TypeOneLiner,
+	djaasv1.JokeType_JOKE_TYPE_KNOCK_KNOCK:     model.TypeKnockKnock,
+	djaasv1.JokeType_JOKE_TYPE_MULTI_PART:      model.TypeMultiPart,
+}
+
+// ratings maps protobuf content ratings to model ratings
+var ratings = map[djaasv1.ContentRating]model.ContentRating{
+	djaasv1.ContentRating_CONTENT_RATING_G:    model.RatingG,
+	djaasv1.ContentRating_CONTENT_RATING_PG:   model.RatingPG,
+	djaasv1.ContentRating_CONTENT_RATING_PG13: model.RatingPG13,
+	djaasv1.ContentRating_CONTENT_RATING_R:    model.RatingR,
+}
+
+// fromProtoJokeType converts a joke type, reporting false for unknown
+// values. Unspecified converts to "", meaning any type.
+func fromProtoJokeType(jokeType djaasv1.JokeType) (model.JokeType, bool) {
+	if jokeType == djaasv1.JokeType_JOKE_TYPE_UNSPECIFIED {
+		return "", true
+	}
+	converted, ok := jokeTypes[jokeType]
+	return converted, ok
+}
+
+// fromProtoRating converts a content rating, reporting false for unknown
+// values. Unspecified converts to "".
+func fromProtoRating(rating djaasv1.ContentRating) (model.ContentRating, bool) {
+	if rating == djaasv1.ContentRating_CONTENT_RATING_UNSPECIFIED {
+		return "", true
+	}
+	converted, ok := ratings[rating]
+	return converted, ok
+}
+
+// toProtoJoke converts a joke for a response
+func toProtoJoke(joke model.Joke) *djaasv1.Joke {
+	converted := &djaasv1.Joke{
+		Id:              joke.ID,
+		Setup:           joke.Setup,
+		Punchline:       joke.Punchline,
+		Parts:           toProtoParts(joke.Parts),
+		Category:        valueOf(joke.Category),
+		Tags:            joke.Tags,
+		ContentWarnings: joke.ContentWarnings,
+		Flagged:         joke.Flagged,
+		Language:        joke.Language,
+		Author:          valueOf(joke.Author),
+		SourceName:      valueOf(joke.SourceName),
+		SourceUrl:       valueOf(joke.SourceURL),
+		License:         valueOf(joke.License),
+		CreatedAt:       timestamppb.New(joke.CreatedAt),
+		UpdatedAt:       timestamppb.New(joke.UpdatedAt),
+	}
+
+	for protoType, jokeType := range jokeTypes {
+		if jokeType == joke.Type {
+			converted.Type = protoType
+		}
+	}
+	for protoRating, rating := range ratings {
+		if rating == joke.Rating {
+			converted.Rating = protoRating
+		}
+	}
+	if joke.TranslationOf != nil {
+		converted.TranslationOf = *joke.TranslationOf
+	}
+
+	return converted
+}
+
+// toProtoParts converts a joke's parts
+func toProtoParts(parts []model.JokePart) []*djaasv1.JokePart {
+	converted := make([]*djaasv1.JokePart, 0, len(parts))
+	for _, part := range parts {
+		converted = append(converted, &djaasv1.JokePart{Speaker: part.Speaker, Text: part.Text})
+	}
+	return converted
+}
+
+// fromProtoParts converts the parts of a new joke
+func fromProtoParts(parts []*djaasv1.JokePart) []model.JokePart {
+	if len(parts) == 0 {
+		return nil
+	}
+	converted := make([]model.JokePart, 0, len(parts))
+	for _, part := range parts {
+		converted = append(converted, model.JokePart{Speaker: part.GetSpeaker(), Text: part.GetText()})
+	}
+	return converted
+}
+
+// valueOf returns the string s points to, or "" for nil
+func valueOf(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// optional returns a pointer to s, or nil when s is empty
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
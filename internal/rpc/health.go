@@ -0,0 +1,87 @@
+package rpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/cdunlap/djaas/internal/health"
+	djaasv1 "github.com/cdunlap/djaas/internal/pb/djaas/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// healthWatchInterval is how often a watched status is checked for changes
+const healthWatchInterval = 5 * time.Second
+
+// healthServer implements the standard gRPC health service with the same
+// readiness checks as /readyz. The server as a whole ("") and JokeService
+// are reported; both are serving when every required check passes and the
+// service is not draining.
+type healthServer struct {
+	healthpb.UnimplementedHealthServer
+
+	checks  *health.Health
+	closing <-chan struct{}
+}
+
+// Check reports the current status of a service
+func (h *healthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if !knownService(req.GetService()) {
+		return nil, status.Error(codes.NotFound, "unknown service")
+	}
+	return &healthpb.HealthCheckResponse{Status: h.status(ctx)}, nil
+}
+
+// List reports the status of every service
+func (h *healthServer) List(ctx context.Context, _ *healthpb.HealthListRequest) (*healthpb.HealthListResponse, error) {
+	current := &healthpb.HealthCheckResponse{Status: h.status(ctx)}
+	return &healthpb.HealthListResponse{Statuses: map[string]*healthpb.HealthCheckResponse{
+		"": current,
+		djaasv1.JokeService_ServiceDesc.ServiceName: current,
+	}}, nil
+}
+
+// Watch sends the status of a service, then again whenever it changes,
+// until the caller cancels or the server shuts down
+func (h *healthServer) Watch(req *healthpb.HealthCheckRequest, stream grpc.ServerStreamingServer[healthpb.HealthCheckResponse]) error {
+	ctx := stream.Context()
+	if !knownService(req.GetService()) {
+		return stream.Send(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVICE_UNKNOWN})
+	}
+
+	ticker := time.NewTicker(healthWatchInterval)
+	defer ticker.Stop()
+
+	last := healthpb.HealthCheckResponse_UNKNOWN
+	for {
+		if current := h.status(ctx); current != last {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: current}); err != nil {
+				return err
+			}
+			last = current
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-h.closing:
+			return stream.Send(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_NOT_SERVING})
+		case <-ticker.C:
+		}
+	}
+}
+
+// status runs the readiness checks
+func (h *healthServer) status(ctx context.Context) healthpb.HealthCheckResponse_ServingStatus {
+	if h.checks.Check(ctx).Ready {
+		return healthpb.HealthCheckResponse_SERVING
+	}
+	return healthpb.HealthCheckResponse_NOT_SERVING
+}
+
+// knownService reports whether the health service reports on service
+func knownService(service string) bool {
+	return service == "" || service == djaasv1.JokeService_ServiceDesc.ServiceName
+}
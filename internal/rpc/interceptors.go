@@ -0,0 +1,303 @@
+package rpc
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/cdunlap/djaas/internal/audit"
+	"github.com/cdunlap/djaas/internal/auth"
+	"github.com/cdunlap/djaas/internal/logging"
+	"github.com/cdunlap/djaas/internal/middleware"
+	"github.com/cdunlap/djaas/internal/model"
+	djaasv1 "github.com/cdunlap/djaas/internal/pb/djaas/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// metadataRequestID carries the request ID in both directions, like the
+// X-Request-ID header
+const metadataRequestID = "x-request-id"
+
+// errorDomain identifies this service in ErrorInfo details
+const errorDomain = "djaas"
+
+// requiredScopes lists the scopes each method needs beyond being
+// authenticated; methods not listed are open to anonymous callers
+var requiredScopes = map[string][]model.Scope{
+	djaasv1.JokeService_CreateJoke_FullMethodName: {model.ScopeJokesWrite},
+}
+
+type (
+	requestIDKey     struct{}
+	principalKey     struct{}
+	ratingCeilingKey struct{}
+	callFieldsKey    struct{}
+)
+
+// callFields collects values set by later interceptors for the call log line
+type callFields struct {
+	principal string
+}
+
+// logUnary identifies and logs each unary call; see startCall
+func (s *Server) logUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	ctx, fields := s.startCall(ctx)
+	resp, err := handler(ctx, req)
+	s.logCall(ctx, info.FullMethod, start, fields, err)
+	return resp, err
+}
+
+// logStream identifies and logs each streaming call; see startCall
+func (s *Server) logStream(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	ctx, fields := s.startCall(stream.Context())
+	err := handler(srv, &serverStream{ServerStream: stream, ctx: ctx})
+	s.logCall(ctx, info.FullMethod, start, fields, err)
+	return err
+}
+
+// startCall gives a call a request ID, keeping the caller's x-request-id
+// metadata if it is a plausible ID. The ID is returned in the response
+// headers, included in errors, and added to a call logger stored in the
+// context (see logging.FromContext).
+func (s *Server) startCall(ctx context.Context) (context.Context, *callFields) {
+	id := firstMetadata(ctx, metadataRequestID)
+	if !middleware.ValidRequestID(id) {
+		id = rand.Text()
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(metadataRequestID, id))
+
+	fields := &callFields{}
+	ctx = context.WithValue(ctx, requestIDKey{}, id)
+	ctx = context.WithValue(ctx, callFieldsKey{}, fields)
+	ctx = logging.NewContext(ctx, s.logger.With("request_id", id))
+	return ctx, fields
+}
+
+// logCall writes the log line for a finished call
+func (s *Server) logCall(ctx context.Context, method string, start time.Time, fields *callFields, err error) {
+	s.log(ctx).InfoContext(ctx, "rpc completed",
+		"method", method,
+		"code", status.Code(err).String(),
+		"duration_ms", time.Since(start).Milliseconds(),
+		"client_ip", clientIP(ctx),
+		"user_agent", firstMetadata(ctx, "user-agent"),
+		"principal", fields.principal,
+	)
+}
+
+// recoverUnary turns a panic in a unary call into an Internal error
+func (s *Server) recoverUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer s.recoverCall(ctx, info.FullMethod, &err)
+	return handler(ctx, req)
+}
+
+// recoverStream turns a panic in a streaming call into an Internal error
+func (s *Server) recoverStream(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer s.recoverCall(stream.Context(), info.FullMethod, &err)
+	return handler(srv, stream)
+}
+
+// recoverCall logs a recovered panic with its stack trace; it must be
+// deferred
+func (s *Server) recoverCall(ctx context.Context, method string, err *error) {
+	if recovered := recover(); recovered != nil {
+		s.log(ctx).ErrorContext(ctx, "panic recovered",
+			"error", recovered,
+			"method", method,
+			"stack", string(debug.Stack()),
+		)
+		*err = statusError(ctx, codes.Internal, "internal_error", "An internal error occurred")
+	}
+}
+
+// authenticateUnary authenticates a unary call; see authenticate
+func (s *Server) authenticateUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := s.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// authenticateStream authenticates a streaming call; see authenticate
+func (s *Server) authenticateStream(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := s.authenticate(stream.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &serverStream{ServerStream: stream, ctx: ctx})
+}
+
+// authenticate identifies the caller with the first authenticator that
+// finds credentials in the call's metadata, as middleware.Authenticate does
+// for HTTP requests, then stores the caller's rating ceiling and audit
+// actor in the context. Calls without credentials continue
+// anonymously unless the method needs scopes.
+func (s *Server) authenticate(ctx context.Context, method string) (context.Context, error) {
+	principal, err := s.identify(ctx, method)
+	if err != nil {
+		return nil, err
+	}
+
+	if scopes, ok := requiredScopes[method]; ok {
+		if principal == nil {
+			return nil, statusError(ctx, codes.Unauthenticated, "unauthorized", "Authentication required: send x-api-token or authorization: Bearer metadata")
+		}
+		for _, scope := range scopes {
+			if !principal.HasScope(scope) {
+				return nil, statusError(ctx, codes.PermissionDenied, "forbidden", "This operation requires the "+string(scope)+" scope")
+			}
+		}
+	}
+
+	ceiling := s.config.DefaultMaxRating
+	subject := "anonymous"
+	if principal != nil {
+		subject = principal.Subject
+		if principal.MaxRating != nil {
+			ceiling = *principal.MaxRating
+		}
+		if fields, ok := ctx.Value(callFieldsKey{}).(*callFields); ok {
+			fields.principal = principal.Subject
+		}
+	}
+
+	ctx = context.WithValue(ctx, principalKey{}, principal)
+	ctx = context.WithValue(ctx, ratingCeilingKey{}, ceiling)
+	ctx = audit.WithActor(ctx, audit.Actor{
+		Subject:   subject,
+		ClientIP:  clientIP(ctx),
+		RequestID: requestIDFromContext(ctx),
+	})
+	return ctx, nil
+}
+
+// identify runs the authenticators. They read credentials from request
+// headers, so the call's metadata is presented to them as one.
+func (s *Server) identify(ctx context.Context, method string) (*model.Principal, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if len(md) == 0 {
+		return nil, nil
+	}
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, method, nil)
+	if err != nil {
+		return nil, statusError(ctx, codes.Internal, "internal_error", "An internal error occurred")
+	}
+	for key, values := range md {
+		if strings.HasPrefix(key, ":") {
+			continue
+		}
+		for _, value := range values {
+			r.Header.Add(key, value)
+		}
+	}
+
+	if middleware.HasCredentials(r) {
+		if wait := s.config.AuthThrottle.Blocked(clientIP(ctx)); wait > 0 {
+			return nil, statusError(ctx, codes.ResourceExhausted, "rate_limit_exceeded", "Too many failed authentication attempts, please try again later",
+				&errdetails.RetryInfo{RetryDelay: durationpb.New(wait)})
+		}
+	}
+
+	for _, authenticator := range s.authenticators {
+		principal, err := authenticator.Authenticate(r)
+		if errors.Is(err, auth.ErrNoCredentials) {
+			continue
+		}
+		if err != nil {
+			if errors.Is(err, auth.ErrInvalidCredentials) {
+				s.log(ctx).DebugContext(ctx, "rejected credentials", "error", err)
+				s.config.AuthThrottle.Failed(ctx, clientIP(ctx))
+				return nil, statusError(ctx, codes.Unauthenticated, "invalid_credentials", "The API key or bearer token is invalid, expired or revoked")
+			}
+			s.log(ctx).ErrorContext(ctx, "failed to authenticate call", "error", err)
+			return nil, statusError(ctx, codes.Unavailable, "auth_unavailable", "Credentials could not be checked, please try again later")
+		}
+		return principal, nil
+	}
+
+	return nil, nil
+}
+
+// serverStream replaces a stream's context
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// requestIDFromContext returns the ID assigned by startCall
+func requestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// principalFromContext returns the caller identified by authenticate, or
+// nil for anonymous calls
+func principalFromContext(ctx context.Context) *model.Principal {
+	principal, _ := ctx.Value(principalKey{}).(*model.Principal)
+	return principal
+}
+
+// ratingCeilingFromContext returns the caller's rating ceiling, defaulting
+// to the most family-friendly rating when none was set
+func ratingCeilingFromContext(ctx context.Context) model.ContentRating {
+	if rating, ok := ctx.Value(ratingCeilingKey{}).(model.ContentRating); ok {
+		return rating
+	}
+	return model.RatingG
+}
+
+// firstMetadata returns the first value of an incoming metadata key
+func firstMetadata(ctx context.Context, key string) string {
+	if values := metadata.ValueFromIncomingContext(ctx, key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// clientIP returns the address of the caller's connection
+func clientIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// statusError builds a gRPC error carrying the same error code as the
+// REST API's error responses, as ErrorInfo, and the request ID, as
+// RequestInfo, followed by any other details
+func statusError(ctx context.Context, code codes.Code, reason, message string, details ...protoadapt.MessageV1) error {
+	st := status.New(code, message)
+	detailed, err := st.WithDetails(append([]protoadapt.MessageV1{
+		&errdetails.ErrorInfo{Reason: reason, Domain: errorDomain},
+		&errdetails.RequestInfo{RequestId: requestIDFromContext(ctx)},
+	}, details...)...)
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
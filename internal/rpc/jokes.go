@@ -0,0 +1,292 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cdunlap/djaas/internal/language"
+	"github.com/cdunlap/djaas/internal/model"
+	djaasv1 "github.com/cdunlap/djaas/internal/pb/djaas/v1"
+	"github.com/cdunlap/djaas/internal/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	defaultStreamInterval = 30 * time.Second
+	minStreamInterval     = 5 * time.Second
+	maxStreamInterval     = time.Hour
+)
+
+// GetRandomJoke returns a random joke matching the filter
+func (s *Server) GetRandomJoke(ctx context.Context, req *djaasv1.GetRandomJokeRequest) (*djaasv1.Joke, error) {
+	query, filter, err := s.jokeFilter(ctx, req.GetFilter())
+	if err != nil {
+		return nil, err
+	}
+
+	joke, err := s.jokes.FindJoke(ctx, query, filter)
+	if err != nil {
+		return nil, s.serviceError(ctx, err)
+	}
+
+	s.metrics.JokeServed(joke.Category)
+	return toProtoJoke(*joke), nil
+}
+
+// GetJoke returns a joke by ID
+func (s *Server) GetJoke(ctx context.Context, req *djaasv1.GetJokeRequest) (*djaasv1.Joke, error) {
+	if req.GetId() <= 0 {
+		return nil, statusError(ctx, codes.InvalidArgument, "invalid_id", "id must be a positive integer")
+	}
+
+	filter := service.JokeFilter{MaxRating: ratingCeilingFromContext(ctx)}
+	joke, err := s.jokes.GetJoke(ctx, req.GetId(), filter)
+	if err != nil {
+		return nil, s.serviceError(ctx, err)
+	}
+
+	return toProtoJoke(*joke), nil
+}
+
+// ListJokes pages through the jokes matching the filter, oldest first
+func (s *Server) ListJokes(ctx context.Context, req *djaasv1.ListJokesRequest) (*djaasv1.ListJokesResponse, error) {
+	query, filter, err := s.jokeFilter(ctx, req.GetFilter())
+	if err != nil {
+		return nil, err
+	}
+	if req.GetPageSize() < 0 || req.GetPageSize() > service.MaxJokeListLimit {
+		return nil, statusError(ctx, codes.InvalidArgument, "invalid_page_size", "page_size must be between 1 and 100")
+	}
+
+	page := service.JokePage{Limit: int(req.GetPageSize()), Cursor: req.GetPageToken()}
+	list, err := s.jokes.ListJokes(ctx, query, filter, page)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidInput) && page.Cursor != "" {
+			return nil, statusError(ctx, codes.InvalidArgument, "invalid_page_token", "page_token must be the next_page_token of a previous page")
+		}
+		return nil, s.serviceError(ctx, err)
+	}
+
+	resp := &djaasv1.ListJokesResponse{
+		Jokes:         make([]*djaasv1.Joke, 0, len(list.Jokes)),
+		NextPageToken: list.NextCursor,
+	}
+	for _, joke := range list.Jokes {
+		resp.Jokes = append(resp.Jokes, toProtoJoke(joke))
+	}
+	return resp, nil
+}
+
+// CreateJoke adds a joke. The interceptors have already checked the
+// caller holds the jokes:write scope.
+func (s *Server) CreateJoke(ctx context.Context, req *djaasv1.CreateJokeRequest) (*djaasv1.Joke, error) {
+	newJoke, err := toNewJoke(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	joke, err := s.jokes.CreateJoke(ctx, newJoke)
+	if err != nil {
+		return nil, s.serviceError(ctx, err)
+	}
+
+	return toProtoJoke(*joke), nil
+}
+
+// ListTags returns every tag
+func (s *Server) ListTags(ctx context.Context, _ *djaasv1.ListTagsRequest) (*djaasv1.ListTagsResponse, error) {
+	tags, err := s.jokes.GetAllTags(ctx)
+	if err != nil {
+		return nil, s.serviceError(ctx, err)
+	}
+	return &djaasv1.ListTagsResponse{Tags: tags}, nil
+}
+
+// StreamJokes sends a joke straight away and then every interval. A filter
+// that matches nothing fails the call before anything is sent; later
+// failures skip a joke but keep the stream open. Each joke after the first
+// is charged to the caller's rate limit and daily quota like the call
+// itself, and the stream ends with the rejection when it is over them.
+// Streams end when the server shuts down, so callers reconnect to another
+// instance.
+func (s *Server) StreamJokes(req *djaasv1.StreamJokesRequest, stream grpc.ServerStreamingServer[djaasv1.Joke]) error {
+	ctx := stream.Context()
+
+	interval := defaultStreamInterval
+	if req.GetInterval() != nil {
+		interval = req.GetInterval().AsDuration()
+		if err := req.GetInterval().CheckValid(); err != nil || interval < minStreamInterval || interval > maxStreamInterval {
+			return statusError(ctx, codes.InvalidArgument, "invalid_interval", "interval must be between 5s and 1h")
+		}
+	}
+
+	query, filter, err := s.jokeFilter(ctx, req.GetFilter())
+	if err != nil {
+		return err
+	}
+
+	joke, err := s.jokes.FindJoke(ctx, query, filter)
+	if err != nil {
+		return s.serviceError(ctx, err)
+	}
+
+	policy := "read"
+	if query.Search != "" {
+		policy = "search"
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var lastJokeID int32
+	for {
+		if joke != nil {
+			s.metrics.JokeServed(joke.Category)
+			if err := stream.Send(toProtoJoke(*joke)); err != nil {
+				return err
+			}
+			lastJokeID = joke.ID
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.closing:
+			return statusError(ctx, codes.Unavailable, "shutting_down", "The server is shutting down, please reconnect")
+		case <-ticker.C:
+		}
+
+		if err := s.rateLimit(ctx, policy); err != nil {
+			return err
+		}
+		joke, err = s.nextStreamJoke(ctx, query, filter, lastJokeID)
+		if err != nil && ctx.Err() == nil && !errors.Is(err, service.ErrNoJokesFound) {
+			s.log(ctx).WarnContext(ctx, "skipped joke in stream", "error", err)
+		}
+	}
+}
+
+// nextStreamJoke finds a random joke for a stream, trying once more if it
+// repeats the previous joke
+func (s *Server) nextStreamJoke(ctx context.Context, query service.JokeQuery, filter service.JokeFilter, previousID int32) (*model.Joke, error) {
+	joke, err := s.jokes.FindJoke(ctx, query, filter)
+	if err == nil && joke.ID == previousID {
+		joke, err = s.jokes.FindJoke(ctx, query, filter)
+	}
+	return joke, err
+}
+
+// jokeFilter converts a request's filter, capping its rating at the
+// caller's ceiling as the HTTP API does
+func (s *Server) jokeFilter(ctx context.Context, f *djaasv1.JokeFilter) (service.JokeQuery, service.JokeFilter, error) {
+	ceiling := ratingCeilingFromContext(ctx)
+	filter := service.JokeFilter{MaxRating: ceiling, Source: f.GetSource()}
+
+	maxRating, ok := fromProtoRating(f.GetMaxRating())
+	if !ok {
+		return service.JokeQuery{}, filter, statusError(ctx, codes.InvalidArgument, "invalid_rating", "max_rating is not a known content rating")
+	}
+	if maxRating != "" && !maxRating.Exceeds(ceiling) {
+		filter.MaxRating = maxRating
+	}
+
+	languages, err := language.Preferences(f.GetLang(), "")
+	if err != nil {
+		return service.JokeQuery{}, filter, statusError(ctx, codes.InvalidArgument, "invalid_language", "lang must be a language code such as en, es or de")
+	}
+	filter.Languages = languages
+
+	jokeType, ok := fromProtoJokeType(f.GetType())
+	if !ok {
+		return service.JokeQuery{}, filter, statusError(ctx, codes.InvalidArgument, "invalid_type", "type is not a known joke type")
+	}
+	filter.Type = jokeType
+
+	query := service.JokeQuery{Search: f.GetSearch(), Category: f.GetCategory()}
+	for _, tag := range f.GetTags() {
+		if trimmed := strings.TrimSpace(tag); trimmed != "" {
+			query.Tags = append(query.Tags, trimmed)
+		}
+	}
+
+	return query, filter, nil
+}
+
+// toNewJoke validates a CreateJokeRequest and converts it for the service
+// layer. Structural checks, such as the parts each joke type needs, are
+// left to JokeService.
+func toNewJoke(ctx context.Context, req *djaasv1.CreateJokeRequest) (service.NewJoke, error) {
+	invalid := func(reason, message string) (service.NewJoke, error) {
+		return service.NewJoke{}, statusError(ctx, codes.InvalidArgument, reason, message)
+	}
+
+	jokeType, ok := fromProtoJokeType(req.GetType())
+	if !ok {
+		return invalid("invalid_type", "type is not a known joke type")
+	}
+
+	rating, ok := fromProtoRating(req.GetRating())
+	if !ok {
+		return invalid("invalid_rating", "rating is not a known content rating")
+	}
+
+	if req.GetLanguage() != "" {
+		if _, err := language.Normalize(req.GetLanguage()); err != nil {
+			return invalid("invalid_language", "language must be a language code such as en, es or de")
+		}
+	}
+
+	if req.GetSourceUrl() != "" {
+		if u, err := url.Parse(req.GetSourceUrl()); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return invalid("invalid_source_url", "source_url must be an absolute http or https URL")
+		}
+	}
+
+	var translationOf *int32
+	if req.GetTranslationOf() != 0 {
+		id := req.GetTranslationOf()
+		translationOf = &id
+	}
+
+	return service.NewJoke{
+		Setup:           req.GetSetup(),
+		Punchline:       req.GetPunchline(),
+		Type:            jokeType,
+		Parts:           fromProtoParts(req.GetParts()),
+		Category:        optional(req.GetCategory()),
+		Tags:            req.GetTags(),
+		Rating:          rating,
+		ContentWarnings: req.GetContentWarnings(),
+		Language:        req.GetLanguage(),
+		TranslationOf:   translationOf,
+		Author:          optional(req.GetAuthor()),
+		SourceName:      optional(req.GetSourceName()),
+		SourceURL:       optional(req.GetSourceUrl()),
+		License:         optional(req.GetLicense()),
+	}, nil
+}
+
+// serviceError maps a JokeService error to a gRPC error
+func (s *Server) serviceError(ctx context.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrNoJokesFound):
+		s.metrics.NoJokesFound()
+		return statusError(ctx, codes.NotFound, "not_found", "No jokes found matching your criteria")
+	case errors.Is(err, service.ErrJokeNotFound):
+		return statusError(ctx, codes.NotFound, "not_found", "Joke not found")
+	case errors.Is(err, service.ErrInvalidTranslation):
+		return statusError(ctx, codes.InvalidArgument, "invalid_translation", "translation_of must reference an existing joke with no translation in this language")
+	case errors.Is(err, service.ErrInvalidInput):
+		return statusError(ctx, codes.InvalidArgument, "invalid_input", "Invalid search query, category, tags or joke structure")
+	case errors.Is(err, context.DeadlineExceeded):
+		return statusError(ctx, codes.DeadlineExceeded, "deadline_exceeded", "The call's deadline passed")
+	case errors.Is(err, context.Canceled):
+		return statusError(ctx, codes.Canceled, "canceled", "The call was cancelled")
+	default:
+		s.log(ctx).ErrorContext(ctx, "rpc failed", "error", err)
+		return statusError(ctx, codes.Internal, "internal_error", "An internal error occurred")
+	}
+}
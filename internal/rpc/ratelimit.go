@@ -0,0 +1,102 @@
+package rpc
+
+import (
+	"context"
+	"strings"
+
+	djaasv1 "github.com/cdunlap/djaas/internal/pb/djaas/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// filteredRequest is a request carrying a JokeFilter
+type filteredRequest interface {
+	GetFilter() *djaasv1.JokeFilter
+}
+
+// ratePolicy returns the rate limit policy for a call, the route group of
+// its REST equivalent. Calls filtered by a search query count as searches,
+// as they do over HTTP.
+func ratePolicy(method string, req any) string {
+	switch {
+	case method == djaasv1.JokeService_CreateJoke_FullMethodName:
+		return "write"
+	case strings.HasPrefix(method, "/grpc.health.v1.Health/"):
+		return "health"
+	case !strings.HasPrefix(method, "/djaas.v1.JokeService/"):
+		// Reflection, which describes the API like the Swagger UI
+		return "static"
+	}
+
+	if filtered, ok := req.(filteredRequest); ok && filtered.GetFilter().GetSearch() != "" {
+		return "search"
+	}
+	return "read"
+}
+
+// rateLimitUnary limits unary calls; see rateLimit
+func (s *Server) rateLimitUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := s.rateLimit(ctx, ratePolicy(info.FullMethod, req)); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// rateLimitStream limits streaming calls; see rateLimit. Calls with a
+// single request, such as StreamJokes, are checked when it arrives so
+// its filter can choose the policy; others are checked once, at the start.
+func (s *Server) rateLimitStream(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if s.config.RateLimiter == nil {
+		return handler(srv, stream)
+	}
+	if info.IsClientStream {
+		if err := s.rateLimit(stream.Context(), ratePolicy(info.FullMethod, nil)); err != nil {
+			return err
+		}
+		return handler(srv, stream)
+	}
+	return handler(srv, &rateLimitedStream{ServerStream: stream, server: s, method: info.FullMethod})
+}
+
+// rateLimit checks a call against the caller's rate limit and daily quota
+// with the shared RateLimiter. Rejected calls fail with ResourceExhausted
+// and a RetryInfo detail, or Unavailable when the limits could not be
+// checked.
+func (s *Server) rateLimit(ctx context.Context, policy string) error {
+	if s.config.RateLimiter == nil {
+		return nil
+	}
+
+	v := s.config.RateLimiter.Check(ctx, s.logger, principalFromContext(ctx), clientIP(ctx), policy)
+	switch {
+	case v.Allowed:
+		return nil
+	case v.RetryAfter > 0:
+		return statusError(ctx, codes.ResourceExhausted, v.Reason, v.Message,
+			&errdetails.RetryInfo{RetryDelay: durationpb.New(v.RetryAfter)})
+	default:
+		return statusError(ctx, codes.Unavailable, v.Reason, v.Message)
+	}
+}
+
+// rateLimitedStream checks the rate limit when a server streaming call's
+// request arrives
+type rateLimitedStream struct {
+	grpc.ServerStream
+	server  *Server
+	method  string
+	checked bool
+}
+
+func (s *rateLimitedStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if s.checked {
+		return nil
+	}
+	s.checked = true
+	return s.server.rateLimit(s.Context(), ratePolicy(s.method, m))
+}
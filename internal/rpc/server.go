@@ -0,0 +1,116 @@
+// Package rpc serves the joke API over gRPC, alongside the REST API. It
+// shares JokeService with the HTTP handlers, and its interceptors do the
+// work of the HTTP middleware: request IDs, logging, panic recovery,
+// authentication, rate limiting and rating ceilings.
+package rpc
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"sync"
+
+	"github.com/cdunlap/djaas/internal/auth"
+	"github.com/cdunlap/djaas/internal/health"
+	"github.com/cdunlap/djaas/internal/logging"
+	"github.com/cdunlap/djaas/internal/middleware"
+	"github.com/cdunlap/djaas/internal/model"
+	djaasv1 "github.com/cdunlap/djaas/internal/pb/djaas/v1"
+	"github.com/cdunlap/djaas/internal/service"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// JokeMetrics records the jokes served, for metrics
+type JokeMetrics interface {
+	JokeServed(category *string)
+	NoJokesFound()
+}
+
+// Config configures the gRPC server
+type Config struct {
+	// DefaultMaxRating is the rating ceiling for callers whose API key sets none
+	DefaultMaxRating model.ContentRating
+	// Reflection registers the reflection service, so tools such as grpcurl
+	// can discover the API
+	Reflection bool
+	// RateLimiter limits calls as it does HTTP requests, by the policy of
+	// each method's REST equivalent; nil disables rate limiting
+	RateLimiter *middleware.RateLimiter
+	// AuthThrottle limits failed authentication attempts per client
+	// address; nil disables it
+	AuthThrottle *middleware.AuthThrottle
+}
+
+// Server is the gRPC server. It implements djaasv1.JokeServiceServer.
+type Server struct {
+	djaasv1.UnimplementedJokeServiceServer
+
+	jokes          *service.JokeService
+	metrics        JokeMetrics
+	logger         *slog.Logger
+	authenticators []auth.Authenticator
+	config         Config
+
+	grpc *grpc.Server
+	// closing is closed on shutdown to end open joke streams
+	closing   chan struct{}
+	closeOnce sync.Once
+}
+
+// NewServer creates a Server for jokes. Callers are identified by the
+// first of authenticators to find credentials, as in the HTTP API, and the
+// health service reports the readiness checks.
+func NewServer(jokes *service.JokeService, metrics JokeMetrics, checks *health.Health, logger *slog.Logger, authenticators []auth.Authenticator, config Config) *Server {
+	s := &Server{
+		jokes:          jokes,
+		metrics:        metrics,
+		logger:         logger,
+		authenticators: authenticators,
+		config:         config,
+		closing:        make(chan struct{}),
+	}
+
+	s.grpc = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(s.logUnary, s.recoverUnary, s.authenticateUnary, s.rateLimitUnary),
+		grpc.ChainStreamInterceptor(s.logStream, s.recoverStream, s.authenticateStream, s.rateLimitStream),
+	)
+	djaasv1.RegisterJokeServiceServer(s.grpc, s)
+	healthpb.RegisterHealthServer(s.grpc, &healthServer{checks: checks, closing: s.closing})
+	if config.Reflection {
+		reflection.Register(s.grpc)
+	}
+
+	return s
+}
+
+// log returns the call's logger, which carries its request ID
+func (s *Server) log(ctx context.Context) *slog.Logger {
+	return logging.FromContext(ctx, s.logger)
+}
+
+// Serve accepts connections on lis until the server is shut down
+func (s *Server) Serve(lis net.Listener) error {
+	return s.grpc.Serve(lis)
+}
+
+// Shutdown ends open joke streams and stops accepting calls, then waits
+// for running calls to finish. If ctx ends first, they are cancelled.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.closeOnce.Do(func() { close(s.closing) })
+
+	done := make(chan struct{})
+	go func() {
+		s.grpc.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.grpc.Stop()
+		return ctx.Err()
+	}
+}
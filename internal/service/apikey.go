@@ -0,0 +1,279 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cdunlap/djaas/internal/audit"
+	"github.com/cdunlap/djaas/internal/database"
+	"github.com/cdunlap/djaas/internal/logging"
+	"github.com/cdunlap/djaas/internal/model"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	ErrInvalidAPIKey  = errors.New("invalid api key")
+	ErrAPIKeyNotFound = errors.New("api key not found")
+)
+
+// apiKeyPrefix marks DJaaS keys so they are easy to spot in leaked secrets
+const apiKeyPrefix = "djaas_"
+
+// NewAPIKey holds the fields needed to create an API key
+type NewAPIKey struct {
+	Name   string
+	Owner  string
+	Scopes []model.Scope
+	// Tier names the rate limit tier; the caller checks it exists
+	Tier string
+	// MaxRating overrides the default content rating ceiling when set
+	MaxRating *model.ContentRating
+	// ExpiresAt is optional; keys without it never expire
+	ExpiresAt *time.Time
+}
+
+// APIKeyService manages API keys and authenticates requests that present one
+type APIKeyService struct {
+	queries *database.Queries
+	db      TxBeginner
+	logger  *slog.Logger
+	audit   *AuditService
+}
+
+// NewAPIKeyService creates a new APIKeyService. Keys created or revoked are
+// recorded with auditLog, in the transaction on db that changes them.
+func NewAPIKeyService(queries *database.Queries, db TxBeginner, logger *slog.Logger, auditLog *AuditService) *APIKeyService {
+	return &APIKeyService{
+		queries: queries,
+		db:      db,
+		logger:  logger,
+		audit:   auditLog,
+	}
+}
+
+// log returns the request's logger from ctx, falling back to the service's
+func (s *APIKeyService) log(ctx context.Context) *slog.Logger {
+	return logging.FromContext(ctx, s.logger)
+}
+
+// CreateKey generates and stores a new API key. The returned token is the
+// only copy of the key; only its hash is stored.
+func (s *APIKeyService) CreateKey(ctx context.Context, input NewAPIKey) (string, *model.APIKey, error) {
+	if strings.TrimSpace(input.Name) == "" || strings.TrimSpace(input.Owner) == "" || input.Tier == "" {
+		return "", nil, ErrInvalidInput
+	}
+
+	prefix, token, err := generateAPIKey()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate api key: %w", err)
+	}
+
+	scopes := make([]string, 0, len(input.Scopes))
+	for _, scope := range input.Scopes {
+		scopes = append(scopes, string(scope))
+	}
+
+	params := database.CreateAPIKeyParams{
+		Name:      strings.TrimSpace(input.Name),
+		Owner:     strings.TrimSpace(input.Owner),
+		KeyPrefix: prefix,
+		KeyHash:   hashAPIKey(token),
+		Scopes:    scopes,
+		Tier:      input.Tier,
+	}
+	if input.MaxRating != nil {
+		params.MaxRating = database.NullContentRating{ContentRating: database.ContentRating(*input.MaxRating), Valid: true}
+	}
+	if input.ExpiresAt != nil {
+		params.ExpiresAt = pgtype.Timestamptz{Time: *input.ExpiresAt, Valid: true}
+	}
+
+	var created *model.APIKey
+	err = inTx(ctx, s.db, s.queries, func(q *database.Queries) error {
+		key, err := q.CreateAPIKey(ctx, params)
+		if err != nil {
+			return fmt.Errorf("failed to create api key: %w", err)
+		}
+		created = toModelAPIKey(key)
+		return s.audit.Record(ctx, q, audit.ActionAPIKeyCreate, audit.TargetAPIKey, strconv.Itoa(int(key.ID)), nil, created)
+	})
+	if err != nil {
+		s.log(ctx).ErrorContext(ctx, "failed to create api key", "error", err)
+		return "", nil, err
+	}
+
+	s.log(ctx).InfoContext(ctx, "api key created", "key_id", created.ID, "name", created.Name, "owner", created.Owner)
+	return token, created, nil
+}
+
+// ListKeys returns every API key, including revoked and expired ones
+func (s *APIKeyService) ListKeys(ctx context.Context) ([]model.APIKey, error) {
+	keys, err := s.queries.ListAPIKeys(ctx)
+	if err != nil {
+		s.log(ctx).ErrorContext(ctx, "failed to list api keys", "error", err)
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+
+	result := make([]model.APIKey, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, *toModelAPIKey(key))
+	}
+	return result, nil
+}
+
+// RevokeKey disables an API key. Revoking an already revoked key returns
+// ErrAPIKeyNotFound.
+func (s *APIKeyService) RevokeKey(ctx context.Context, id int32) error {
+	err := inTx(ctx, s.db, s.queries, func(q *database.Queries) error {
+		key, err := q.RevokeAPIKey(ctx, id)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrAPIKeyNotFound
+			}
+			return fmt.Errorf("failed to revoke api key: %w", err)
+		}
+
+		revoked := toModelAPIKey(key)
+		before := *revoked
+		before.RevokedAt = nil
+		return s.audit.Record(ctx, q, audit.ActionAPIKeyRevoke, audit.TargetAPIKey, strconv.Itoa(int(key.ID)), before, revoked)
+	})
+	if err != nil {
+		if !errors.Is(err, ErrAPIKeyNotFound) {
+			s.log(ctx).ErrorContext(ctx, "failed to revoke api key", "error", err, "key_id", id)
+		}
+		return err
+	}
+
+	s.log(ctx).InfoContext(ctx, "api key revoked", "key_id", id)
+	return nil
+}
+
+// AuthenticateKey resolves a presented API key to the principal it belongs
+// to. Unknown, malformed, revoked and expired keys return ErrInvalidAPIKey.
+func (s *APIKeyService) AuthenticateKey(ctx context.Context, token string) (*model.Principal, error) {
+	prefix, ok := parseAPIKeyPrefix(token)
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := s.queries.GetAPIKeyByPrefix(ctx, prefix)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrInvalidAPIKey
+		}
+		s.log(ctx).ErrorContext(ctx, "failed to look up api key", "error", err)
+		return nil, fmt.Errorf("failed to look up api key: %w", err)
+	}
+
+	// Compare hashes in constant time so response timing reveals nothing
+	// about how much of the key was correct
+	if subtle.ConstantTimeCompare([]byte(hashAPIKey(token)), []byte(key.KeyHash)) != 1 {
+		return nil, ErrInvalidAPIKey
+	}
+	if key.RevokedAt.Valid {
+		s.log(ctx).DebugContext(ctx, "revoked api key presented", "key_id", key.ID)
+		return nil, ErrInvalidAPIKey
+	}
+	if key.ExpiresAt.Valid && time.Now().After(key.ExpiresAt.Time) {
+		s.log(ctx).DebugContext(ctx, "expired api key presented", "key_id", key.ID)
+		return nil, ErrInvalidAPIKey
+	}
+
+	if err := s.queries.TouchAPIKey(ctx, key.ID); err != nil {
+		// Usage tracking is best effort and must not fail the request
+		s.log(ctx).WarnContext(ctx, "failed to record api key usage", "error", err, "key_id", key.ID)
+	}
+
+	modelKey := toModelAPIKey(key)
+	return &model.Principal{
+		Subject:   fmt.Sprintf("key:%d", key.ID),
+		KeyID:     key.ID,
+		Name:      key.Name,
+		Owner:     key.Owner,
+		Scopes:    modelKey.Scopes,
+		Tier:      key.Tier,
+		MaxRating: modelKey.MaxRating,
+	}, nil
+}
+
+// generateAPIKey returns the lookup prefix and the full token of a new key.
+// Keys have the form djaas_<prefix>_<secret>, where the prefix is 16 hex
+// characters.
+func generateAPIKey() (string, string, error) {
+	prefixBytes := make([]byte, 8)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return "", "", err
+	}
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return "", "", err
+	}
+
+	prefix := hex.EncodeToString(prefixBytes)
+	token := apiKeyPrefix + prefix + "_" + base64.RawURLEncoding.EncodeToString(secretBytes)
+	return prefix, token, nil
+}
+
+// parseAPIKeyPrefix extracts the lookup prefix from a presented key
+func parseAPIKeyPrefix(token string) (string, bool) {
+	rest, ok := strings.CutPrefix(token, apiKeyPrefix)
+	if !ok {
+		return "", false
+	}
+	prefix, secret, ok := strings.Cut(rest, "_")
+	if !ok || len(prefix) != 16 || secret == "" {
+		return "", false
+	}
+	return prefix, true
+}
+
+// hashAPIKey returns the hex-encoded SHA-256 hash stored for a key. Keys
+// carry 256 bits of entropy, so a fast unsalted hash is sufficient.
+func hashAPIKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// toModelAPIKey converts a database key to a model key, omitting its hash
+func toModelAPIKey(key database.ApiKey) *model.APIKey {
+	result := &model.APIKey{
+		ID:        key.ID,
+		Name:      key.Name,
+		Owner:     key.Owner,
+		Prefix:    key.KeyPrefix,
+		Scopes:    make([]model.Scope, 0, len(key.Scopes)),
+		Tier:      key.Tier,
+		CreatedAt: key.CreatedAt.Time,
+	}
+	for _, scope := range key.Scopes {
+		result.Scopes = append(result.Scopes, model.Scope(scope))
+	}
+	if key.MaxRating.Valid {
+		rating := model.ContentRating(key.MaxRating.ContentRating)
+		result.MaxRating = &rating
+	}
+	result.ExpiresAt = toTimePtr(key.ExpiresAt)
+	result.LastUsedAt = toTimePtr(key.LastUsedAt)
+	result.RevokedAt = toTimePtr(key.RevokedAt)
+	return result
+}
+
+// toTimePtr converts a nullable timestamp to a time pointer
+func toTimePtr(t pgtype.Timestamptz) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
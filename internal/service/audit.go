@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/cdunlap/djaas/internal/audit"
+	"github.com/cdunlap/djaas/internal/database"
+	"github.com/cdunlap/djaas/internal/logging"
+	"github.com/cdunlap/djaas/internal/model"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Audit log page sizes
+const (
+	DefaultAuditLimit = 50
+	MaxAuditLimit     = 200
+)
+
+// AuditFilter narrows a listing of audit events. Empty fields match
+// everything.
+type AuditFilter struct {
+	Actor      string
+	Action     string
+	TargetType string
+	TargetID   string
+	// Since and Until bound when the events occurred, Until exclusive
+	Since *time.Time
+	Until *time.Time
+	// Limit is the page size; 0 means DefaultAuditLimit
+	Limit int
+	// Cursor is the NextCursor of the previous page
+	Cursor string
+}
+
+// AuditService records write and admin operations and lists them
+type AuditService struct {
+	queries *database.Queries
+	logger  *slog.Logger
+}
+
+// NewAuditService creates a new AuditService
+func NewAuditService(queries *database.Queries, logger *slog.Logger) *AuditService {
+	return &AuditService{
+		queries: queries,
+		logger:  logger,
+	}
+}
+
+// Record stores an audit event for an operation, with the actor taken
+// from ctx. before and after are encoded as JSON; pass nil for a side on
+// which the target did not exist. Pass queries bound to the operation's
+// transaction, so the event is committed with the operation or not at all,
+// and fail the operation if Record does. A nil AuditService records
+// nothing.
+func (s *AuditService) Record(ctx context.Context, queries *database.Queries, action audit.Action, targetType, targetID string, before, after any) error {
+	if s == nil {
+		return nil
+	}
+
+	actor := audit.ActorFromContext(ctx)
+	logger := logging.FromContext(ctx, s.logger)
+
+	beforeState, err := encodeAuditState(before)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit state: %w", err)
+	}
+	afterState, err := encodeAuditState(after)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit state: %w", err)
+	}
+
+	err = queries.CreateAuditEvent(ctx, database.CreateAuditEventParams{
+		Actor:       actor.Subject,
+		Action:      string(action),
+		TargetType:  targetType,
+		TargetID:    targetID,
+		BeforeState: beforeState,
+		AfterState:  afterState,
+		ClientIp:    toOptionalPgText(actor.ClientIP),
+		RequestID:   toOptionalPgText(actor.RequestID),
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to record audit event",
+			"error", err,
+			"action", action,
+			"target_type", targetType,
+			"target_id", targetID,
+			"actor", actor.Subject,
+		)
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+	return nil
+}
+
+// List returns a page of audit events matching filter, newest first
+func (s *AuditService) List(ctx context.Context, filter AuditFilter) (*model.AuditEventList, error) {
+	limit := filter.Limit
+	if limit == 0 {
+		limit = DefaultAuditLimit
+	}
+	if limit < 0 || limit > MaxAuditLimit {
+		return nil, ErrInvalidInput
+	}
+
+	var beforeID int64
+	if filter.Cursor != "" {
+		id, err := strconv.ParseInt(filter.Cursor, 10, 64)
+		if err != nil || id <= 0 {
+			return nil, ErrInvalidInput
+		}
+		beforeID = id
+	}
+
+	// Fetch one extra row to learn whether there is another page
+	rows, err := s.queries.ListAuditEvents(ctx, database.ListAuditEventsParams{
+		Actor:      filter.Actor,
+		Action:     filter.Action,
+		TargetType: filter.TargetType,
+		TargetID:   filter.TargetID,
+		Since:      toPgTimestamptz(filter.Since),
+		Until:      toPgTimestamptz(filter.Until),
+		BeforeID:   beforeID,
+		RowLimit:   int32(limit + 1),
+	})
+	if err != nil {
+		logging.FromContext(ctx, s.logger).ErrorContext(ctx, "failed to list audit events", "error", err)
+		return nil, fmt.Errorf("failed to list audit events: %w", err)
+	}
+
+	list := &model.AuditEventList{Events: make([]model.AuditEvent, 0, min(len(rows), limit))}
+	for i, row := range rows {
+		if i == limit {
+			list.NextCursor = strconv.FormatInt(rows[limit-1].ID, 10)
+			break
+		}
+		list.Events = append(list.Events, model.AuditEvent{
+			ID:         row.ID,
+			OccurredAt: row.OccurredAt.Time,
+			Actor:      row.Actor,
+			Action:     row.Action,
+			TargetType: row.TargetType,
+			TargetID:   row.TargetID,
+			Before:     row.BeforeState,
+			After:      row.AfterState,
+			ClientIP:   row.ClientIp.String,
+			RequestID:  row.RequestID.String,
+		})
+	}
+
+	return list, nil
+}
+
+// encodeAuditState encodes one side of an audit event, or returns nil when
+// the target did not exist on that side
+func encodeAuditState(state any) ([]byte, error) {
+	if state == nil {
+		return nil, nil
+	}
+	return json.Marshal(state)
+}
+
+// toOptionalPgText converts an empty string to NULL
+func toOptionalPgText(s string) pgtype.Text {
+	if s == "" {
+		return pgtype.Text{}
+	}
+	return toPgText(s)
+}
+
+func toPgTimestamptz(t *time.Time) pgtype.Timestamptz {
+	if t == nil {
+		return pgtype.Timestamptz{}
+	}
+	return pgtype.Timestamptz{Time: *t, Valid: true}
+}
@@ -2,50 +2,184 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
+	"strconv"
+	"strings"
 
+	"github.com/cdunlap/djaas/internal/audit"
 	"github.com/cdunlap/djaas/internal/database"
+	"github.com/cdunlap/djaas/internal/language"
+	"github.com/cdunlap/djaas/internal/logging"
 	"github.com/cdunlap/djaas/internal/model"
+	"github.com/cdunlap/djaas/internal/moderation"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
 var (
-	ErrNoJokesFound = errors.New("no jokes found")
-	ErrInvalidInput = errors.New("invalid input")
+	ErrNoJokesFound       = errors.New("no jokes found")
+	ErrJokeNotFound       = errors.New("joke not found")
+	ErrInvalidInput       = errors.New("invalid input")
+	ErrInvalidTranslation = errors.New("invalid translation")
 )
 
+// pgUniqueViolation is the PostgreSQL unique_violation error code
+const pgUniqueViolation = "23505"
+
+// Joke list page sizes
+const (
+	DefaultJokeListLimit = 20
+	MaxJokeListLimit     = 100
+)
+
+// JokeFilter holds constraints applied to every joke retrieval
+type JokeFilter struct {
+	// MaxRating is the least family-friendly rating that may be returned
+	MaxRating model.ContentRating
+	// Languages lists the caller's preferred languages, most preferred first.
+	// The service's fallback chain is always appended.
+	Languages []string
+	// Type restricts results to one joke format; empty means any
+	Type model.JokeType
+	// Source restricts results to jokes from one source; empty means any
+	Source string
+}
+
+// JokeQuery selects random jokes by search text, category and tags; empty
+// fields are ignored
+type JokeQuery struct {
+	Search   string
+	Category string
+	Tags     []string
+}
+
+// JokePage selects a page of a joke listing
+type JokePage struct {
+	// Limit is the page size; 0 means DefaultJokeListLimit
+	Limit int
+	// Cursor is the NextCursor of the previous page
+	Cursor string
+}
+
+// NewJoke holds the fields needed to create a joke. Setup and punchline
+// are derived from Parts for one-liners, knock-knock and multi-part jokes
+// when the caller leaves them empty, and vice versa for classic jokes.
+type NewJoke struct {
+	Setup           string
+	Punchline       string
+	Type            model.JokeType
+	Parts           []model.JokePart
+	Category        *string
+	Tags            []string
+	Rating          model.ContentRating
+	ContentWarnings []string
+	// Language defaults to the first fallback language when empty
+	Language string
+	// TranslationOf links the joke to an equivalent joke in another language
+	TranslationOf *int32
+	Author        *string
+	SourceName    *string
+	SourceURL     *string
+	License       *string
+}
+
+// JokePublisher is told about newly approved jokes
+type JokePublisher interface {
+	Publish(joke model.Joke)
+}
+
 // JokeService provides business logic for jokes
 type JokeService struct {
-	queries *database.Queries
-	logger  *slog.Logger
+	queries   *database.Queries
+	db        TxBeginner
+	logger    *slog.Logger
+	checker   moderation.Checker
+	fallbacks []string
+	audit     *AuditService
+	publisher JokePublisher
 }
 
-// NewJokeService creates a new JokeService
-func NewJokeService(queries *database.Queries, logger *slog.Logger) *JokeService {
+// NewJokeService creates a new JokeService. fallbacks is the ordered list of
+// languages served when none of the caller's preferred languages match.
+// Jokes and tags created or deleted are recorded with auditLog, in the
+// transaction on db that changes them. New jokes that pass the content
+// checker are approved and sent to publisher, which may be nil.
+func NewJokeService(queries *database.Queries, db TxBeginner, logger *slog.Logger, checker moderation.Checker, fallbacks []string, auditLog *AuditService, publisher JokePublisher) *JokeService {
 	return &JokeService{
-		queries: queries,
-		logger:  logger,
+		queries:   queries,
+		db:        db,
+		logger:    logger,
+		checker:   checker,
+		fallbacks: fallbacks,
+		audit:     auditLog,
+		publisher: publisher,
+	}
+}
+
+// log returns the request's logger from ctx, falling back to the service's
+func (s *JokeService) log(ctx context.Context) *slog.Logger {
+	return logging.FromContext(ctx, s.logger)
+}
+
+// languages returns the caller's preferred languages followed by the fallback chain
+func (s *JokeService) languages(filter JokeFilter) []string {
+	return language.WithFallbacks(filter.Languages, s.fallbacks)
+}
+
+// FindJoke retrieves a random joke matching every field of query that is
+// set, using the lookup for that combination
+func (s *JokeService) FindJoke(ctx context.Context, query JokeQuery, filter JokeFilter) (*model.Joke, error) {
+	tags, category, search := query.Tags, query.Category, query.Search
+
+	switch {
+	case len(tags) > 0 && category != "" && search != "":
+		return s.GetJokeByAllFilters(ctx, tags, category, search, filter)
+	case len(tags) > 0 && category != "":
+		return s.GetJokeByTagsAndCategory(ctx, tags, category, filter)
+	case len(tags) > 0 && search != "":
+		return s.GetJokeByTagsAndSearch(ctx, tags, search, filter)
+	case len(tags) > 0:
+		return s.GetJokeByTags(ctx, tags, filter)
+	case category != "" && search != "":
+		return s.GetJokeByCategoryAndSearch(ctx, category, search, filter)
+	case category != "":
+		return s.GetJokeByCategory(ctx, category, filter)
+	case search != "":
+		return s.SearchJokes(ctx, search, filter)
+	default:
+		return s.GetRandomJoke(ctx, filter)
 	}
 }
 
 // GetRandomJoke retrieves a random joke
-func (s *JokeService) GetRandomJoke(ctx context.Context) (*model.Joke, error) {
-	joke, err := s.queries.GetRandomJoke(ctx)
+func (s *JokeService) GetRandomJoke(ctx context.Context, filter JokeFilter) (_ *model.Joke, err error) {
+	ctx, span := tracer.Start(ctx, "JokeService.GetRandomJoke")
+	defer func() { endSpan(span, err) }()
+
+	params := database.GetRandomJokeParams{
+		Rating:     toDBRating(filter.MaxRating),
+		Column2:    s.languages(filter),
+		JokeType:   string(filter.Type),
+		SourceName: toPgText(filter.Source),
+	}
+
+	joke, err := s.queries.GetRandomJoke(ctx, params)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			s.logger.Warn("no jokes found in database")
+			s.log(ctx).WarnContext(ctx, "no jokes found in database")
 			return nil, ErrNoJokesFound
 		}
-		s.logger.Error("failed to get random joke", "error", err)
+		s.log(ctx).ErrorContext(ctx, "failed to get random joke", "error", err)
 		return nil, fmt.Errorf("failed to get random joke: %w", err)
 	}
 
 	tags, err := s.queries.GetTagsForJoke(ctx, joke.ID)
 	if err != nil {
-		s.logger.Error("failed to get tags for joke", "error", err, "joke_id", joke.ID)
+		s.log(ctx).ErrorContext(ctx, "failed to get tags for joke", "error", err, "joke_id", joke.ID)
 		// Continue with empty tags rather than failing
 		tags = []string{}
 	}
@@ -54,24 +188,35 @@ func (s *JokeService) GetRandomJoke(ctx context.Context) (*model.Joke, error) {
 }
 
 // SearchJokes searches for jokes containing the query string
-func (s *JokeService) SearchJokes(ctx context.Context, query string) (*model.Joke, error) {
+func (s *JokeService) SearchJokes(ctx context.Context, query string, filter JokeFilter) (_ *model.Joke, err error) {
+	ctx, span := tracer.Start(ctx, "JokeService.SearchJokes")
+	defer func() { endSpan(span, err) }()
+
 	if query == "" {
 		return nil, ErrInvalidInput
 	}
 
-	joke, err := s.queries.SearchJokes(ctx, toPgText(query))
+	params := database.SearchJokesParams{
+		Column1:    toPgText(query),
+		Rating:     toDBRating(filter.MaxRating),
+		Column3:    s.languages(filter),
+		JokeType:   string(filter.Type),
+		SourceName: toPgText(filter.Source),
+	}
+
+	joke, err := s.queries.SearchJokes(ctx, params)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			s.logger.Warn("no jokes found matching search query", "query", query)
+			s.log(ctx).WarnContext(ctx, "no jokes found matching search query", "query", query)
 			return nil, ErrNoJokesFound
 		}
-		s.logger.Error("failed to search jokes", "error", err, "query", query)
+		s.log(ctx).ErrorContext(ctx, "failed to search jokes", "error", err, "query", query)
 		return nil, fmt.Errorf("failed to search jokes: %w", err)
 	}
 
 	tags, err := s.queries.GetTagsForJoke(ctx, joke.ID)
 	if err != nil {
-		s.logger.Error("failed to get tags for joke", "error", err, "joke_id", joke.ID)
+		s.log(ctx).ErrorContext(ctx, "failed to get tags for joke", "error", err, "joke_id", joke.ID)
 		tags = []string{}
 	}
 
@@ -79,24 +224,35 @@ func (s *JokeService) SearchJokes(ctx context.Context, query string) (*model.Jok
 }
 
 // GetJokeByCategory retrieves a random joke from a specific category
-func (s *JokeService) GetJokeByCategory(ctx context.Context, category string) (*model.Joke, error) {
+func (s *JokeService) GetJokeByCategory(ctx context.Context, category string, filter JokeFilter) (_ *model.Joke, err error) {
+	ctx, span := tracer.Start(ctx, "JokeService.GetJokeByCategory")
+	defer func() { endSpan(span, err) }()
+
 	if category == "" {
 		return nil, ErrInvalidInput
 	}
 
-	joke, err := s.queries.GetJokeByCategory(ctx, toPgText(category))
+	params := database.GetJokeByCategoryParams{
+		Category:   toPgText(category),
+		Rating:     toDBRating(filter.MaxRating),
+		Column3:    s.languages(filter),
+		JokeType:   string(filter.Type),
+		SourceName: toPgText(filter.Source),
+	}
+
+	joke, err := s.queries.GetJokeByCategory(ctx, params)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			s.logger.Warn("no jokes found in category", "category", category)
+			s.log(ctx).WarnContext(ctx, "no jokes found in category", "category", category)
 			return nil, ErrNoJokesFound
 		}
-		s.logger.Error("failed to get joke by category", "error", err, "category", category)
+		s.log(ctx).ErrorContext(ctx, "failed to get joke by category", "error", err, "category", category)
 		return nil, fmt.Errorf("failed to get joke by category: %w", err)
 	}
 
 	tags, err := s.queries.GetTagsForJoke(ctx, joke.ID)
 	if err != nil {
-		s.logger.Error("failed to get tags for joke", "error", err, "joke_id", joke.ID)
+		s.log(ctx).ErrorContext(ctx, "failed to get tags for joke", "error", err, "joke_id", joke.ID)
 		tags = []string{}
 	}
 
@@ -104,31 +260,38 @@ func (s *JokeService) GetJokeByCategory(ctx context.Context, category string) (*
 }
 
 // GetJokeByCategoryAndSearch retrieves a random joke from a specific category matching the search query
-func (s *JokeService) GetJokeByCategoryAndSearch(ctx context.Context, category, query string) (*model.Joke, error) {
+func (s *JokeService) GetJokeByCategoryAndSearch(ctx context.Context, category, query string, filter JokeFilter) (_ *model.Joke, err error) {
+	ctx, span := tracer.Start(ctx, "JokeService.GetJokeByCategoryAndSearch")
+	defer func() { endSpan(span, err) }()
+
 	if category == "" || query == "" {
 		return nil, ErrInvalidInput
 	}
 
 	params := database.GetJokeByCategoryAndSearchParams{
-		Category: toPgText(category),
-		Column2:  toPgText(query),
+		Category:   toPgText(category),
+		Column2:    toPgText(query),
+		Rating:     toDBRating(filter.MaxRating),
+		Column4:    s.languages(filter),
+		JokeType:   string(filter.Type),
+		SourceName: toPgText(filter.Source),
 	}
 
 	joke, err := s.queries.GetJokeByCategoryAndSearch(ctx, params)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			s.logger.Warn("no jokes found matching category and search",
+			s.log(ctx).WarnContext(ctx, "no jokes found matching category and search",
 				"category", category, "query", query)
 			return nil, ErrNoJokesFound
 		}
-		s.logger.Error("failed to get joke by category and search",
+		s.log(ctx).ErrorContext(ctx, "failed to get joke by category and search",
 			"error", err, "category", category, "query", query)
 		return nil, fmt.Errorf("failed to get joke by category and search: %w", err)
 	}
 
 	tags, err := s.queries.GetTagsForJoke(ctx, joke.ID)
 	if err != nil {
-		s.logger.Error("failed to get tags for joke", "error", err, "joke_id", joke.ID)
+		s.log(ctx).ErrorContext(ctx, "failed to get tags for joke", "error", err, "joke_id", joke.ID)
 		tags = []string{}
 	}
 
@@ -143,14 +306,51 @@ func (s *JokeService) buildJokeWithTags(dbJoke database.Joke, tags []string) *mo
 		category = &dbJoke.Category.String
 	}
 
+	var translationOf *int32
+	if dbJoke.TranslationOf.Valid {
+		translationOf = &dbJoke.TranslationOf.Int32
+	}
+
+	parts := decodeParts(dbJoke)
+
+	var author, sourceName, sourceURL, license *string
+	if dbJoke.Author.Valid {
+		author = &dbJoke.Author.String
+	}
+	if dbJoke.SourceName.Valid {
+		sourceName = &dbJoke.SourceName.String
+	}
+	if dbJoke.SourceUrl.Valid {
+		sourceURL = &dbJoke.SourceUrl.String
+	}
+	if dbJoke.License.Valid {
+		license = &dbJoke.License.String
+	}
+
+	contentWarnings := dbJoke.ContentWarnings
+	if contentWarnings == nil {
+		contentWarnings = []string{}
+	}
+
 	return &model.Joke{
-		ID:        dbJoke.ID,
-		Setup:     dbJoke.Setup,
-		Punchline: dbJoke.Punchline,
-		Category:  category,
-		Tags:      tags,
-		CreatedAt: dbJoke.CreatedAt.Time,
-		UpdatedAt: dbJoke.UpdatedAt.Time,
+		ID:              dbJoke.ID,
+		Setup:           dbJoke.Setup,
+		Punchline:       dbJoke.Punchline,
+		Type:            model.JokeType(dbJoke.JokeType),
+		Parts:           parts,
+		Category:        category,
+		Tags:            tags,
+		Rating:          model.ContentRating(dbJoke.Rating),
+		ContentWarnings: contentWarnings,
+		Flagged:         dbJoke.Flagged,
+		Language:        dbJoke.Language,
+		TranslationOf:   translationOf,
+		Author:          author,
+		SourceName:      sourceName,
+		SourceURL:       sourceURL,
+		License:         license,
+		CreatedAt:       dbJoke.CreatedAt.Time,
+		UpdatedAt:       dbJoke.UpdatedAt.Time,
 	}
 }
 
@@ -163,25 +363,47 @@ func toPgText(s string) pgtype.Text {
 	}
 }
 
+func toNullablePgText(s *string) pgtype.Text {
+	if s == nil {
+		return pgtype.Text{}
+	}
+	return toPgText(*s)
+}
+
+func toDBRating(r model.ContentRating) database.ContentRating {
+	return database.ContentRating(r)
+}
+
 // GetJokeByTags retrieves a random joke matching any of the provided tags
-func (s *JokeService) GetJokeByTags(ctx context.Context, tags []string) (*model.Joke, error) {
+func (s *JokeService) GetJokeByTags(ctx context.Context, tags []string, filter JokeFilter) (_ *model.Joke, err error) {
+	ctx, span := tracer.Start(ctx, "JokeService.GetJokeByTags")
+	defer func() { endSpan(span, err) }()
+
 	if len(tags) == 0 {
 		return nil, ErrInvalidInput
 	}
 
-	joke, err := s.queries.GetJokeByTags(ctx, tags)
+	params := database.GetJokeByTagsParams{
+		Column1:    tags,
+		Rating:     toDBRating(filter.MaxRating),
+		Column3:    s.languages(filter),
+		JokeType:   string(filter.Type),
+		SourceName: toPgText(filter.Source),
+	}
+
+	joke, err := s.queries.GetJokeByTags(ctx, params)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			s.logger.Warn("no jokes found matching tags", "tags", tags)
+			s.log(ctx).WarnContext(ctx, "no jokes found matching tags", "tags", tags)
 			return nil, ErrNoJokesFound
 		}
-		s.logger.Error("failed to get joke by tags", "error", err, "tags", tags)
+		s.log(ctx).ErrorContext(ctx, "failed to get joke by tags", "error", err, "tags", tags)
 		return nil, fmt.Errorf("failed to get joke by tags: %w", err)
 	}
 
 	joketags, err := s.queries.GetTagsForJoke(ctx, joke.ID)
 	if err != nil {
-		s.logger.Error("failed to get tags for joke", "error", err, "joke_id", joke.ID)
+		s.log(ctx).ErrorContext(ctx, "failed to get tags for joke", "error", err, "joke_id", joke.ID)
 		joketags = []string{}
 	}
 
@@ -189,29 +411,36 @@ func (s *JokeService) GetJokeByTags(ctx context.Context, tags []string) (*model.
 }
 
 // GetJokeByTagsAndCategory retrieves a random joke matching tags and category
-func (s *JokeService) GetJokeByTagsAndCategory(ctx context.Context, tags []string, category string) (*model.Joke, error) {
+func (s *JokeService) GetJokeByTagsAndCategory(ctx context.Context, tags []string, category string, filter JokeFilter) (_ *model.Joke, err error) {
+	ctx, span := tracer.Start(ctx, "JokeService.GetJokeByTagsAndCategory")
+	defer func() { endSpan(span, err) }()
+
 	if len(tags) == 0 || category == "" {
 		return nil, ErrInvalidInput
 	}
 
 	params := database.GetJokeByTagsAndCategoryParams{
-		Column1:  tags,
-		Category: toPgText(category),
+		Column1:    tags,
+		Category:   toPgText(category),
+		Rating:     toDBRating(filter.MaxRating),
+		Column4:    s.languages(filter),
+		JokeType:   string(filter.Type),
+		SourceName: toPgText(filter.Source),
 	}
 
 	joke, err := s.queries.GetJokeByTagsAndCategory(ctx, params)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			s.logger.Warn("no jokes found matching tags and category", "tags", tags, "category", category)
+			s.log(ctx).WarnContext(ctx, "no jokes found matching tags and category", "tags", tags, "category", category)
 			return nil, ErrNoJokesFound
 		}
-		s.logger.Error("failed to get joke by tags and category", "error", err, "tags", tags, "category", category)
+		s.log(ctx).ErrorContext(ctx, "failed to get joke by tags and category", "error", err, "tags", tags, "category", category)
 		return nil, fmt.Errorf("failed to get joke by tags and category: %w", err)
 	}
 
 	joketags, err := s.queries.GetTagsForJoke(ctx, joke.ID)
 	if err != nil {
-		s.logger.Error("failed to get tags for joke", "error", err, "joke_id", joke.ID)
+		s.log(ctx).ErrorContext(ctx, "failed to get tags for joke", "error", err, "joke_id", joke.ID)
 		joketags = []string{}
 	}
 
@@ -219,29 +448,36 @@ func (s *JokeService) GetJokeByTagsAndCategory(ctx context.Context, tags []strin
 }
 
 // GetJokeByTagsAndSearch retrieves a random joke matching tags and search query
-func (s *JokeService) GetJokeByTagsAndSearch(ctx context.Context, tags []string, searchQuery string) (*model.Joke, error) {
+func (s *JokeService) GetJokeByTagsAndSearch(ctx context.Context, tags []string, searchQuery string, filter JokeFilter) (_ *model.Joke, err error) {
+	ctx, span := tracer.Start(ctx, "JokeService.GetJokeByTagsAndSearch")
+	defer func() { endSpan(span, err) }()
+
 	if len(tags) == 0 || searchQuery == "" {
 		return nil, ErrInvalidInput
 	}
 
 	params := database.GetJokeByTagsAndSearchParams{
-		Column1: tags,
-		Column2: toPgText(searchQuery),
+		Column1:    tags,
+		Column2:    toPgText(searchQuery),
+		Rating:     toDBRating(filter.MaxRating),
+		Column4:    s.languages(filter),
+		JokeType:   string(filter.Type),
+		SourceName: toPgText(filter.Source),
 	}
 
 	joke, err := s.queries.GetJokeByTagsAndSearch(ctx, params)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			s.logger.Warn("no jokes found matching tags and search", "tags", tags, "search", searchQuery)
+			s.log(ctx).WarnContext(ctx, "no jokes found matching tags and search", "tags", tags, "search", searchQuery)
 			return nil, ErrNoJokesFound
 		}
-		s.logger.Error("failed to get joke by tags and search", "error", err, "tags", tags, "search", searchQuery)
+		s.log(ctx).ErrorContext(ctx, "failed to get joke by tags and search", "error", err, "tags", tags, "search", searchQuery)
 		return nil, fmt.Errorf("failed to get joke by tags and search: %w", err)
 	}
 
 	joketags, err := s.queries.GetTagsForJoke(ctx, joke.ID)
 	if err != nil {
-		s.logger.Error("failed to get tags for joke", "error", err, "joke_id", joke.ID)
+		s.log(ctx).ErrorContext(ctx, "failed to get tags for joke", "error", err, "joke_id", joke.ID)
 		joketags = []string{}
 	}
 
@@ -249,108 +485,653 @@ func (s *JokeService) GetJokeByTagsAndSearch(ctx context.Context, tags []string,
 }
 
 // GetJokeByAllFilters retrieves a random joke matching tags, category, and search query
-func (s *JokeService) GetJokeByAllFilters(ctx context.Context, tags []string, category string, searchQuery string) (*model.Joke, error) {
+func (s *JokeService) GetJokeByAllFilters(ctx context.Context, tags []string, category string, searchQuery string, filter JokeFilter) (_ *model.Joke, err error) {
+	ctx, span := tracer.Start(ctx, "JokeService.GetJokeByAllFilters")
+	defer func() { endSpan(span, err) }()
+
 	if len(tags) == 0 || category == "" || searchQuery == "" {
 		return nil, ErrInvalidInput
 	}
 
 	params := database.GetJokeByAllFiltersParams{
-		Column1:  tags,
-		Category: toPgText(category),
-		Column3:  toPgText(searchQuery),
+		Column1:    tags,
+		Category:   toPgText(category),
+		Column3:    toPgText(searchQuery),
+		Rating:     toDBRating(filter.MaxRating),
+		Column5:    s.languages(filter),
+		JokeType:   string(filter.Type),
+		SourceName: toPgText(filter.Source),
 	}
 
 	joke, err := s.queries.GetJokeByAllFilters(ctx, params)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			s.logger.Warn("no jokes found matching all filters", "tags", tags, "category", category, "search", searchQuery)
+			s.log(ctx).WarnContext(ctx, "no jokes found matching all filters", "tags", tags, "category", category, "search", searchQuery)
 			return nil, ErrNoJokesFound
 		}
-		s.logger.Error("failed to get joke by all filters", "error", err, "tags", tags, "category", category, "search", searchQuery)
+		s.log(ctx).ErrorContext(ctx, "failed to get joke by all filters", "error", err, "tags", tags, "category", category, "search", searchQuery)
 		return nil, fmt.Errorf("failed to get joke by all filters: %w", err)
 	}
 
 	joketags, err := s.queries.GetTagsForJoke(ctx, joke.ID)
 	if err != nil {
-		s.logger.Error("failed to get tags for joke", "error", err, "joke_id", joke.ID)
+		s.log(ctx).ErrorContext(ctx, "failed to get tags for joke", "error", err, "joke_id", joke.ID)
 		joketags = []string{}
 	}
 
 	return s.buildJokeWithTags(joke, joketags), nil
 }
 
+// GetJoke retrieves a joke by ID. Jokes above the filter's rating ceiling
+// are reported as not found.
+func (s *JokeService) GetJoke(ctx context.Context, id int32, filter JokeFilter) (_ *model.Joke, err error) {
+	ctx, span := tracer.Start(ctx, "JokeService.GetJoke")
+	defer func() { endSpan(span, err) }()
+
+	joke, err := s.queries.GetJokeByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrJokeNotFound
+		}
+		s.log(ctx).ErrorContext(ctx, "failed to get joke by id", "error", err, "joke_id", id)
+		return nil, fmt.Errorf("failed to get joke by id: %w", err)
+	}
+	if model.ContentRating(joke.Rating).Exceeds(filter.MaxRating) {
+		return nil, ErrJokeNotFound
+	}
+
+	tags, err := s.queries.GetTagsForJoke(ctx, joke.ID)
+	if err != nil {
+		s.log(ctx).ErrorContext(ctx, "failed to get tags for joke", "error", err, "joke_id", joke.ID)
+		tags = []string{}
+	}
+
+	return s.buildJokeWithTags(joke, tags), nil
+}
+
+// ListJokes returns a page of the jokes matching query and filter, oldest
+// first. Jokes with any of the query's tags match. Unlike random jokes,
+// lists are limited to the filter's languages exactly, or include every
+// language when it has none.
+func (s *JokeService) ListJokes(ctx context.Context, query JokeQuery, filter JokeFilter, page JokePage) (_ *model.JokeList, err error) {
+	ctx, span := tracer.Start(ctx, "JokeService.ListJokes")
+	defer func() { endSpan(span, err) }()
+
+	limit := page.Limit
+	if limit == 0 {
+		limit = DefaultJokeListLimit
+	}
+	if limit < 0 || limit > MaxJokeListLimit {
+		return nil, ErrInvalidInput
+	}
+
+	var afterID int32
+	if page.Cursor != "" {
+		id, err := strconv.ParseInt(page.Cursor, 10, 32)
+		if err != nil || id <= 0 {
+			return nil, ErrInvalidInput
+		}
+		afterID = int32(id)
+	}
+
+	tags := query.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	languages := filter.Languages
+	if languages == nil {
+		languages = []string{}
+	}
+
+	// Fetch one extra row to learn whether there is another page
+	rows, err := s.queries.ListJokes(ctx, database.ListJokesParams{
+		Category:   query.Category,
+		Search:     query.Search,
+		Tags:       tags,
+		Rating:     toDBRating(filter.MaxRating),
+		Languages:  languages,
+		JokeType:   string(filter.Type),
+		SourceName: filter.Source,
+		AfterID:    afterID,
+		RowLimit:   int32(limit + 1),
+	})
+	if err != nil {
+		s.log(ctx).ErrorContext(ctx, "failed to list jokes", "error", err)
+		return nil, fmt.Errorf("failed to list jokes: %w", err)
+	}
+
+	list := &model.JokeList{Jokes: make([]model.Joke, 0, min(len(rows), limit))}
+	if len(rows) > limit {
+		rows = rows[:limit]
+		list.NextCursor = strconv.FormatInt(int64(rows[limit-1].ID), 10)
+	}
+
+	ids := make([]int32, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+	jokeTags, err := s.GetTagsForJokes(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		list.Jokes = append(list.Jokes, *s.buildJokeWithTags(row, jokeTags[row.ID]))
+	}
+	return list, nil
+}
+
+// GetTagsForJokes retrieves the tags of several jokes in one query, keyed
+// by joke ID. Every requested joke has an entry, empty if it has no tags.
+func (s *JokeService) GetTagsForJokes(ctx context.Context, ids []int32) (_ map[int32][]string, err error) {
+	ctx, span := tracer.Start(ctx, "JokeService.GetTagsForJokes")
+	defer func() { endSpan(span, err) }()
+
+	tags := make(map[int32][]string, len(ids))
+	for _, id := range ids {
+		tags[id] = []string{}
+	}
+	if len(ids) == 0 {
+		return tags, nil
+	}
+
+	rows, err := s.queries.GetTagsForJokes(ctx, ids)
+	if err != nil {
+		s.log(ctx).ErrorContext(ctx, "failed to get tags for jokes", "error", err, "jokes", len(ids))
+		return nil, fmt.Errorf("failed to get tags for jokes: %w", err)
+	}
+	for _, row := range rows {
+		tags[row.JokeID] = append(tags[row.JokeID], row.Name)
+	}
+	return tags, nil
+}
+
+// GetSimilarJokes retrieves up to limit jokes ranked by shared tags, same
+// category and text similarity to the given joke, excluding the joke itself
+func (s *JokeService) GetSimilarJokes(ctx context.Context, id int32, limit int32, filter JokeFilter) (_ []model.SimilarJoke, err error) {
+	ctx, span := tracer.Start(ctx, "JokeService.GetSimilarJokes")
+	defer func() { endSpan(span, err) }()
+
+	if limit <= 0 {
+		return nil, ErrInvalidInput
+	}
+
+	// A joke above the ceiling is reported as missing; ranking against its
+	// text would reveal it
+	source, err := s.queries.GetJokeByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrJokeNotFound
+		}
+		s.log(ctx).ErrorContext(ctx, "failed to get joke by id", "error", err, "joke_id", id)
+		return nil, fmt.Errorf("failed to get joke by id: %w", err)
+	}
+	if model.ContentRating(source.Rating).Exceeds(filter.MaxRating) {
+		return nil, ErrJokeNotFound
+	}
+
+	params := database.GetSimilarJokesParams{
+		ID:     id,
+		Rating: toDBRating(filter.MaxRating),
+		Limit:  limit,
+	}
+
+	rows, err := s.queries.GetSimilarJokes(ctx, params)
+	if err != nil {
+		s.log(ctx).ErrorContext(ctx, "failed to get similar jokes", "error", err, "joke_id", id)
+		return nil, fmt.Errorf("failed to get similar jokes: %w", err)
+	}
+
+	ids := make([]int32, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+	jokeTags, err := s.GetTagsForJokes(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	similar := make([]model.SimilarJoke, 0, len(rows))
+	for _, row := range rows {
+		joke := s.buildJokeWithTags(database.Joke{
+			ID:              row.ID,
+			Setup:           row.Setup,
+			Punchline:       row.Punchline,
+			Category:        row.Category,
+			CreatedAt:       row.CreatedAt,
+			UpdatedAt:       row.UpdatedAt,
+			Rating:          row.Rating,
+			ContentWarnings: row.ContentWarnings,
+			Flagged:         row.Flagged,
+			Language:        row.Language,
+			TranslationOf:   row.TranslationOf,
+			JokeType:        row.JokeType,
+			Parts:           row.Parts,
+			Author:          row.Author,
+			SourceName:      row.SourceName,
+			SourceUrl:       row.SourceUrl,
+			License:         row.License,
+		}, jokeTags[row.ID])
+
+		similar = append(similar, model.SimilarJoke{
+			Joke:  *joke,
+			Score: row.Score,
+		})
+	}
+
+	return similar, nil
+}
+
+// GetJokeTranslations retrieves the equivalent jokes in other languages.
+// A joke above the filter's rating ceiling is reported as not found.
+func (s *JokeService) GetJokeTranslations(ctx context.Context, id int32, filter JokeFilter) (_ []model.Joke, err error) {
+	ctx, span := tracer.Start(ctx, "JokeService.GetJokeTranslations")
+	defer func() { endSpan(span, err) }()
+
+	source, err := s.queries.GetJokeByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrJokeNotFound
+		}
+		s.log(ctx).ErrorContext(ctx, "failed to get joke by id", "error", err, "joke_id", id)
+		return nil, fmt.Errorf("failed to get joke by id: %w", err)
+	}
+	if model.ContentRating(source.Rating).Exceeds(filter.MaxRating) {
+		return nil, ErrJokeNotFound
+	}
+
+	params := database.GetJokeTranslationsParams{
+		ID:     id,
+		Rating: toDBRating(filter.MaxRating),
+	}
+
+	rows, err := s.queries.GetJokeTranslations(ctx, params)
+	if err != nil {
+		s.log(ctx).ErrorContext(ctx, "failed to get joke translations", "error", err, "joke_id", id)
+		return nil, fmt.Errorf("failed to get joke translations: %w", err)
+	}
+
+	ids := make([]int32, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+	jokeTags, err := s.GetTagsForJokes(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	translations := make([]model.Joke, 0, len(rows))
+	for _, row := range rows {
+		translations = append(translations, *s.buildJokeWithTags(row, jokeTags[row.ID]))
+	}
+
+	return translations, nil
+}
+
+// DeleteJokesBySource deletes every joke from the given source, for example
+// to honour a takedown request. Translations from other sources of a
+// deleted original stay linked, under the lowest remaining ID in their
+// group. It returns the number of jokes deleted.
+func (s *JokeService) DeleteJokesBySource(ctx context.Context, source string) (_ int64, err error) {
+	ctx, span := tracer.Start(ctx, "JokeService.DeleteJokesBySource")
+	defer func() { endSpan(span, err) }()
+
+	if source == "" {
+		return 0, ErrInvalidInput
+	}
+
+	var deleted int64
+	err = inTx(ctx, s.db, s.queries, func(q *database.Queries) error {
+		reparented, err := q.ReparentTranslationsBySource(ctx, toPgText(source))
+		if err != nil {
+			return fmt.Errorf("failed to reparent translations: %w", err)
+		}
+		if reparented > 0 {
+			s.log(ctx).InfoContext(ctx, "reparented translations of deleted jokes", "source", source, "reparented", reparented)
+		}
+
+		rows, err := q.DeleteJokesBySource(ctx, toPgText(source))
+		if err != nil {
+			return fmt.Errorf("failed to delete jokes by source: %w", err)
+		}
+
+		for _, row := range rows {
+			joke := s.buildJokeWithTags(database.Joke{
+				ID:              row.ID,
+				Setup:           row.Setup,
+				Punchline:       row.Punchline,
+				Category:        row.Category,
+				CreatedAt:       row.CreatedAt,
+				UpdatedAt:       row.UpdatedAt,
+				Rating:          row.Rating,
+				ContentWarnings: row.ContentWarnings,
+				Flagged:         row.Flagged,
+				Language:        row.Language,
+				TranslationOf:   row.TranslationOf,
+				JokeType:        row.JokeType,
+				Parts:           row.Parts,
+				Author:          row.Author,
+				SourceName:      row.SourceName,
+				SourceUrl:       row.SourceUrl,
+				License:         row.License,
+			}, row.Tags)
+			if err := s.audit.Record(ctx, q, audit.ActionJokeDelete, audit.TargetJoke, strconv.Itoa(int(joke.ID)), joke, nil); err != nil {
+				return err
+			}
+		}
+		deleted = int64(len(rows))
+		return nil
+	})
+	if err != nil {
+		s.log(ctx).ErrorContext(ctx, "failed to delete jokes by source", "error", err, "source", source)
+		return 0, err
+	}
+
+	s.log(ctx).InfoContext(ctx, "deleted jokes by source", "source", source, "deleted", deleted)
+	return deleted, nil
+}
+
 // GetAllTags retrieves all available tags
-func (s *JokeService) GetAllTags(ctx context.Context) ([]string, error) {
+func (s *JokeService) GetAllTags(ctx context.Context) (_ []string, err error) {
+	ctx, span := tracer.Start(ctx, "JokeService.GetAllTags")
+	defer func() { endSpan(span, err) }()
+
 	tags, err := s.queries.GetAllTags(ctx)
 	if err != nil {
-		s.logger.Error("failed to get all tags", "error", err)
+		s.log(ctx).ErrorContext(ctx, "failed to get all tags", "error", err)
 		return nil, fmt.Errorf("failed to get all tags: %w", err)
 	}
 
 	return tags, nil
 }
 
-// CreateJoke creates a new joke with associated tags
-func (s *JokeService) CreateJoke(ctx context.Context, setup, punchline string, category *string, tagNames []string) (*model.Joke, error) {
-	if setup == "" || punchline == "" {
-		return nil, ErrInvalidInput
+// GetCategories retrieves every category with the number of jokes in it at
+// or below the filter's rating ceiling
+func (s *JokeService) GetCategories(ctx context.Context, filter JokeFilter) (_ []model.Category, err error) {
+	ctx, span := tracer.Start(ctx, "JokeService.GetCategories")
+	defer func() { endSpan(span, err) }()
+
+	rows, err := s.queries.GetCategories(ctx, toDBRating(filter.MaxRating))
+	if err != nil {
+		s.log(ctx).ErrorContext(ctx, "failed to get categories", "error", err)
+		return nil, fmt.Errorf("failed to get categories: %w", err)
 	}
 
-	// Convert category to pgtype.Text
-	var pgCategory pgtype.Text
-	if category != nil {
-		pgCategory = toPgText(*category)
+	categories := make([]model.Category, 0, len(rows))
+	for _, row := range rows {
+		categories = append(categories, model.Category{Name: row.Name, JokeCount: row.JokeCount})
 	}
+	return categories, nil
+}
 
-	// Create the joke
-	params := database.CreateJokeParams{
-		Setup:     setup,
-		Punchline: punchline,
-		Category:  pgCategory,
+// CreateJoke creates a new joke with associated tags. Submissions are run
+// through the content checker; flagged jokes are stored with the rating
+// implied by the matched terms so they never reach a lower ceiling.
+func (s *JokeService) CreateJoke(ctx context.Context, input NewJoke) (_ *model.Joke, err error) {
+	ctx, span := tracer.Start(ctx, "JokeService.CreateJoke")
+	defer func() { endSpan(span, err) }()
+
+	jokeType, parts, setup, punchline, err := composeParts(input)
+	if err != nil {
+		return nil, err
 	}
 
-	joke, err := s.queries.CreateJoke(ctx, params)
+	encodedParts, err := json.Marshal(parts)
 	if err != nil {
-		s.logger.Error("failed to create joke", "error", err)
-		return nil, fmt.Errorf("failed to create joke: %w", err)
+		return nil, fmt.Errorf("failed to encode joke parts: %w", err)
 	}
 
-	// Associate tags with the joke
-	for _, tagName := range tagNames {
-		if tagName == "" {
-			continue
+	rating := input.Rating
+	if rating == "" {
+		rating = model.RatingG
+	}
+	if rating.Rank() < 0 {
+		return nil, ErrInvalidInput
+	}
+
+	lang := s.fallbacks[0]
+	if input.Language != "" {
+		normalized, err := language.Normalize(input.Language)
+		if err != nil {
+			return nil, ErrInvalidInput
 		}
+		lang = normalized
+	}
 
-		// Try to get existing tag
-		tag, err := s.queries.GetTagByName(ctx, tagName)
+	var translationOf pgtype.Int4
+	if input.TranslationOf != nil {
+		source, err := s.queries.GetJokeByID(ctx, *input.TranslationOf)
 		if err != nil {
-			// Tag doesn't exist, create it
-			tag, err = s.queries.CreateTag(ctx, tagName)
-			if err != nil {
-				s.logger.Error("failed to create tag", "error", err, "tag", tagName)
-				// Continue with other tags rather than failing
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, ErrInvalidTranslation
+			}
+			s.log(ctx).ErrorContext(ctx, "failed to get joke by id", "error", err, "joke_id", *input.TranslationOf)
+			return nil, fmt.Errorf("failed to get joke by id: %w", err)
+		}
+
+		// Always link to the original so a translation group has a single root
+		translationOf = pgtype.Int4{Int32: source.ID, Valid: true}
+		if source.TranslationOf.Valid {
+			translationOf = source.TranslationOf
+		}
+	}
+
+	texts := []string{setup, punchline}
+	for _, part := range parts {
+		texts = append(texts, part.Text)
+	}
+
+	check := s.checker.Check(strings.Join(texts, "\n"), rating)
+	if check.Flagged {
+		s.log(ctx).WarnContext(ctx, "joke submission flagged by content checker",
+			"matches", check.Matches,
+			"declared_rating", rating,
+			"assigned_rating", check.Rating,
+		)
+		rating = check.Rating
+	}
+
+	// Convert category to pgtype.Text
+	pgCategory := toNullablePgText(input.Category)
+
+	// Create the joke
+	params := database.CreateJokeParams{
+		Setup:           setup,
+		Punchline:       punchline,
+		Category:        pgCategory,
+		Rating:          toDBRating(rating),
+		ContentWarnings: normalizeContentWarnings(input.ContentWarnings),
+		Flagged:         check.Flagged,
+		Language:        lang,
+		TranslationOf:   translationOf,
+		JokeType:        string(jokeType),
+		Parts:           encodedParts,
+		Author:          toNullablePgText(input.Author),
+		SourceName:      toNullablePgText(input.SourceName),
+		SourceUrl:       toNullablePgText(input.SourceURL),
+		License:         toNullablePgText(input.License),
+	}
+
+	// The joke, its tags and their audit events are committed together
+	var created *model.Joke
+	err = inTx(ctx, s.db, s.queries, func(q *database.Queries) error {
+		joke, err := q.CreateJoke(ctx, params)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+				// idx_jokes_translation_language: the group already has this language
+				return ErrInvalidTranslation
+			}
+			return fmt.Errorf("failed to create joke: %w", err)
+		}
+
+		// Associate tags with the joke, creating those that do not exist
+		for _, tagName := range input.Tags {
+			if tagName == "" {
 				continue
 			}
+
+			tag, err := s.tagByName(ctx, q, tagName)
+			if err != nil {
+				return err
+			}
+
+			err = q.AddJokeTag(ctx, database.AddJokeTagParams{
+				JokeID: joke.ID,
+				TagID:  tag.ID,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to associate tag %q with joke: %w", tagName, err)
+			}
 		}
 
-		// Associate tag with joke
-		err = s.queries.AddJokeTag(ctx, database.AddJokeTagParams{
-			JokeID: joke.ID,
-			TagID:  tag.ID,
-		})
+		// Get all tags for the created joke
+		tags, err := q.GetTagsForJoke(ctx, joke.ID)
 		if err != nil {
-			s.logger.Error("failed to associate tag with joke", "error", err, "joke_id", joke.ID, "tag_id", tag.ID)
-			// Continue with other tags
-			continue
+			return fmt.Errorf("failed to get tags for created joke: %w", err)
+		}
+
+		created = s.buildJokeWithTags(joke, tags)
+		return s.audit.Record(ctx, q, audit.ActionJokeCreate, audit.TargetJoke, strconv.Itoa(int(created.ID)), nil, created)
+	})
+	if err != nil {
+		if !errors.Is(err, ErrInvalidTranslation) {
+			s.log(ctx).ErrorContext(ctx, "failed to create joke", "error", err)
 		}
+		return nil, err
 	}
 
-	// Get all tags for the created joke
-	tags, err := s.queries.GetTagsForJoke(ctx, joke.ID)
+	// Flagged jokes were re-rated by the checker; only clean ones are announced
+	if !created.Flagged && s.publisher != nil {
+		s.publisher.Publish(*created)
+	}
+	return created, nil
+}
+
+// tagByName returns the tag called name, creating it, and recording its
+// creation, if it does not exist
+func (s *JokeService) tagByName(ctx context.Context, q *database.Queries, name string) (database.Tag, error) {
+	tag, err := q.GetTagByName(ctx, name)
+	if err == nil {
+		return tag, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return database.Tag{}, fmt.Errorf("failed to get tag %q: %w", name, err)
+	}
+
+	tag, err = q.CreateTag(ctx, name)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Created concurrently since the lookup
+		tag, err = q.GetTagByName(ctx, name)
+	} else if err == nil {
+		err = s.audit.Record(ctx, q, audit.ActionTagCreate, audit.TargetTag, tag.Name, nil, map[string]string{"name": tag.Name})
+	}
 	if err != nil {
-		s.logger.Error("failed to get tags for created joke", "error", err, "joke_id", joke.ID)
-		tags = []string{}
+		return database.Tag{}, fmt.Errorf("failed to create tag %q: %w", name, err)
 	}
+	return tag, nil
+}
 
-	return s.buildJokeWithTags(joke, tags), nil
+// normalizeContentWarnings lowercases, trims and de-duplicates warning flags
+func normalizeContentWarnings(warnings []string) []string {
+	result := []string{}
+	seen := make(map[string]bool)
+	for _, warning := range warnings {
+		warning = strings.ToLower(strings.TrimSpace(warning))
+		if warning == "" || seen[warning] {
+			continue
+		}
+		seen[warning] = true
+		result = append(result, warning)
+	}
+	return result
+}
+
+// composeParts validates a new joke's structure and returns its type, parts,
+// setup and punchline. Whichever of parts or setup/punchline the caller left
+// out is derived from the other so older clients can keep reading
+// setup/punchline for every joke type.
+func composeParts(input NewJoke) (model.JokeType, []model.JokePart, string, string, error) {
+	jokeType := input.Type
+	if jokeType == "" {
+		jokeType = model.TypeSetupPunchline
+	}
+
+	setup, punchline := input.Setup, input.Punchline
+	parts := input.Parts
+
+	switch jokeType {
+	case model.TypeSetupPunchline:
+		if len(parts) == 0 {
+			parts = []model.JokePart{{Text: setup}, {Text: punchline}}
+		}
+		if len(parts) != 2 {
+			return "", nil, "", "", ErrInvalidInput
+		}
+		if setup == "" && punchline == "" {
+			setup, punchline = parts[0].Text, parts[1].Text
+		}
+		if punchline == "" {
+			return "", nil, "", "", ErrInvalidInput
+		}
+
+	case model.TypeOneLiner:
+		if len(parts) == 0 {
+			line := strings.TrimSpace(setup + " " + punchline)
+			parts = []model.JokePart{{Text: line}}
+		}
+		if len(parts) != 1 {
+			return "", nil, "", "", ErrInvalidInput
+		}
+		if setup == "" && punchline == "" {
+			setup = parts[0].Text
+		}
+
+	case model.TypeKnockKnock, model.TypeMultiPart:
+		minParts := 2
+		if jokeType == model.TypeKnockKnock {
+			minParts = 3
+		}
+		if len(parts) < minParts {
+			return "", nil, "", "", ErrInvalidInput
+		}
+		if setup == "" && punchline == "" {
+			lines := make([]string, 0, len(parts)-1)
+			for _, part := range parts[:len(parts)-1] {
+				lines = append(lines, part.Text)
+			}
+			setup = strings.Join(lines, " ")
+			punchline = parts[len(parts)-1].Text
+		}
+
+	default:
+		return "", nil, "", "", ErrInvalidInput
+	}
+
+	// Check the parts as stored, including those derived from setup and
+	// punchline
+	for _, part := range parts {
+		if strings.TrimSpace(part.Text) == "" {
+			return "", nil, "", "", ErrInvalidInput
+		}
+	}
+	if setup == "" {
+		return "", nil, "", "", ErrInvalidInput
+	}
+
+	return jokeType, parts, setup, punchline, nil
+}
+
+// decodeParts returns a joke's stored parts, falling back to setup and
+// punchline for rows without valid parts
+func decodeParts(dbJoke database.Joke) []model.JokePart {
+	var parts []model.JokePart
+	if err := json.Unmarshal(dbJoke.Parts, &parts); err == nil && len(parts) > 0 {
+		return parts
+	}
+
+	if dbJoke.Punchline == "" {
+		return []model.JokePart{{Text: dbJoke.Setup}}
+	}
+	return []model.JokePart{{Text: dbJoke.Setup}, {Text: dbJoke.Punchline}}
 }
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/cdunlap/djaas/internal/service")
+
+// endSpan ends a service method's span, marking it failed if err is an
+// unexpected error. Not found and invalid input errors are answers to the
+// caller, so they are recorded as an event only.
+func endSpan(span trace.Span, err error) {
+	defer span.End()
+
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	if errors.Is(err, ErrNoJokesFound) || errors.Is(err, ErrJokeNotFound) ||
+		errors.Is(err, ErrInvalidInput) || errors.Is(err, ErrInvalidTranslation) {
+		return
+	}
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cdunlap/djaas/internal/database"
+	"github.com/jackc/pgx/v5"
+)
+
+// TxBeginner starts database transactions; *pgxpool.Pool implements it
+type TxBeginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// inTx runs fn with queries bound to a new transaction, committing it if
+// fn succeeds and rolling it back otherwise
+func inTx(ctx context.Context, db TxBeginner, queries *database.Queries, fn func(q *database.Queries) error) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(context.WithoutCancel(ctx))
+
+	if err := fn(queries.WithTx(tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
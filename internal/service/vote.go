@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cdunlap/djaas/internal/database"
+	"github.com/cdunlap/djaas/internal/model"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// pgForeignKeyViolation is the PostgreSQL foreign_key_violation error code
+const pgForeignKeyViolation = "23503"
+
+// VoteJoke records voter's vote on a joke, replacing any earlier vote of
+// theirs, and returns the joke's new totals
+func (s *JokeService) VoteJoke(ctx context.Context, jokeID int32, voter string, vote model.Vote) (_ *model.VoteTotals, err error) {
+	ctx, span := tracer.Start(ctx, "JokeService.VoteJoke")
+	defer func() { endSpan(span, err) }()
+
+	value := int16(1)
+	switch vote {
+	case model.VoteUp:
+	case model.VoteDown:
+		value = -1
+	default:
+		return nil, ErrInvalidInput
+	}
+
+	err = s.queries.UpsertJokeVote(ctx, database.UpsertJokeVoteParams{
+		JokeID: jokeID,
+		Voter:  voter,
+		Vote:   value,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgForeignKeyViolation {
+			return nil, ErrJokeNotFound
+		}
+		s.log(ctx).ErrorContext(ctx, "failed to record vote", "error", err, "joke_id", jokeID)
+		return nil, fmt.Errorf("failed to record vote: %w", err)
+	}
+
+	totals, err := s.queries.GetJokeVoteTotals(ctx, jokeID)
+	if err != nil {
+		s.log(ctx).ErrorContext(ctx, "failed to get vote totals", "error", err, "joke_id", jokeID)
+		return nil, fmt.Errorf("failed to get vote totals: %w", err)
+	}
+
+	return &model.VoteTotals{JokeID: jokeID, Up: totals.Up, Down: totals.Down}, nil
+}
@@ -0,0 +1,70 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// QueryTracer is a pgx.QueryTracer that records a client span per query.
+// Spans are named after the sqlc query ("-- name: GetRandomJoke :one") when
+// there is one. Query arguments are not recorded, as they can hold secrets
+// such as API key hashes.
+type QueryTracer struct {
+	tracer trace.Tracer
+}
+
+// NewQueryTracer creates a QueryTracer using the global tracer provider
+func NewQueryTracer() *QueryTracer {
+	return &QueryTracer{tracer: otel.Tracer("github.com/cdunlap/djaas/internal/database")}
+}
+
+// TraceQueryStart implements pgx.QueryTracer
+func (t *QueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	name, ok := queryName(data.SQL)
+	if !ok {
+		name = "query"
+	}
+	ctx, _ = t.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system.name", "postgresql"),
+			attribute.String("db.operation.name", name),
+			attribute.String("db.query.text", data.SQL),
+		),
+	)
+	return ctx
+}
+
+// TraceQueryEnd implements pgx.QueryTracer
+func (t *QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	defer span.End()
+
+	// An empty result is an answer, not a failure
+	if data.Err != nil && !errors.Is(data.Err, pgx.ErrNoRows) {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+		return
+	}
+	span.SetAttributes(attribute.Int64("db.response.affected_rows", data.CommandTag.RowsAffected()))
+}
+
+// queryName extracts the query name from sqlc's leading comment
+func queryName(sql string) (string, bool) {
+	rest, ok := strings.CutPrefix(sql, "-- name: ")
+	if !ok {
+		return "", false
+	}
+	name, _, _ := strings.Cut(rest, " ")
+	if name == "" {
+		return "", false
+	}
+	return name, true
+}
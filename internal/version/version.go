@@ -0,0 +1,79 @@
+// Package version describes the running build. Release builds set the
+// version, commit and build time with linker flags:
+//
+//	go build -ldflags "-X github.com/cdunlap/djaas/internal/version.version=v1.4.0 \
+//	    -X github.com/cdunlap/djaas/internal/version.commit=$(git rev-parse HEAD) \
+//	    -X github.com/cdunlap/djaas/internal/version.buildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Anything not set that way is taken from the build information the Go
+// toolchain records, which includes the commit when building from a git
+// checkout.
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Set with -ldflags "-X ..."
+var (
+	version   string
+	commit    string
+	buildTime string
+)
+
+// Unknown is reported for anything the build did not record
+const Unknown = "unknown"
+
+// Info identifies a build
+type Info struct {
+	Version string
+	Commit  string
+	// BuildTime is when the binary was built, or for builds without linker
+	// flags the time of the commit
+	BuildTime string
+	// Modified is set when built from a checkout with uncommitted changes
+	Modified  bool
+	GoVersion string
+}
+
+// Get returns the running build's information
+func Get() Info {
+	info := Info{
+		Version:   version,
+		Commit:    commit,
+		BuildTime: buildTime,
+		GoVersion: runtime.Version(),
+	}
+
+	if build, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "" && build.Main.Version != "(devel)" {
+			info.Version = build.Main.Version
+		}
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = setting.Value
+				}
+			case "vcs.time":
+				if info.BuildTime == "" {
+					info.BuildTime = setting.Value
+				}
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+	}
+
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	if info.Commit == "" {
+		info.Commit = Unknown
+	}
+	if info.BuildTime == "" {
+		info.BuildTime = Unknown
+	}
+	return info
+}
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_jokes_rating;
+ALTER TABLE jokes
+    DROP COLUMN IF EXISTS flagged,
+    DROP COLUMN IF EXISTS content_warnings,
+    DROP COLUMN IF EXISTS rating;
+DROP TYPE IF EXISTS content_rating;
@@ -0,0 +1,12 @@
+-- Content ratings, ordered from most to least family-friendly so that
+-- comparisons like rating <= 'pg' follow the enum declaration order
+CREATE TYPE content_rating AS ENUM ('g', 'pg', 'pg13', 'r');
+
+-- Add rating, optional content warnings and the moderation flag to jokes
+ALTER TABLE jokes
+    ADD COLUMN IF NOT EXISTS rating content_rating NOT NULL DEFAULT 'g',
+    ADD COLUMN IF NOT EXISTS content_warnings TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS flagged BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Add index for rating filtering
+CREATE INDEX IF NOT EXISTS idx_jokes_rating ON jokes(rating);
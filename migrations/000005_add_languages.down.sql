@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_jokes_search;
+DROP INDEX IF EXISTS idx_jokes_translation_language;
+DROP INDEX IF EXISTS idx_jokes_translation_of;
+DROP INDEX IF EXISTS idx_jokes_language;
+ALTER TABLE jokes
+    DROP COLUMN IF EXISTS translation_of,
+    DROP COLUMN IF EXISTS language;
+DROP FUNCTION IF EXISTS joke_search_config(TEXT);
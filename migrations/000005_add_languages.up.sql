@@ -0,0 +1,34 @@
+-- Map a joke language code to its full-text search configuration.
+-- Unknown languages fall back to the language-agnostic 'simple' config.
+CREATE OR REPLACE FUNCTION joke_search_config(lang TEXT)
+RETURNS regconfig AS $$
+    SELECT CASE lang
+        WHEN 'da' THEN 'danish'::regconfig
+        WHEN 'de' THEN 'german'::regconfig
+        WHEN 'en' THEN 'english'::regconfig
+        WHEN 'es' THEN 'spanish'::regconfig
+        WHEN 'fi' THEN 'finnish'::regconfig
+        WHEN 'fr' THEN 'french'::regconfig
+        WHEN 'it' THEN 'italian'::regconfig
+        WHEN 'nl' THEN 'dutch'::regconfig
+        WHEN 'no' THEN 'norwegian'::regconfig
+        WHEN 'pt' THEN 'portuguese'::regconfig
+        WHEN 'ru' THEN 'russian'::regconfig
+        WHEN 'sv' THEN 'swedish'::regconfig
+        ELSE 'simple'::regconfig
+    END
+$$ LANGUAGE sql IMMUTABLE;
+
+-- Add language code and translation link to jokes. Translations point at
+-- the original joke, so every joke sharing COALESCE(translation_of, id)
+-- is an equivalent joke in another language.
+ALTER TABLE jokes
+    ADD COLUMN IF NOT EXISTS language VARCHAR(10) NOT NULL DEFAULT 'en',
+    ADD COLUMN IF NOT EXISTS translation_of INTEGER REFERENCES jokes(id) ON DELETE SET NULL;
+
+-- Add indexes for language filtering, translation lookups and search
+CREATE INDEX IF NOT EXISTS idx_jokes_language ON jokes(language);
+CREATE INDEX IF NOT EXISTS idx_jokes_translation_of ON jokes(translation_of);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_jokes_translation_language ON jokes(COALESCE(translation_of, id), language);
+CREATE INDEX IF NOT EXISTS idx_jokes_search ON jokes
+    USING gin(to_tsvector(joke_search_config(language), setup || ' ' || punchline));
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_jokes_joke_type;
+ALTER TABLE jokes
+    DROP COLUMN IF EXISTS parts,
+    DROP COLUMN IF EXISTS joke_type;
@@ -0,0 +1,17 @@
+-- Add joke type and structured parts. Setup and punchline stay populated
+-- for every type so existing clients keep working.
+ALTER TABLE jokes
+    ADD COLUMN IF NOT EXISTS joke_type VARCHAR(20) NOT NULL DEFAULT 'setup_punchline'
+        CHECK (joke_type IN ('setup_punchline', 'one_liner', 'knock_knock', 'multi_part')),
+    ADD COLUMN IF NOT EXISTS parts JSONB NOT NULL DEFAULT '[]';
+
+-- Backfill parts for existing setup/punchline jokes
+UPDATE jokes
+SET parts = jsonb_build_array(
+    jsonb_build_object('text', setup),
+    jsonb_build_object('text', punchline)
+)
+WHERE parts = '[]';
+
+-- Add index for type filtering
+CREATE INDEX IF NOT EXISTS idx_jokes_joke_type ON jokes(joke_type);
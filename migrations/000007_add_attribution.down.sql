@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_jokes_source_name;
+ALTER TABLE jokes
+    DROP COLUMN IF EXISTS license,
+    DROP COLUMN IF EXISTS source_url,
+    DROP COLUMN IF EXISTS source_name,
+    DROP COLUMN IF EXISTS author;
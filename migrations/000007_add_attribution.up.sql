@@ -0,0 +1,9 @@
+-- Add author and source attribution to jokes
+ALTER TABLE jokes
+    ADD COLUMN IF NOT EXISTS author VARCHAR(200),
+    ADD COLUMN IF NOT EXISTS source_name VARCHAR(200),
+    ADD COLUMN IF NOT EXISTS source_url TEXT,
+    ADD COLUMN IF NOT EXISTS license VARCHAR(100);
+
+-- Add index for source filtering and takedowns
+CREATE INDEX IF NOT EXISTS idx_jokes_source_name ON jokes(source_name);
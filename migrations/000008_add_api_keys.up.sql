@@ -0,0 +1,18 @@
+-- API keys are stored as SHA-256 hashes. The prefix is stored in the clear so
+-- a presented key can be looked up before its hash is compared.
+CREATE TABLE IF NOT EXISTS api_keys (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    owner VARCHAR(200) NOT NULL,
+    key_prefix VARCHAR(16) NOT NULL UNIQUE,
+    key_hash VARCHAR(64) NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    max_rating content_rating,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Add index for listing keys by owner
+CREATE INDEX IF NOT EXISTS idx_api_keys_owner ON api_keys(owner);
@@ -0,0 +1,3 @@
+-- Rate limit tier for each API key; tiers are defined in configuration
+ALTER TABLE api_keys
+    ADD COLUMN IF NOT EXISTS tier VARCHAR(50) NOT NULL DEFAULT 'free';
@@ -0,0 +1,21 @@
+-- Audit trail of write and admin operations. before_state and after_state
+-- hold the affected record as JSON; either is NULL when the record did not
+-- exist on that side of the operation.
+CREATE TABLE IF NOT EXISTS audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    actor VARCHAR(200) NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    target_type VARCHAR(20) NOT NULL,
+    target_id VARCHAR(200) NOT NULL,
+    before_state JSONB,
+    after_state JSONB,
+    client_ip VARCHAR(45),
+    request_id VARCHAR(128)
+);
+
+-- Add indexes for the audit endpoint's filters, newest first
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor ON audit_events(actor, id DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_events_action ON audit_events(action, id DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_events_target ON audit_events(target_type, target_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_events_occurred_at ON audit_events(occurred_at);
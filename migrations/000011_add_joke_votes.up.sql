@@ -0,0 +1,10 @@
+-- One vote per caller per joke: 1 for up, -1 for down. voter is the
+-- caller's principal subject, so changing a vote replaces it.
+CREATE TABLE IF NOT EXISTS joke_votes (
+    joke_id INTEGER NOT NULL REFERENCES jokes(id) ON DELETE CASCADE,
+    voter VARCHAR(200) NOT NULL,
+    vote SMALLINT NOT NULL CHECK (vote IN (-1, 1)),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (joke_id, voter)
+);
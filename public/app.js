@@ -6,12 +6,14 @@ const searchInput = document.getElementById('searchInput');
 const searchBtn = document.getElementById('searchBtn');
 const categorySelect = document.getElementById('categorySelect');
 const tagSelect = document.getElementById('tagSelect');
+const typeSelect = document.getElementById('typeSelect');
 const randomBtn = document.getElementById('randomBtn');
 const clearBtn = document.getElementById('clearBtn');
 
 const jokeDisplay = document.getElementById('jokeDisplay');
 const jokeSetup = document.getElementById('jokeSetup');
 const jokePunchline = document.getElementById('jokePunchline');
+const jokeParts = document.getElementById('jokeParts');
 const jokeCategory = document.getElementById('jokeCategory');
 const jokeTags = document.getElementById('jokeTags');
 
@@ -53,6 +55,10 @@ async function fetchJoke(params = {}) {
             queryParams.append('tags', params.tags.join(','));
         }
 
+        if (params.type) {
+            queryParams.append('type', params.type);
+        }
+
         const url = `${API_BASE_URL}/joke${queryParams.toString() ? '?' + queryParams.toString() : ''}`;
 
         const response = await fetch(url);
@@ -74,8 +80,25 @@ async function fetchJoke(params = {}) {
 
 // UI Functions
 function displayJoke(joke) {
-    jokeSetup.textContent = joke.setup;
-    jokePunchline.textContent = joke.punchline;
+    jokeParts.innerHTML = '';
+
+    switch (joke.type) {
+        case 'one_liner':
+            // One-liners have no separate punchline
+            jokeSetup.textContent = joke.setup;
+            jokePunchline.textContent = '';
+            showSetupPunchline(true, false);
+            break;
+        case 'knock_knock':
+        case 'multi_part':
+            displayParts(joke.parts || []);
+            showSetupPunchline(false, false);
+            break;
+        default:
+            jokeSetup.textContent = joke.setup;
+            jokePunchline.textContent = joke.punchline;
+            showSetupPunchline(true, true);
+    }
 
     if (joke.category) {
         jokeCategory.textContent = joke.category;
@@ -101,6 +124,33 @@ function displayJoke(joke) {
     jokeDisplay.classList.remove('hidden');
 }
 
+// Render ordered parts as dialogue lines; the last line is the punchline
+function displayParts(parts) {
+    parts.forEach((part, index) => {
+        const lineEl = document.createElement('li');
+        lineEl.className = index === parts.length - 1 ? 'line punchline' : 'line';
+
+        if (part.speaker) {
+            const speakerEl = document.createElement('span');
+            speakerEl.className = 'speaker';
+            speakerEl.textContent = `${part.speaker}: `;
+            lineEl.appendChild(speakerEl);
+        }
+
+        lineEl.appendChild(document.createTextNode(part.text));
+        jokeParts.appendChild(lineEl);
+    });
+    jokeParts.classList.remove('hidden');
+}
+
+function showSetupPunchline(showSetup, showPunchline) {
+    jokeSetup.classList.toggle('hidden', !showSetup);
+    jokePunchline.classList.toggle('hidden', !showPunchline);
+    if (showSetup || showPunchline) {
+        jokeParts.classList.add('hidden');
+    }
+}
+
 function showLoading() {
     loading.classList.remove('hidden');
     jokeDisplay.classList.add('hidden');
@@ -132,12 +182,14 @@ function hideEmptyState() {
 function handleSearch() {
     const search = searchInput.value.trim();
     const category = categorySelect.value;
+    const type = typeSelect.value;
     const selectedTags = Array.from(tagSelect.selectedOptions).map(opt => opt.value);
 
     fetchJoke({
         search: search || undefined,
         category: category || undefined,
-        tags: selectedTags.length > 0 ? selectedTags : undefined
+        tags: selectedTags.length > 0 ? selectedTags : undefined,
+        type: type || undefined
     });
 }
 
@@ -148,6 +200,7 @@ function handleRandom() {
 function handleClear() {
     searchInput.value = '';
     categorySelect.value = '';
+    typeSelect.value = '';
     tagSelect.selectedIndex = -1;
 
     jokeDisplay.classList.add('hidden');
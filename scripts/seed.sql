@@ -107,3 +107,12 @@ INSERT INTO jokes (setup, punchline, category) VALUES
 ('What did the biologist wear to impress?', 'Designer genes!', 'science'),
 ('Why do biologists look forward to casual Fridays?', 'They''re allowed to wear genes to work!', 'science'),
 ('What did one quantum physicist say when he wanted to fight another quantum physicist?', 'Let me atom!', 'science');
+
+-- Knock-knock and one-liner jokes (setup/punchline kept for older clients)
+INSERT INTO jokes (setup, punchline, category, joke_type, parts) VALUES
+('Knock, knock. Who''s there? Lettuce. Lettuce who?', 'Lettuce in, it''s cold out here!', 'food', 'knock_knock',
+ '[{"speaker": "A", "text": "Knock, knock."}, {"speaker": "B", "text": "Who''s there?"}, {"speaker": "A", "text": "Lettuce."}, {"speaker": "B", "text": "Lettuce who?"}, {"speaker": "A", "text": "Lettuce in, it''s cold out here!"}]'),
+('Knock, knock. Who''s there? Interrupting cow. Interrupting cow wh-', 'MOO!', 'animals', 'knock_knock',
+ '[{"speaker": "A", "text": "Knock, knock."}, {"speaker": "B", "text": "Who''s there?"}, {"speaker": "A", "text": "Interrupting cow."}, {"speaker": "B", "text": "Interrupting cow wh-"}, {"speaker": "A", "text": "MOO!"}]'),
+('I only know 25 letters of the alphabet. I don''t know y.', '', 'general', 'one_liner',
+ '[{"text": "I only know 25 letters of the alphabet. I don''t know y."}]');
@@ -1,39 +1,56 @@
 -- name: GetRandomJoke :one
-SELECT id, setup, punchline, category, created_at, updated_at
+SELECT id, setup, punchline, category, created_at, updated_at, rating, content_warnings, flagged, language, translation_of, joke_type, parts, author, source_name, source_url, license
 FROM jokes
-ORDER BY RANDOM()
+WHERE rating <= $1
+  AND language = ANY($2::text[])
+  AND (joke_type = $3 OR $3 = '')
+  AND (source_name = $4 OR $4 = '')
+ORDER BY array_position($2::text[], language), RANDOM()
 LIMIT 1;
 
 -- name: SearchJokes :one
-SELECT id, setup, punchline, category, created_at, updated_at
+SELECT id, setup, punchline, category, created_at, updated_at, rating, content_warnings, flagged, language, translation_of, joke_type, parts, author, source_name, source_url, license
 FROM jokes
-WHERE setup ILIKE '%' || $1 || '%'
-   OR punchline ILIKE '%' || $1 || '%'
-ORDER BY RANDOM()
+WHERE (setup ILIKE '%' || $1 || '%' OR punchline ILIKE '%' || $1 || '%'
+       OR to_tsvector(joke_search_config(language), setup || ' ' || punchline) @@ plainto_tsquery(joke_search_config(language), $1))
+  AND rating <= $2
+  AND language = ANY($3::text[])
+  AND (joke_type = $4 OR $4 = '')
+  AND (source_name = $5 OR $5 = '')
+ORDER BY array_position($3::text[], language), RANDOM()
 LIMIT 1;
 
 -- name: GetJokeByCategory :one
-SELECT id, setup, punchline, category, created_at, updated_at
+SELECT id, setup, punchline, category, created_at, updated_at, rating, content_warnings, flagged, language, translation_of, joke_type, parts, author, source_name, source_url, license
 FROM jokes
 WHERE category = $1
-ORDER BY RANDOM()
+  AND rating <= $2
+  AND language = ANY($3::text[])
+  AND (joke_type = $4 OR $4 = '')
+  AND (source_name = $5 OR $5 = '')
+ORDER BY array_position($3::text[], language), RANDOM()
 LIMIT 1;
 
 -- name: GetJokeByCategoryAndSearch :one
-SELECT id, setup, punchline, category, created_at, updated_at
+SELECT id, setup, punchline, category, created_at, updated_at, rating, content_warnings, flagged, language, translation_of, joke_type, parts, author, source_name, source_url, license
 FROM jokes
 WHERE category = $1
-  AND (setup ILIKE '%' || $2 || '%' OR punchline ILIKE '%' || $2 || '%')
-ORDER BY RANDOM()
+  AND (setup ILIKE '%' || $2 || '%' OR punchline ILIKE '%' || $2 || '%'
+       OR to_tsvector(joke_search_config(language), setup || ' ' || punchline) @@ plainto_tsquery(joke_search_config(language), $2))
+  AND rating <= $3
+  AND language = ANY($4::text[])
+  AND (joke_type = $5 OR $5 = '')
+  AND (source_name = $6 OR $6 = '')
+ORDER BY array_position($4::text[], language), RANDOM()
 LIMIT 1;
 
 -- name: CreateJoke :one
-INSERT INTO jokes (setup, punchline, category)
-VALUES ($1, $2, $3)
-RETURNING id, setup, punchline, category, created_at, updated_at;
+INSERT INTO jokes (setup, punchline, category, rating, content_warnings, flagged, language, translation_of, joke_type, parts, author, source_name, source_url, license)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+RETURNING id, setup, punchline, category, created_at, updated_at, rating, content_warnings, flagged, language, translation_of, joke_type, parts, author, source_name, source_url, license;
 
 -- name: GetJokeByID :one
-SELECT id, setup, punchline, category, created_at, updated_at
+SELECT id, setup, punchline, category, created_at, updated_at, rating, content_warnings, flagged, language, translation_of, joke_type, parts, author, source_name, source_url, license
 FROM jokes
 WHERE id = $1;
 
@@ -44,8 +61,38 @@ INNER JOIN joke_tags jt ON t.id = jt.tag_id
 WHERE jt.joke_id = $1
 ORDER BY t.name;
 
+-- name: GetTagsForJokes :many
+SELECT jt.joke_id, t.name
+FROM joke_tags jt
+INNER JOIN tags t ON t.id = jt.tag_id
+WHERE jt.joke_id = ANY($1::int[])
+ORDER BY jt.joke_id, t.name;
+
+-- name: ListJokes :many
+-- Pages oldest first; after_id is the last ID of the previous page, or 0.
+-- Empty filters match every joke, including an empty languages array.
+SELECT j.id, j.setup, j.punchline, j.category, j.created_at, j.updated_at, j.rating, j.content_warnings, j.flagged, j.language, j.translation_of, j.joke_type, j.parts, j.author, j.source_name, j.source_url, j.license
+FROM jokes j
+WHERE (j.category = sqlc.arg(category)::text OR sqlc.arg(category)::text = '')
+  AND (sqlc.arg(search)::text = ''
+       OR j.setup ILIKE '%' || sqlc.arg(search)::text || '%' OR j.punchline ILIKE '%' || sqlc.arg(search)::text || '%'
+       OR to_tsvector(joke_search_config(j.language), j.setup || ' ' || j.punchline) @@ plainto_tsquery(joke_search_config(j.language), sqlc.arg(search)::text))
+  AND (cardinality(sqlc.arg(tags)::text[]) = 0 OR j.id IN (
+    SELECT jt.joke_id
+    FROM joke_tags jt
+    INNER JOIN tags t ON jt.tag_id = t.id
+    WHERE t.name = ANY(sqlc.arg(tags)::text[])
+))
+  AND j.rating <= sqlc.arg(rating)
+  AND (cardinality(sqlc.arg(languages)::text[]) = 0 OR j.language = ANY(sqlc.arg(languages)::text[]))
+  AND (j.joke_type = sqlc.arg(joke_type)::text OR sqlc.arg(joke_type)::text = '')
+  AND (j.source_name = sqlc.arg(source_name)::text OR sqlc.arg(source_name)::text = '')
+  AND j.id > sqlc.arg(after_id)::int
+ORDER BY j.id
+LIMIT sqlc.arg(row_limit);
+
 -- name: GetJokeByTags :one
-SELECT j.id, j.setup, j.punchline, j.category, j.created_at, j.updated_at
+SELECT j.id, j.setup, j.punchline, j.category, j.created_at, j.updated_at, j.rating, j.content_warnings, j.flagged, j.language, j.translation_of, j.joke_type, j.parts, j.author, j.source_name, j.source_url, j.license
 FROM jokes j
 WHERE j.id IN (
     SELECT DISTINCT jt.joke_id
@@ -53,11 +100,15 @@ WHERE j.id IN (
     INNER JOIN tags t ON jt.tag_id = t.id
     WHERE t.name = ANY($1::text[])
 )
-ORDER BY RANDOM()
+  AND j.rating <= $2
+  AND j.language = ANY($3::text[])
+  AND (j.joke_type = $4 OR $4 = '')
+  AND (j.source_name = $5 OR $5 = '')
+ORDER BY array_position($3::text[], j.language), RANDOM()
 LIMIT 1;
 
 -- name: GetJokeByTagsAndCategory :one
-SELECT j.id, j.setup, j.punchline, j.category, j.created_at, j.updated_at
+SELECT j.id, j.setup, j.punchline, j.category, j.created_at, j.updated_at, j.rating, j.content_warnings, j.flagged, j.language, j.translation_of, j.joke_type, j.parts, j.author, j.source_name, j.source_url, j.license
 FROM jokes j
 WHERE j.category = $2
   AND j.id IN (
@@ -66,11 +117,15 @@ WHERE j.category = $2
     INNER JOIN tags t ON jt.tag_id = t.id
     WHERE t.name = ANY($1::text[])
 )
-ORDER BY RANDOM()
+  AND j.rating <= $3
+  AND j.language = ANY($4::text[])
+  AND (j.joke_type = $5 OR $5 = '')
+  AND (j.source_name = $6 OR $6 = '')
+ORDER BY array_position($4::text[], j.language), RANDOM()
 LIMIT 1;
 
 -- name: GetJokeByTagsAndSearch :one
-SELECT j.id, j.setup, j.punchline, j.category, j.created_at, j.updated_at
+SELECT j.id, j.setup, j.punchline, j.category, j.created_at, j.updated_at, j.rating, j.content_warnings, j.flagged, j.language, j.translation_of, j.joke_type, j.parts, j.author, j.source_name, j.source_url, j.license
 FROM jokes j
 WHERE j.id IN (
     SELECT DISTINCT jt.joke_id
@@ -78,12 +133,17 @@ WHERE j.id IN (
     INNER JOIN tags t ON jt.tag_id = t.id
     WHERE t.name = ANY($1::text[])
 )
-  AND (j.setup ILIKE '%' || $2 || '%' OR j.punchline ILIKE '%' || $2 || '%')
-ORDER BY RANDOM()
+  AND (j.setup ILIKE '%' || $2 || '%' OR j.punchline ILIKE '%' || $2 || '%'
+       OR to_tsvector(joke_search_config(j.language), j.setup || ' ' || j.punchline) @@ plainto_tsquery(joke_search_config(j.language), $2))
+  AND j.rating <= $3
+  AND j.language = ANY($4::text[])
+  AND (j.joke_type = $5 OR $5 = '')
+  AND (j.source_name = $6 OR $6 = '')
+ORDER BY array_position($4::text[], j.language), RANDOM()
 LIMIT 1;
 
 -- name: GetJokeByAllFilters :one
-SELECT j.id, j.setup, j.punchline, j.category, j.created_at, j.updated_at
+SELECT j.id, j.setup, j.punchline, j.category, j.created_at, j.updated_at, j.rating, j.content_warnings, j.flagged, j.language, j.translation_of, j.joke_type, j.parts, j.author, j.source_name, j.source_url, j.license
 FROM jokes j
 WHERE j.category = $2
   AND j.id IN (
@@ -92,15 +152,100 @@ WHERE j.category = $2
     INNER JOIN tags t ON jt.tag_id = t.id
     WHERE t.name = ANY($1::text[])
 )
-  AND (j.setup ILIKE '%' || $3 || '%' OR j.punchline ILIKE '%' || $3 || '%')
-ORDER BY RANDOM()
+  AND (j.setup ILIKE '%' || $3 || '%' OR j.punchline ILIKE '%' || $3 || '%'
+       OR to_tsvector(joke_search_config(j.language), j.setup || ' ' || j.punchline) @@ plainto_tsquery(joke_search_config(j.language), $3))
+  AND j.rating <= $4
+  AND j.language = ANY($5::text[])
+  AND (j.joke_type = $6 OR $6 = '')
+  AND (j.source_name = $7 OR $7 = '')
+ORDER BY array_position($5::text[], j.language), RANDOM()
 LIMIT 1;
 
+-- name: GetSimilarJokes :many
+-- Ranks jokes by shared tags, matching category and trigram similarity of
+-- their text to the source joke
+WITH source AS (
+    SELECT s.id, s.category, s.language, s.setup || ' ' || s.punchline AS body
+    FROM jokes s
+    WHERE s.id = $1
+)
+SELECT j.id, j.setup, j.punchline, j.category, j.created_at, j.updated_at, j.rating, j.content_warnings, j.flagged, j.language, j.translation_of, j.joke_type, j.parts, j.author, j.source_name, j.source_url, j.license,
+    (
+        (SELECT COUNT(*)
+         FROM joke_tags jt
+         INNER JOIN joke_tags st ON st.tag_id = jt.tag_id AND st.joke_id = source.id
+         WHERE jt.joke_id = j.id)
+        + CASE WHEN j.category = source.category THEN 1 ELSE 0 END
+        + 2 * similarity(j.setup || ' ' || j.punchline, source.body)
+    )::float8 AS score
+FROM jokes j
+CROSS JOIN source
+WHERE j.id <> source.id
+  AND j.language = source.language
+  AND j.rating <= $2
+ORDER BY score DESC, j.id
+LIMIT $3;
+
+-- name: GetJokeTranslations :many
+-- Returns every other joke in the same translation group as the given joke
+SELECT j.id, j.setup, j.punchline, j.category, j.created_at, j.updated_at, j.rating, j.content_warnings, j.flagged, j.language, j.translation_of, j.joke_type, j.parts, j.author, j.source_name, j.source_url, j.license
+FROM jokes j
+WHERE COALESCE(j.translation_of, j.id) = (
+    SELECT COALESCE(s.translation_of, s.id)
+    FROM jokes s
+    WHERE s.id = $1
+)
+  AND j.id <> $1
+  AND j.rating <= $2
+ORDER BY j.language, j.id;
+
+-- name: ReparentTranslationsBySource :execrows
+-- Moves the surviving translations of each original from the source under
+-- the lowest remaining ID in its group, before the originals are deleted,
+-- so that ON DELETE SET NULL does not split the group apart
+WITH groups AS (
+    SELECT j.translation_of AS old_root, MIN(j.id) AS new_root
+    FROM jokes j
+    JOIN jokes o ON o.id = j.translation_of
+    WHERE o.source_name = $1
+      AND j.source_name IS DISTINCT FROM $1
+    GROUP BY j.translation_of
+)
+UPDATE jokes
+SET translation_of = NULLIF(g.new_root, jokes.id)
+FROM groups g
+WHERE jokes.translation_of = g.old_root
+  AND jokes.source_name IS DISTINCT FROM $1;
+
+-- name: DeleteJokesBySource :many
+-- Returns the deleted jokes with their tags, which the outer query still
+-- sees as the delete only takes effect when the statement ends
+WITH deleted AS (
+    DELETE FROM jokes
+    WHERE source_name = $1
+    RETURNING id, setup, punchline, category, created_at, updated_at, rating, content_warnings, flagged, language, translation_of, joke_type, parts, author, source_name, source_url, license
+)
+SELECT d.id, d.setup, d.punchline, d.category, d.created_at, d.updated_at, d.rating, d.content_warnings, d.flagged, d.language, d.translation_of, d.joke_type, d.parts, d.author, d.source_name, d.source_url, d.license,
+       COALESCE(array_agg(t.name ORDER BY t.name) FILTER (WHERE t.name IS NOT NULL), '{}')::text[] AS tags
+FROM deleted d
+LEFT JOIN joke_tags jt ON jt.joke_id = d.id
+LEFT JOIN tags t ON t.id = jt.tag_id
+GROUP BY d.id, d.setup, d.punchline, d.category, d.created_at, d.updated_at, d.rating, d.content_warnings, d.flagged, d.language, d.translation_of, d.joke_type, d.parts, d.author, d.source_name, d.source_url, d.license
+ORDER BY d.id;
+
 -- name: GetAllTags :many
 SELECT name
 FROM tags
 ORDER BY name ASC;
 
+-- name: GetCategories :many
+SELECT category::text AS name, COUNT(*) AS joke_count
+FROM jokes
+WHERE category IS NOT NULL
+  AND rating <= $1
+GROUP BY category
+ORDER BY category;
+
 -- name: CreateTag :one
 INSERT INTO tags (name)
 VALUES ($1)
@@ -116,3 +261,62 @@ WHERE name = $1;
 INSERT INTO joke_tags (joke_id, tag_id)
 VALUES ($1, $2)
 ON CONFLICT (joke_id, tag_id) DO NOTHING;
+
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (name, owner, key_prefix, key_hash, scopes, max_rating, expires_at, tier)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, name, owner, key_prefix, key_hash, scopes, max_rating, expires_at, last_used_at, revoked_at, created_at, tier;
+
+-- name: GetAPIKeyByPrefix :one
+SELECT id, name, owner, key_prefix, key_hash, scopes, max_rating, expires_at, last_used_at, revoked_at, created_at, tier
+FROM api_keys
+WHERE key_prefix = $1;
+
+-- name: ListAPIKeys :many
+SELECT id, name, owner, key_prefix, key_hash, scopes, max_rating, expires_at, last_used_at, revoked_at, created_at, tier
+FROM api_keys
+ORDER BY id;
+
+-- name: RevokeAPIKey :one
+UPDATE api_keys
+SET revoked_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND revoked_at IS NULL
+RETURNING id, name, owner, key_prefix, key_hash, scopes, max_rating, expires_at, last_used_at, revoked_at, created_at, tier;
+
+-- name: TouchAPIKey :exec
+-- Records key usage at most once a minute to avoid a write per request
+UPDATE api_keys
+SET last_used_at = CURRENT_TIMESTAMP
+WHERE id = $1
+  AND (last_used_at IS NULL OR last_used_at < CURRENT_TIMESTAMP - INTERVAL '1 minute');
+
+-- name: CreateAuditEvent :exec
+INSERT INTO audit_events (actor, action, target_type, target_id, before_state, after_state, client_ip, request_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+
+-- name: ListAuditEvents :many
+-- Pages newest first; before_id is the last ID of the previous page, or 0
+SELECT id, occurred_at, actor, action, target_type, target_id, before_state, after_state, client_ip, request_id
+FROM audit_events
+WHERE (actor = sqlc.arg(actor) OR sqlc.arg(actor) = '')
+  AND (action = sqlc.arg(action) OR sqlc.arg(action) = '')
+  AND (target_type = sqlc.arg(target_type) OR sqlc.arg(target_type) = '')
+  AND (target_id = sqlc.arg(target_id) OR sqlc.arg(target_id) = '')
+  AND (occurred_at >= sqlc.narg(since) OR sqlc.narg(since) IS NULL)
+  AND (occurred_at < sqlc.narg(until) OR sqlc.narg(until) IS NULL)
+  AND (id < sqlc.arg(before_id) OR sqlc.arg(before_id)::bigint = 0)
+ORDER BY id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: UpsertJokeVote :exec
+INSERT INTO joke_votes (joke_id, voter, vote)
+VALUES ($1, $2, $3)
+ON CONFLICT (joke_id, voter)
+DO UPDATE SET vote = EXCLUDED.vote, updated_at = CURRENT_TIMESTAMP;
+
+-- name: GetJokeVoteTotals :one
+SELECT
+    COUNT(*) FILTER (WHERE vote = 1)::bigint AS up,
+    COUNT(*) FILTER (WHERE vote = -1)::bigint AS down
+FROM joke_votes
+WHERE joke_id = $1;
@@ -1,10 +1,45 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE FUNCTION joke_search_config(lang TEXT)
+RETURNS regconfig AS $$
+    SELECT CASE lang
+        WHEN 'da' THEN 'danish'::regconfig
+        WHEN 'de' THEN 'german'::regconfig
+        WHEN 'en' THEN 'english'::regconfig
+        WHEN 'es' THEN 'spanish'::regconfig
+        WHEN 'fi' THEN 'finnish'::regconfig
+        WHEN 'fr' THEN 'french'::regconfig
+        WHEN 'it' THEN 'italian'::regconfig
+        WHEN 'nl' THEN 'dutch'::regconfig
+        WHEN 'no' THEN 'norwegian'::regconfig
+        WHEN 'pt' THEN 'portuguese'::regconfig
+        WHEN 'ru' THEN 'russian'::regconfig
+        WHEN 'sv' THEN 'swedish'::regconfig
+        ELSE 'simple'::regconfig
+    END
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE TYPE content_rating AS ENUM ('g', 'pg', 'pg13', 'r');
+
 CREATE TABLE jokes (
     id SERIAL PRIMARY KEY,
     setup TEXT NOT NULL,
     punchline TEXT NOT NULL,
     category VARCHAR(50),
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    rating content_rating NOT NULL DEFAULT 'g',
+    content_warnings TEXT[] NOT NULL DEFAULT '{}',
+    flagged BOOLEAN NOT NULL DEFAULT FALSE,
+    language VARCHAR(10) NOT NULL DEFAULT 'en',
+    translation_of INTEGER REFERENCES jokes(id) ON DELETE SET NULL,
+    joke_type VARCHAR(20) NOT NULL DEFAULT 'setup_punchline'
+        CHECK (joke_type IN ('setup_punchline', 'one_liner', 'knock_knock', 'multi_part')),
+    parts JSONB NOT NULL DEFAULT '[]',
+    author VARCHAR(200),
+    source_name VARCHAR(200),
+    source_url TEXT,
+    license VARCHAR(100)
 );
 
 CREATE TABLE tags (
@@ -23,3 +58,56 @@ CREATE TABLE joke_tags (
 CREATE INDEX idx_tags_name ON tags(name);
 CREATE INDEX idx_joke_tags_joke_id ON joke_tags(joke_id);
 CREATE INDEX idx_joke_tags_tag_id ON joke_tags(tag_id);
+
+CREATE INDEX idx_jokes_rating ON jokes(rating);
+CREATE INDEX idx_jokes_language ON jokes(language);
+CREATE INDEX idx_jokes_translation_of ON jokes(translation_of);
+CREATE UNIQUE INDEX idx_jokes_translation_language ON jokes(COALESCE(translation_of, id), language);
+CREATE INDEX idx_jokes_search ON jokes
+    USING gin(to_tsvector(joke_search_config(language), setup || ' ' || punchline));
+CREATE INDEX idx_jokes_joke_type ON jokes(joke_type);
+CREATE INDEX idx_jokes_source_name ON jokes(source_name);
+
+CREATE TABLE api_keys (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    owner VARCHAR(200) NOT NULL,
+    key_prefix VARCHAR(16) NOT NULL UNIQUE,
+    key_hash VARCHAR(64) NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    max_rating content_rating,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    tier VARCHAR(50) NOT NULL DEFAULT 'free'
+);
+
+CREATE INDEX idx_api_keys_owner ON api_keys(owner);
+
+CREATE TABLE audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    actor VARCHAR(200) NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    target_type VARCHAR(20) NOT NULL,
+    target_id VARCHAR(200) NOT NULL,
+    before_state JSONB,
+    after_state JSONB,
+    client_ip VARCHAR(45),
+    request_id VARCHAR(128)
+);
+
+CREATE INDEX idx_audit_events_actor ON audit_events(actor, id DESC);
+CREATE INDEX idx_audit_events_action ON audit_events(action, id DESC);
+CREATE INDEX idx_audit_events_target ON audit_events(target_type, target_id, id DESC);
+CREATE INDEX idx_audit_events_occurred_at ON audit_events(occurred_at);
+
+CREATE TABLE joke_votes (
+    joke_id INTEGER NOT NULL REFERENCES jokes(id) ON DELETE CASCADE,
+    voter VARCHAR(200) NOT NULL,
+    vote SMALLINT NOT NULL CHECK (vote IN (-1, 1)),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (joke_id, voter)
+);